@@ -2,32 +2,20 @@ package main
 
 import (
 	"context"
-	"flag"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
-	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/cli"
 	"github.com/AntonKosov/git-backups/internal/config"
 	"github.com/AntonKosov/git-backups/internal/git"
-	"github.com/AntonKosov/git-backups/internal/git/backup"
 	"github.com/AntonKosov/git-backups/internal/github"
 	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/metrics"
 )
 
 func main() {
-	debug := flag.Bool("debug", false, "enable debug logging")
-	flag.Parse()
-
-	logLevel := slog.LevelInfo
-	if *debug {
-		logLevel = slog.LevelDebug
-	}
-
-	logHandler := clog.NewHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
-	slog.SetDefault(slog.New(logHandler))
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -37,17 +25,40 @@ func main() {
 		sig := <-termSig
 		slog.InfoContext(ctx, "Terminating app...", "signal", sig)
 		cancel()
+
+		sig = <-termSig
+		slog.WarnContext(ctx, "Forcing exit", "signal", sig)
+		os.Exit(cli.ExitFailure)
 	}()
 
-	conf, err := config.ReadConfig("config.yaml")
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to read config", "error", err)
-		os.Exit(1)
-	}
-
-	err = launcher.Run(ctx, conf, backup.NewService(git.Git{}), github.Reader{})
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to backup", "error", err)
-		os.Exit(1)
-	}
+	// SIGHUP and SIGUSR1 start a run immediately in the daemon mode. Signals received during a run are
+	// coalesced into one follow-up run.
+	trigger := make(chan struct{}, 1)
+	triggerSig := make(chan os.Signal, 1)
+	signal.Notify(triggerSig, syscall.SIGHUP, syscall.SIGUSR1)
+	go func() {
+		for sig := range triggerSig {
+			slog.InfoContext(ctx, "Run requested", "signal", sig)
+			select {
+			case trigger <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	exitCode := cli.Run(ctx, os.Args[1:], os.Stdout, os.Stderr, cli.Dependencies{
+		ConfigService: config.Reader{},
+		NewBackupService: func(conf config.Config) (launcher.BackupService, func()) {
+			return cli.NewBackupService(git.Git{}, conf)
+		},
+		ReaderService: github.Reader{},
+		RestoreGit:    git.Git{},
+		RepoCreator:   github.Writer{},
+		BundleGit:     git.Git{},
+		Metrics:       metrics.NewCollector(),
+		Trigger:       trigger,
+	})
+
+	cancel()
+	os.Exit(exitCode)
 }
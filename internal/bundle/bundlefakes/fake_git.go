@@ -0,0 +1,205 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package bundlefakes
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+)
+
+type FakeGit struct {
+	CreateBundleStub        func(context.Context, string, io.Writer, []string) error
+	createBundleMutex       sync.RWMutex
+	createBundleArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 io.Writer
+		arg4 []string
+	}
+	createBundleReturns struct {
+		result1 error
+	}
+	createBundleReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RefsStub        func(context.Context, string) (map[string]string, error)
+	refsMutex       sync.RWMutex
+	refsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	refsReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	refsReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeGit) CreateBundle(arg1 context.Context, arg2 string, arg3 io.Writer, arg4 []string) error {
+	var arg4Copy []string
+	if arg4 != nil {
+		arg4Copy = make([]string, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	fake.createBundleMutex.Lock()
+	ret, specificReturn := fake.createBundleReturnsOnCall[len(fake.createBundleArgsForCall)]
+	fake.createBundleArgsForCall = append(fake.createBundleArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 io.Writer
+		arg4 []string
+	}{arg1, arg2, arg3, arg4Copy})
+	stub := fake.CreateBundleStub
+	fakeReturns := fake.createBundleReturns
+	fake.recordInvocation("CreateBundle", []interface{}{arg1, arg2, arg3, arg4Copy})
+	fake.createBundleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) CreateBundleCallCount() int {
+	fake.createBundleMutex.RLock()
+	defer fake.createBundleMutex.RUnlock()
+	return len(fake.createBundleArgsForCall)
+}
+
+func (fake *FakeGit) CreateBundleCalls(stub func(context.Context, string, io.Writer, []string) error) {
+	fake.createBundleMutex.Lock()
+	defer fake.createBundleMutex.Unlock()
+	fake.CreateBundleStub = stub
+}
+
+func (fake *FakeGit) CreateBundleArgsForCall(i int) (context.Context, string, io.Writer, []string) {
+	fake.createBundleMutex.RLock()
+	defer fake.createBundleMutex.RUnlock()
+	argsForCall := fake.createBundleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGit) CreateBundleReturns(result1 error) {
+	fake.createBundleMutex.Lock()
+	defer fake.createBundleMutex.Unlock()
+	fake.CreateBundleStub = nil
+	fake.createBundleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) CreateBundleReturnsOnCall(i int, result1 error) {
+	fake.createBundleMutex.Lock()
+	defer fake.createBundleMutex.Unlock()
+	fake.CreateBundleStub = nil
+	if fake.createBundleReturnsOnCall == nil {
+		fake.createBundleReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createBundleReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) Refs(arg1 context.Context, arg2 string) (map[string]string, error) {
+	fake.refsMutex.Lock()
+	ret, specificReturn := fake.refsReturnsOnCall[len(fake.refsArgsForCall)]
+	fake.refsArgsForCall = append(fake.refsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RefsStub
+	fakeReturns := fake.refsReturns
+	fake.recordInvocation("Refs", []interface{}{arg1, arg2})
+	fake.refsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGit) RefsCallCount() int {
+	fake.refsMutex.RLock()
+	defer fake.refsMutex.RUnlock()
+	return len(fake.refsArgsForCall)
+}
+
+func (fake *FakeGit) RefsCalls(stub func(context.Context, string) (map[string]string, error)) {
+	fake.refsMutex.Lock()
+	defer fake.refsMutex.Unlock()
+	fake.RefsStub = stub
+}
+
+func (fake *FakeGit) RefsArgsForCall(i int) (context.Context, string) {
+	fake.refsMutex.RLock()
+	defer fake.refsMutex.RUnlock()
+	argsForCall := fake.refsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) RefsReturns(result1 map[string]string, result2 error) {
+	fake.refsMutex.Lock()
+	defer fake.refsMutex.Unlock()
+	fake.RefsStub = nil
+	fake.refsReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) RefsReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.refsMutex.Lock()
+	defer fake.refsMutex.Unlock()
+	fake.RefsStub = nil
+	if fake.refsReturnsOnCall == nil {
+		fake.refsReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.refsReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createBundleMutex.RLock()
+	defer fake.createBundleMutex.RUnlock()
+	fake.refsMutex.RLock()
+	defer fake.refsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeGit) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ bundle.Git = new(FakeGit)
@@ -0,0 +1,3 @@
+package bundle
+
+//go:generate go tool counterfeiter -generate
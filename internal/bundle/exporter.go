@@ -0,0 +1,217 @@
+package bundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/crypt"
+	"github.com/AntonKosov/git-backups/internal/fsutil"
+)
+
+//counterfeiter:generate . Git
+type Git interface {
+	Refs(ctx context.Context, path string) (map[string]string, error)
+	CreateBundle(ctx context.Context, path string, output io.Writer, prerequisites []string) error
+}
+
+type Bundle struct {
+	// File is the name of the bundle in the folder of the manifest.
+	File        string    `json:"file"`
+	Sequence    int       `json:"sequence"`
+	Created     time.Time `json:"created"`
+	Incremental bool      `json:"incremental"`
+	// Encrypted bundles are age files which have to be decrypted before they are applied.
+	Encrypted bool   `json:"encrypted,omitempty"`
+	Size      int64  `json:"size_bytes"`
+	SHA256    string `json:"sha256"`
+	// Refs are the object names of the refs in the bundle.
+	Refs map[string]string `json:"refs"`
+	// Prerequisites are the objects of the previous bundle which incremental bundles are applied on top of.
+	Prerequisites []string `json:"prerequisites,omitempty"`
+}
+
+// Exporter writes bundles of repositories into <folder>/<profile>/<repository folder> next to a manifest.
+// Full bundles replace the previous ones, incremental bundles are appended to the chain which starts with
+// the last full bundle. Bundles are encrypted for the recipients from the config while they are written,
+// and the current chain is uploaded to the storage if there is one.
+type Exporter struct {
+	git         Git
+	storage     Storage
+	folder      string
+	incremental bool
+	recipients  []crypt.Recipient
+	keyLayout   string
+	keepChains  int
+	rootFolders map[string]string
+}
+
+// NewExporter returns an exporter which doesn't upload bundles if the storage is nil.
+func NewExporter(git Git, conf config.Config, storage Storage) (*Exporter, error) {
+	recipients, err := crypt.ParseRecipients(conf.Encryption.Recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Exporter{
+		git:         git,
+		storage:     storage,
+		folder:      conf.Bundle.Folder,
+		incremental: conf.Bundle.Incremental,
+		recipients:  recipients,
+		keyLayout:   conf.S3.KeyPrefix,
+		keepChains:  conf.S3.KeepChains,
+		rootFolders: map[string]string{},
+	}
+	for _, profile := range conf.Profiles.GenericProfiles {
+		e.rootFolders[profile.Name] = profile.RootFolder
+	}
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		e.rootFolders[profile.Name] = profile.RootFolder
+	}
+
+	return e, nil
+}
+
+// Export bundles the repository of the profile. The returned bundle is empty if the repository has no
+// refs or they didn't change since the previous bundle. Bundles are uploaded even if they didn't change,
+// so that a failed upload is repeated.
+func (e *Exporter) Export(ctx context.Context, profile, path string) (Bundle, error) {
+	rootFolder, ok := e.rootFolders[profile]
+	if !ok {
+		return Bundle{}, fmt.Errorf("unknown profile %q", profile)
+	}
+
+	relative, err := filepath.Rel(rootFolder, path)
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	folder, err := filepath.Abs(filepath.Join(e.folder, profile, relative))
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	bundle, manifest, err := e.export(ctx, path, folder)
+	if err != nil || e.storage == nil || len(manifest.Bundles) == 0 {
+		return bundle, err
+	}
+
+	if err := e.upload(ctx, folder, e.keyPrefix(profile, relative), manifest); err != nil {
+		return Bundle{}, fmt.Errorf("failed to upload bundles: %w", err)
+	}
+
+	return bundle, nil
+}
+
+// export writes a new bundle of the repository into the folder and returns it with the updated manifest.
+func (e *Exporter) export(ctx context.Context, path, folder string) (Bundle, Manifest, error) {
+	manifest, err := ReadManifest(folder)
+	if err != nil {
+		return Bundle{}, manifest, fmt.Errorf("failed to read the manifest: %w", err)
+	}
+
+	refs, err := e.git.Refs(ctx, path)
+	if err != nil {
+		return Bundle{}, manifest, err
+	}
+
+	previous, hasPrevious := manifest.last()
+	if len(refs) == 0 || (hasPrevious && maps.Equal(previous.Refs, refs)) {
+		slog.DebugContext(ctx, "Nothing to bundle")
+		return Bundle{}, manifest, nil
+	}
+
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return Bundle{}, manifest, err
+	}
+
+	bundle := Bundle{Sequence: previous.Sequence + 1, Created: time.Now().UTC(), Refs: refs, Encrypted: len(e.recipients) > 0}
+	bundle.File = fmt.Sprintf("%06d-%v.bundle", bundle.Sequence, bundle.Created.Format("20060102T150405Z"))
+	if bundle.Encrypted {
+		bundle.File += crypt.Extension
+	}
+	fileName := filepath.Join(folder, bundle.File)
+
+	if e.incremental && hasPrevious {
+		bundle.Incremental = true
+		bundle.Prerequisites = slices.Compact(slices.Sorted(maps.Values(previous.Refs)))
+		bundle.Size, bundle.SHA256, err = e.writeBundle(ctx, path, fileName, bundle.Prerequisites)
+		if err != nil {
+			// Objects of the previous bundle may be gone after force pushes, and a bundle without new
+			// objects is refused, so the chain starts over.
+			slog.WarnContext(ctx, "Failed to create an incremental bundle, creating a full one", "error", err)
+			bundle.Incremental, bundle.Prerequisites = false, nil
+		}
+	}
+	if !bundle.Incremental {
+		bundle.Size, bundle.SHA256, err = e.writeBundle(ctx, path, fileName, nil)
+	}
+	if err != nil {
+		return Bundle{}, manifest, err
+	}
+
+	var replaced []Bundle
+	if bundle.Incremental {
+		manifest.Bundles = append(manifest.Bundles, bundle)
+	} else {
+		replaced, manifest.Bundles = manifest.Bundles, []Bundle{bundle}
+	}
+	manifest.Repository = path
+
+	if err := manifest.WriteFile(folder); err != nil {
+		return Bundle{}, manifest, fmt.Errorf("failed to write the manifest: %w", err)
+	}
+
+	for _, old := range replaced {
+		if err := os.Remove(filepath.Join(folder, old.File)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			slog.WarnContext(ctx, "Failed to remove a replaced bundle", "error", err)
+		}
+	}
+
+	return bundle, manifest, nil
+}
+
+// writeBundle streams the bundle through the encryption into the file and returns the size and the
+// checksum of the file. The file is only created if the bundle is complete.
+func (e *Exporter) writeBundle(ctx context.Context, path, fileName string, prerequisites []string) (size int64, checksum string, err error) {
+	hash := sha256.New()
+	err = fsutil.WriteAtomically(fileName, func(w io.Writer) error {
+		var output io.Writer = io.MultiWriter(w, hash)
+		closeOutput := func() error { return nil }
+		if len(e.recipients) > 0 {
+			encryptor, err := crypt.Encrypt(output, e.recipients)
+			if err != nil {
+				return err
+			}
+			output, closeOutput = encryptor, encryptor.Close
+		}
+
+		if err := e.git.CreateBundle(ctx, path, output, prerequisites); err != nil {
+			return err
+		}
+
+		// Closing the encryption writes the last chunk.
+		return closeOutput()
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return info.Size(), hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,301 @@
+package bundle_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/bundle/bundlefakes"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/crypt"
+	"github.com/AntonKosov/git-backups/internal/s3"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Exporter tests", func() {
+	var (
+		fakeGit      *bundlefakes.FakeGit
+		storage      bundle.Storage
+		conf         config.Config
+		bundleFolder string
+		repoFolder   string
+		refs         map[string]string
+	)
+
+	objectName := func(c string) string { return strings.Repeat(c, 40) }
+
+	export := func() (bundle.Bundle, error) {
+		exporter, err := bundle.NewExporter(fakeGit, conf, storage)
+		Expect(err).NotTo(HaveOccurred())
+
+		return exporter.Export(ctx, "github", filepath.Join("/backups/github", "owner", "repo"))
+	}
+
+	BeforeEach(func() {
+		fakeGit = &bundlefakes.FakeGit{}
+		storage = nil
+		bundleFolder = GinkgoT().TempDir()
+		repoFolder = filepath.Join(bundleFolder, "github", "owner", "repo")
+		conf = config.Config{
+			Bundle: config.Bundle{Folder: bundleFolder},
+			Profiles: config.Profiles{
+				GitHubProfiles: []config.GitHubProfile{{Name: "github", RootFolder: "/backups/github"}},
+			},
+		}
+
+		refs = map[string]string{"refs/heads/main": objectName("a")}
+		fakeGit.RefsStub = func(context.Context, string) (map[string]string, error) { return refs, nil }
+		fakeGit.CreateBundleStub = func(_ context.Context, _ string, output io.Writer, prerequisites []string) error {
+			_, err := io.WriteString(output, "bundle of "+strings.Join(prerequisites, ","))
+			return err
+		}
+	})
+
+	It("writes a full bundle and the manifest", func() {
+		created, err := export()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.File).To(MatchRegexp(`^000001-\d{8}T\d{6}Z\.bundle$`))
+		Expect(created.Incremental).To(BeFalse())
+
+		content, err := os.ReadFile(filepath.Join(repoFolder, created.File))
+		Expect(err).NotTo(HaveOccurred())
+		checksum := sha256.Sum256(content)
+		Expect(created.Size).To(Equal(int64(len(content))))
+		Expect(created.SHA256).To(Equal(hex.EncodeToString(checksum[:])))
+
+		manifest, err := bundle.ReadManifest(repoFolder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(manifest.Repository).To(Equal("/backups/github/owner/repo"))
+		Expect(manifest.Bundles).To(HaveLen(1))
+		Expect(manifest.Bundles[0].File).To(Equal(created.File))
+		Expect(manifest.Bundles[0].Refs).To(Equal(refs))
+	})
+
+	When("the refs didn't change", func() {
+		It("doesn't create a bundle", func() {
+			_, err := export()
+			Expect(err).NotTo(HaveOccurred())
+
+			created, err := export()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeZero())
+			Expect(fakeGit.CreateBundleCallCount()).To(Equal(1))
+		})
+	})
+
+	When("the refs changed", func() {
+		var first, second bundle.Bundle
+
+		JustBeforeEach(func() {
+			var err error
+			first, err = export()
+			Expect(err).NotTo(HaveOccurred())
+
+			refs = map[string]string{"refs/heads/main": objectName("b"), "refs/tags/v1": objectName("a")}
+			second, err = export()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("replaces the previous full bundle", func() {
+			Expect(second.Incremental).To(BeFalse())
+			Expect(second.Sequence).To(Equal(2))
+			Expect(filepath.Join(repoFolder, first.File)).NotTo(BeAnExistingFile())
+
+			manifest, err := bundle.ReadManifest(repoFolder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest.Bundles).To(HaveLen(1))
+			Expect(manifest.Bundles[0].File).To(Equal(second.File))
+		})
+
+		When("bundles are incremental", func() {
+			BeforeEach(func() {
+				conf.Bundle.Incremental = true
+			})
+
+			It("bundles the objects which are missing from the previous bundle", func() {
+				Expect(second.Incremental).To(BeTrue())
+				Expect(second.Prerequisites).To(Equal([]string{objectName("a")}))
+				_, _, _, prerequisites := fakeGit.CreateBundleArgsForCall(1)
+				Expect(prerequisites).To(Equal([]string{objectName("a")}))
+
+				manifest, err := bundle.ReadManifest(repoFolder)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(manifest.Bundles).To(HaveLen(2))
+				Expect(filepath.Join(repoFolder, first.File)).To(BeAnExistingFile())
+			})
+
+			When("the incremental bundle can't be created", func() {
+				BeforeEach(func() {
+					fakeGit.CreateBundleStub = func(_ context.Context, _ string, output io.Writer, prerequisites []string) error {
+						if len(prerequisites) > 0 {
+							_, _ = io.WriteString(output, "partial")
+							return errors.New("Refusing to create empty bundle.")
+						}
+						_, err := io.WriteString(output, "full bundle")
+						return err
+					}
+				})
+
+				It("starts a new chain with a full bundle", func() {
+					Expect(second.Incremental).To(BeFalse())
+					Expect(fakeGit.CreateBundleCallCount()).To(Equal(3))
+					Expect(os.ReadFile(filepath.Join(repoFolder, second.File))).To(Equal([]byte("full bundle")))
+
+					manifest, err := bundle.ReadManifest(repoFolder)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(manifest.Bundles).To(HaveLen(1))
+				})
+			})
+		})
+	})
+
+	When("a storage is configured", func() {
+		var (
+			fakeStorage *bundlefakes.FakeStorage
+			stored      map[string]int64
+			uploaded    []string
+		)
+
+		BeforeEach(func() {
+			conf.S3 = config.S3{KeyPrefix: "backups/{profile}/{path}"}
+			stored, uploaded = map[string]int64{}, nil
+			fakeStorage = &bundlefakes.FakeStorage{}
+			fakeStorage.ListStub = func(_ context.Context, prefix string) ([]s3.Object, error) {
+				var objects []s3.Object
+				for key, size := range stored {
+					if strings.HasPrefix(key, prefix) {
+						objects = append(objects, s3.Object{Key: key, Size: size})
+					}
+				}
+				return objects, nil
+			}
+			fakeStorage.UploadStub = func(_ context.Context, key, fileName string) error {
+				info, err := os.Stat(fileName)
+				if err == nil {
+					stored[key] = info.Size()
+					uploaded = append(uploaded, key)
+				}
+				return err
+			}
+			fakeStorage.DeleteStub = func(_ context.Context, key string) error {
+				delete(stored, key)
+				return nil
+			}
+			storage = fakeStorage
+		})
+
+		It("uploads the bundles of the chain before the manifest", func() {
+			created, err := export()
+			Expect(err).NotTo(HaveOccurred())
+
+			chain := "backups/github/owner/repo/" + strings.TrimSuffix(created.File, ".bundle")
+			Expect(uploaded).To(Equal([]string{chain + "/" + created.File, chain + "/manifest.json"}))
+			_, prefix := fakeStorage.ListArgsForCall(0)
+			Expect(prefix).To(Equal("backups/github/owner/repo/"))
+		})
+
+		It("uploads only what changed", func() {
+			conf.Bundle.Incremental = true
+			first, err := export()
+			Expect(err).NotTo(HaveOccurred())
+			_, err = export()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uploaded).To(HaveLen(2))
+
+			refs = map[string]string{"refs/heads/main": objectName("b")}
+			second, err := export()
+			Expect(err).NotTo(HaveOccurred())
+
+			chain := "backups/github/owner/repo/" + strings.TrimSuffix(first.File, ".bundle")
+			Expect(uploaded[2:]).To(Equal([]string{chain + "/" + second.File, chain + "/manifest.json"}))
+		})
+
+		When("the upload fails", func() {
+			BeforeEach(func() {
+				upload := fakeStorage.UploadStub
+				fakeStorage.UploadStub = func(ctx context.Context, key, fileName string) error {
+					if fakeStorage.UploadCallCount() == 1 {
+						return errors.New("connection reset")
+					}
+					return upload(ctx, key, fileName)
+				}
+			})
+
+			It("repeats it with the next export", func() {
+				_, err := export()
+				Expect(err).To(MatchError(ContainSubstring("failed to upload bundles: connection reset")))
+
+				created, err := export()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created).To(BeZero())
+				Expect(uploaded).To(HaveLen(2))
+			})
+		})
+
+		When("chains are limited", func() {
+			BeforeEach(func() {
+				conf.S3.KeepChains = 1
+			})
+
+			It("deletes older chains", func() {
+				_, err := export()
+				Expect(err).NotTo(HaveOccurred())
+
+				refs = map[string]string{"refs/heads/main": objectName("b")}
+				second, err := export()
+				Expect(err).NotTo(HaveOccurred())
+
+				chain := "backups/github/owner/repo/" + strings.TrimSuffix(second.File, ".bundle")
+				Expect(stored).To(HaveLen(2))
+				Expect(stored).To(HaveKey(chain + "/" + second.File))
+				Expect(stored).To(HaveKey(chain + "/manifest.json"))
+			})
+		})
+	})
+
+	When("recipients are configured", func() {
+		var identity *age.X25519Identity
+
+		BeforeEach(func() {
+			var err error
+			identity, err = age.GenerateX25519Identity()
+			Expect(err).NotTo(HaveOccurred())
+			conf.Encryption.Recipients = []string{identity.Recipient().String()}
+		})
+
+		It("encrypts the bundle", func() {
+			created, err := export()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.Encrypted).To(BeTrue())
+			Expect(created.File).To(HaveSuffix(".bundle.age"))
+
+			file, err := os.Open(filepath.Join(repoFolder, created.File))
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+			decrypted, err := crypt.Decrypt(file, []crypt.Identity{identity})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(io.ReadAll(decrypted)).To(Equal([]byte("bundle of ")))
+		})
+	})
+
+	When("the repository is empty", func() {
+		BeforeEach(func() {
+			refs = map[string]string{}
+		})
+
+		It("doesn't create a bundle", func() {
+			created, err := export()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(BeZero())
+			Expect(fakeGit.CreateBundleCallCount()).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/AntonKosov/git-backups/internal/fsutil"
+)
+
+// ManifestName is the name of the manifest in the bundle folder of every repository.
+const ManifestName = "manifest.json"
+
+// Manifest lists the bundles of a repository in the order they have to be applied: the full bundle
+// followed by incremental ones.
+type Manifest struct {
+	Repository string   `json:"repository"`
+	Bundles    []Bundle `json:"bundles"`
+}
+
+// ReadManifest returns the manifest in the folder or an empty one if it doesn't exist yet.
+func ReadManifest(folder string) (Manifest, error) {
+	var manifest Manifest
+	data, err := os.ReadFile(filepath.Join(folder, ManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return manifest, err
+	}
+
+	return manifest, json.Unmarshal(data, &manifest)
+}
+
+// WriteFile replaces the manifest in the folder atomically so that it always describes complete bundles.
+func (m Manifest) WriteFile(folder string) error {
+	return fsutil.WriteAtomically(filepath.Join(folder, ManifestName), func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(m)
+	})
+}
+
+func (m Manifest) last() (Bundle, bool) {
+	if len(m.Bundles) == 0 {
+		return Bundle{}, false
+	}
+
+	return m.Bundles[len(m.Bundles)-1], true
+}
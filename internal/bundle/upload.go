@@ -0,0 +1,102 @@
+package bundle
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AntonKosov/git-backups/internal/s3"
+)
+
+//counterfeiter:generate . Storage
+type Storage interface {
+	List(ctx context.Context, prefix string) ([]s3.Object, error)
+	Upload(ctx context.Context, key, fileName string) error
+	Delete(ctx context.Context, key string) error
+}
+
+// upload copies the chain of the manifest to <key prefix>/<chain>/, where the chain is named after its full
+// bundle, so that every stored chain can be restored on its own. Bundles are never modified, and the ones
+// which are already stored with the same size are skipped. The manifest is uploaded last, so that a stored
+// manifest never refers to missing bundles. Chains beyond the retention are deleted afterwards.
+func (e *Exporter) upload(ctx context.Context, folder, keyPrefix string, manifest Manifest) error {
+	chain, _, _ := strings.Cut(manifest.Bundles[0].File, ".")
+	objects, err := e.storage.List(ctx, keyPrefix+"/")
+	if err != nil {
+		return err
+	}
+
+	stored := map[string]int64{}
+	chains := []string{chain}
+	for _, object := range objects {
+		stored[object.Key] = object.Size
+		name, _, found := strings.Cut(strings.TrimPrefix(object.Key, keyPrefix+"/"), "/")
+		if found && !slices.Contains(chains, name) {
+			chains = append(chains, name)
+		}
+	}
+
+	uploaded := 0
+	for _, bundle := range manifest.Bundles {
+		key := path.Join(keyPrefix, chain, bundle.File)
+		if size, ok := stored[key]; ok && size == bundle.Size {
+			continue
+		}
+
+		if err := e.storage.Upload(ctx, key, filepath.Join(folder, bundle.File)); err != nil {
+			return err
+		}
+		uploaded++
+	}
+
+	manifestFile := filepath.Join(folder, ManifestName)
+	info, err := os.Stat(manifestFile)
+	if err != nil {
+		return err
+	}
+
+	manifestKey := path.Join(keyPrefix, chain, ManifestName)
+	if size, ok := stored[manifestKey]; uploaded > 0 || !ok || size != info.Size() {
+		if err := e.storage.Upload(ctx, manifestKey, manifestFile); err != nil {
+			return err
+		}
+		uploaded++
+	}
+
+	if uploaded > 0 {
+		slog.InfoContext(ctx, "Uploaded bundles", "objects", uploaded, "chain", path.Join(keyPrefix, chain))
+	}
+
+	if e.keepChains == 0 {
+		return nil
+	}
+
+	// Names of chains start with the sequence of their full bundle, so they are sorted from the oldest.
+	slices.Sort(chains)
+	for _, old := range chains[:max(len(chains)-e.keepChains, 0)] {
+		if old == chain {
+			continue
+		}
+
+		for _, object := range objects {
+			if strings.HasPrefix(object.Key, path.Join(keyPrefix, old)+"/") {
+				if err := e.storage.Delete(ctx, object.Key); err != nil {
+					return err
+				}
+			}
+		}
+		slog.InfoContext(ctx, "Deleted an expired chain", "chain", path.Join(keyPrefix, old))
+	}
+
+	return nil
+}
+
+func (e *Exporter) keyPrefix(profile, relative string) string {
+	replacer := strings.NewReplacer("{profile}", profile, "{path}", filepath.ToSlash(relative))
+
+	return strings.Trim(replacer.Replace(e.keyLayout), "/")
+}
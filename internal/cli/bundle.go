@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"text/tabwriter"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/redact"
+)
+
+type bundledRepo struct {
+	Profile string         `json:"profile"`
+	Path    string         `json:"path"`
+	Status  string         `json:"status"`
+	Bundle  *bundle.Bundle `json:"bundle,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+func bundleCommand(ctx context.Context, env environment, args []string) error {
+	flags := newFlagSet(env, "bundle")
+	folder := flags.String("folder", "", "folder to write bundles to (bundle.folder from the config by default)")
+	full := flags.Bool("full", false, "write full bundles even if the config enables incremental ones")
+	noUpload := flags.Bool("no-upload", false, "don't upload bundles even if the config has an S3 bucket")
+	format := flags.String("format", "text", "output format: text or json")
+	filters := addFilterFlags(env, flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(env.stderr, "invalid format %q\n", *format)
+		return errUsage
+	}
+
+	conf, err := env.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	conf.Bundle.Folder = cmp.Or(*folder, conf.Bundle.Folder)
+	if conf.Bundle.Folder == "" {
+		fmt.Fprintln(env.stderr, "the bundle folder is required (-folder or bundle.folder in the config)")
+		return errUsage
+	}
+	if *full {
+		conf.Bundle.Incremental = false
+	}
+	if *noUpload {
+		conf.S3 = config.S3{}
+	}
+
+	exporter, err := newExporter(conf, env.deps.BundleGit)
+	if err != nil {
+		return err
+	}
+
+	results, exportErr := launcher.Export(ctx, conf, exporter, append(filters(), launcher.WithLocking())...)
+
+	repos := make([]bundledRepo, 0, len(results))
+	for _, result := range results {
+		repo := bundledRepo{Profile: result.Profile, Path: result.Path, Status: "unchanged"}
+		switch {
+		case result.Err != nil:
+			repo.Status, repo.Error = "failed", redact.String(result.Err.Error())
+		case result.Bundle.File != "":
+			repo.Status, repo.Bundle = "full", &result.Bundle
+			if result.Bundle.Incremental {
+				repo.Status = "incremental"
+			}
+		}
+		repos = append(repos, repo)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(repos)
+	} else {
+		err = writeBundlesText(env, repos)
+	}
+	if err != nil {
+		return err
+	}
+
+	if exportErr != nil {
+		slog.ErrorContext(ctx, "Failed to bundle", "error", exportErr)
+	}
+
+	return exportErr
+}
+
+func writeBundlesText(env environment, repos []bundledRepo) error {
+	writer := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATUS\tPROFILE\tPATH\tBUNDLE\tBYTES")
+	counts := map[string]int{}
+	for _, repo := range repos {
+		counts[repo.Status]++
+		file, size := "-", "-"
+		if repo.Bundle != nil {
+			file, size = repo.Bundle.File, fmt.Sprint(repo.Bundle.Size)
+		}
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\n", repo.Status, repo.Profile, repo.Path, file, size)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "\nBundled %v of %v repositories, %v unchanged, %v failed\n",
+		counts["full"]+counts["incremental"], len(repos), counts["unchanged"], counts["failed"])
+
+	return nil
+}
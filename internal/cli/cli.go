@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/logfile"
+	"github.com/AntonKosov/git-backups/internal/metrics"
+	"github.com/AntonKosov/git-backups/internal/redact"
+	"github.com/AntonKosov/git-backups/internal/restore"
+)
+
+const (
+	ExitSuccess = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+
+	defaultCommand = "run"
+)
+
+//counterfeiter:generate . ConfigService
+type ConfigService interface {
+	Read(fileName string) (config.Config, error)
+	Validate(fileName string) error
+}
+
+type Dependencies struct {
+	ConfigService ConfigService
+	// NewBackupService returns the backup service for the profiles of the config and a function which
+	// releases it, see NewBackupService.
+	NewBackupService func(conf config.Config) (launcher.BackupService, func())
+	ReaderService    launcher.ReaderService
+	RestoreGit       restore.Git
+	RepoCreator      restore.RepoCreator
+	BundleGit        bundle.Git
+	// Metrics is optional.
+	Metrics *metrics.Collector
+	// Trigger starts a run immediately in the daemon mode.
+	Trigger <-chan struct{}
+}
+
+type globalOptions struct {
+	configFile    string
+	logLevel      string
+	logFormat     string
+	logFile       string
+	logMaxSizeMB  int
+	logMaxBackups int
+	profiles      stringList
+}
+
+type environment struct {
+	globalOptions
+	deps   Dependencies
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, env environment, args []string) error
+}
+
+var errUsage = errors.New("usage error")
+
+func commands() []command {
+	return []command{
+		{name: "run", description: "back up all selected profiles (default)", run: runCommand},
+		{name: "list", description: "show the targets each profile would back up", run: listCommand},
+		{name: "daemon", description: "keep running and back up on the schedule from the config", run: daemonCommand},
+		{name: "validate", description: "check the config and exit with a non-zero code if it has problems", run: validateCommand},
+		{name: "status", description: "show the backup status of every repository", run: statusCommand},
+		{name: "verify", description: "check the integrity of backed up repositories", run: verifyCommand},
+		{name: "bundle", description: "export backed up repositories as git bundles", run: bundleCommand},
+		{name: "restore", description: "push backed up repositories to a new remote", run: restoreCommand},
+	}
+}
+
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer, deps Dependencies) int {
+	env := environment{deps: deps, stdout: stdout, stderr: stderr}
+
+	flags := flag.NewFlagSet("git-backups", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&env.configFile, "config", "config.yaml", "path to the config file")
+	flags.StringVar(&env.logLevel, "log-level", "info", "log level: debug, info, warn or error")
+	flags.StringVar(&env.logFormat, "log-format", "text", "log format: text or json")
+	flags.StringVar(&env.logFile, "log-file", "", "write logs to the file instead of stdout")
+	flags.IntVar(&env.logMaxSizeMB, "log-max-size", 10, "size of the log file in megabytes which triggers rotation")
+	flags.IntVar(&env.logMaxBackups, "log-max-backups", 5, "number of rotated log files to keep")
+	flags.Var(&env.profiles, "profile", "profile to process (repeatable, all profiles by default)")
+	debug := flags.Bool("debug", false, "enable debug logging (same as -log-level debug)")
+	flags.Usage = func() { printUsage(flags) }
+
+	if err := flags.Parse(args); err != nil {
+		return exitCode(err)
+	}
+
+	if *debug {
+		env.logLevel = "debug"
+	}
+
+	closeLog, err := setupLogger(env.globalOptions, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+	defer closeLog()
+
+	name, commandArgs := defaultCommand, flags.Args()
+	if len(commandArgs) > 0 {
+		name, commandArgs = commandArgs[0], commandArgs[1:]
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return exitCode(cmd.run(ctx, env, commandArgs))
+		}
+	}
+
+	fmt.Fprintf(stderr, "unknown command %q\n", name)
+	printUsage(flags)
+
+	return ExitUsage
+}
+
+func printUsage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintln(out, "Usage: git-backups [global flags] [command] [command flags]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(out, "  %-10v %v\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintln(out, "\nGlobal flags:")
+	flags.PrintDefaults()
+}
+
+func exitCode(err error) int {
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitSuccess
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	default:
+		return ExitFailure
+	}
+}
+
+func setupLogger(options globalOptions, output io.Writer) (closeLog func(), err error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(options.logLevel)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", options.logLevel)
+	}
+
+	if options.logFormat != "text" && options.logFormat != "json" {
+		return nil, fmt.Errorf("invalid log format %q", options.logFormat)
+	}
+
+	closeLog = func() {}
+	if options.logFile != "" {
+		file, err := logfile.New(options.logFile, int64(options.logMaxSizeMB)<<20, options.logMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the log file: %w", err)
+		}
+		output, closeLog = file, func() { file.Close() }
+	}
+
+	handlerOptions := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(output, handlerOptions)
+	if options.logFormat == "json" {
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	}
+
+	slog.SetDefault(slog.New(clog.NewHandler(handler)))
+
+	return closeLog, nil
+}
+
+func (env environment) readConfig(ctx context.Context) (config.Config, error) {
+	conf, err := env.deps.ConfigService.Read(env.configFile)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read config", "error", err)
+		return config.Config{}, err
+	}
+
+	redact.AddSecrets(conf.Secrets()...)
+
+	return conf, nil
+}
+
+type stringList []string
+
+func (sl *stringList) String() string {
+	return strings.Join(*sl, ", ")
+}
+
+func (sl *stringList) Set(value string) error {
+	*sl = append(*sl, value)
+	return nil
+}
@@ -0,0 +1,24 @@
+package cli_test
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+func TestCli(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cli Suite")
+}
+
+var _ = BeforeEach(func() {
+	ctx = context.Background()
+})
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"text/tabwriter"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/healthcheck"
+	"github.com/AntonKosov/git-backups/internal/history"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/notify"
+	"github.com/AntonKosov/git-backups/internal/redact"
+	"github.com/AntonKosov/git-backups/internal/remote"
+	"github.com/AntonKosov/git-backups/internal/report"
+	"github.com/AntonKosov/git-backups/internal/s3"
+)
+
+func newFlagSet(env environment, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.stderr)
+
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+
+		return errors.Join(errUsage, err)
+	}
+
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "unexpected arguments: %v\n", flags.Args())
+		return errUsage
+	}
+
+	return nil
+}
+
+// addFilterFlags adds -profile and -repo flags. Profiles selected with the global flag are included as well.
+func addFilterFlags(env environment, flags *flag.FlagSet) func() []launcher.Option {
+	var profiles, repos stringList
+	flags.Var(&profiles, "profile", "profile to process (repeatable)")
+	flags.Var(&repos, "repo", "pattern of repositories to process (repeatable), e.g. owner/*, repo_name or regex:^svc-")
+
+	return func() []launcher.Option {
+		return []launcher.Option{
+			launcher.WithProfiles(env.profiles...),
+			launcher.WithProfiles(profiles...),
+			launcher.WithRepos(repos...),
+		}
+	}
+}
+
+func runCommand(ctx context.Context, env environment, args []string) error {
+	flags := newFlagSet(env, "run")
+	dryRun := flags.Bool("dry-run", false, "show the backup plan without running git")
+	format := flags.String("format", "text", "dry run output format: text or json")
+	metricsTextfile := flags.String("metrics-textfile", "", "write Prometheus metrics to the file for the node_exporter textfile collector")
+	filters := addFilterFlags(env, flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(env.stderr, "invalid format %q\n", *format)
+		return errUsage
+	}
+
+	conf, err := env.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		return dryRunCommand(ctx, env, conf, *format, filters())
+	}
+
+	options, err := withNotifier(append(filters(), launcher.WithLocking()), conf, notify.NewMemoryStore())
+	if err != nil {
+		return err
+	}
+	options, err = withExporter(withRunObservers(options, conf), conf, env.deps.BundleGit)
+	if err != nil {
+		return err
+	}
+
+	if *metricsTextfile != "" && env.deps.Metrics != nil {
+		if err := env.deps.Metrics.LoadTextfile(*metricsTextfile); err != nil {
+			slog.WarnContext(ctx, "Failed to load previous metrics", "file", *metricsTextfile, "error", err)
+		}
+		options = append(options, launcher.WithObservers(env.deps.Metrics))
+	}
+
+	backupService, closeBackupService := env.deps.NewBackupService(conf)
+	defer closeBackupService()
+
+	runErr := launcher.Run(ctx, conf, backupService, env.deps.ReaderService, options...)
+	if runErr != nil {
+		slog.ErrorContext(ctx, "Failed to backup", "error", runErr)
+	}
+
+	if *metricsTextfile != "" && env.deps.Metrics != nil {
+		if err := env.deps.Metrics.WriteTextfile(*metricsTextfile); err != nil {
+			slog.ErrorContext(ctx, "Failed to write metrics", "file", *metricsTextfile, "error", err)
+			return errors.Join(runErr, err)
+		}
+	}
+
+	return runErr
+}
+
+// withNotifier adds the notifier of the config to the options. The store is replaced with a file store
+// if the config has a notification state file.
+func withNotifier(options []launcher.Option, conf config.Config, store notify.Store) ([]launcher.Option, error) {
+	if len(conf.Notifications.Channels) == 0 {
+		return options, nil
+	}
+
+	if conf.Notifications.StateFile != "" {
+		store = notify.NewFileStore(conf.Notifications.StateFile)
+	}
+
+	notifier, err := notify.New(conf.Notifications, store)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(options, launcher.WithObservers(notifier)), nil
+}
+
+// withRunObservers adds the healthcheck pinger if the config has healthcheck URLs, the history journal,
+// and the report writer if the config has a report file.
+func withRunObservers(options []launcher.Option, conf config.Config) []launcher.Option {
+	if pinger := healthcheck.New(conf); pinger != nil {
+		options = append(options, launcher.WithObservers(pinger))
+	}
+
+	options = append(options, launcher.WithObservers(history.NewJournal(conf)))
+
+	if conf.Report.File != "" {
+		options = append(options, launcher.WithObservers(report.NewWriter(conf.Report.File)))
+	}
+
+	return options
+}
+
+// withExporter bundles backed up repositories if the config enables bundling after backups.
+func withExporter(options []launcher.Option, conf config.Config, git bundle.Git) ([]launcher.Option, error) {
+	if !conf.Bundle.AfterBackup || conf.Bundle.Folder == "" {
+		return options, nil
+	}
+
+	exporter, err := newExporter(conf, git)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(options, launcher.WithExporter(exporter)), nil
+}
+
+// newExporter returns an exporter which uploads bundles to S3 if the config has a bucket.
+func newExporter(conf config.Config, git bundle.Git) (*bundle.Exporter, error) {
+	if conf.S3.Bucket == "" {
+		return bundle.NewExporter(git, conf, nil)
+	}
+
+	client, err := s3.NewClient(conf.S3)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle.NewExporter(git, conf, client)
+}
+
+// NewBackupService returns the backup service which keeps the repositories of profiles with a remote
+// storage in it and a function which closes the connections to the storages.
+func NewBackupService(git backup.Git, conf config.Config) (backup.Service, func()) {
+	service := backup.NewService(git)
+
+	var remotes []*remote.SFTP
+	closeRemotes := func() {
+		for _, r := range remotes {
+			_ = r.Close()
+		}
+	}
+
+	configure := func(rootFolder string, remoteConf config.Remote) {
+		if remoteConf.SFTP.Address != "" {
+			sftp := remote.NewSFTP(remoteConf.SFTP)
+			remotes = append(remotes, sftp)
+			service = service.WithStorage(rootFolder, sftp)
+		}
+	}
+
+	for _, profile := range conf.Profiles.GenericProfiles {
+		configure(profile.RootFolder, profile.Remote)
+	}
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		configure(profile.RootFolder, profile.Remote)
+	}
+
+	return service, closeRemotes
+}
+
+func dryRunCommand(ctx context.Context, env environment, conf config.Config, format string, filters []launcher.Option) error {
+	backupService, closeBackupService := env.deps.NewBackupService(conf)
+	defer closeBackupService()
+
+	plan, planErr := launcher.DryRun(ctx, conf, backupService, env.deps.ReaderService, filters...)
+
+	write := plan.WriteText
+	if format == "json" {
+		write = plan.WriteJSON
+	}
+
+	if err := write(env.stdout); err != nil {
+		return err
+	}
+
+	if planErr != nil {
+		slog.ErrorContext(ctx, "Failed to build the backup plan", "error", planErr)
+	}
+
+	return planErr
+}
+
+func listCommand(ctx context.Context, env environment, args []string) error {
+	flags := newFlagSet(env, "list")
+	filters := addFilterFlags(env, flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	conf, err := env.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	targets, listErr := launcher.List(ctx, conf, env.deps.ReaderService, filters()...)
+
+	writer := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROFILE\tURL\tPATH")
+	for _, target := range targets {
+		fmt.Fprintf(writer, "%v\t%v\t%v\n", target.Profile, redact.String(target.URL), target.Path)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if listErr != nil {
+		slog.ErrorContext(ctx, "Failed to list targets", "error", listErr)
+	}
+
+	return listErr
+}
+
+func validateCommand(ctx context.Context, env environment, args []string) error {
+	if err := parseFlags(newFlagSet(env, "validate"), args); err != nil {
+		return err
+	}
+
+	if err := env.deps.ConfigService.Validate(env.configFile); err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "%v is valid\n", env.configFile)
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/notify"
+	"github.com/AntonKosov/git-backups/internal/schedule"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+func daemonCommand(ctx context.Context, env environment, args []string) error {
+	flags := newFlagSet(env, "daemon")
+	metricsListen := flags.String("metrics-listen", "", "serve Prometheus metrics at /metrics on the address, e.g. :9100")
+	filters := addFilterFlags(env, flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	conf, err := env.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	sched, err := newSchedule(conf.Schedule)
+	if err != nil {
+		fmt.Fprintln(env.stderr, err)
+		return err
+	}
+
+	if *metricsListen != "" {
+		stopServer, err := serveMetrics(ctx, env, *metricsListen)
+		if err != nil {
+			return err
+		}
+		defer stopServer()
+	}
+
+	// Without a state file, recoveries are detected between runs of this daemon.
+	notificationStore := notify.NewMemoryStore()
+	scheduler := schedule.New(sched, schedule.WithJitter(conf.Schedule.Jitter), schedule.WithTrigger(env.deps.Trigger))
+	slog.InfoContext(ctx, "Daemon started", "schedule", describeSchedule(conf.Schedule), "jitter", conf.Schedule.Jitter)
+	err = scheduler.Run(ctx, func(ctx context.Context) {
+		// The config is read before every run so that changes don't require a restart.
+		conf, err := env.readConfig(ctx)
+		if err != nil {
+			return
+		}
+
+		options, err := withNotifier(append(filters(), launcher.WithGracefulStop(), launcher.WithLocking()), conf, notificationStore)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to set up notifications", "error", err)
+			return
+		}
+		options, err = withExporter(withRunObservers(options, conf), conf, env.deps.BundleGit)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to set up bundles", "error", err)
+			return
+		}
+
+		if env.deps.Metrics != nil {
+			options = append(options, launcher.WithObservers(env.deps.Metrics))
+		}
+
+		backupService, closeBackupService := env.deps.NewBackupService(conf)
+		defer closeBackupService()
+
+		if err := launcher.Run(ctx, conf, backupService, env.deps.ReaderService, options...); err != nil {
+			slog.ErrorContext(ctx, "Failed to backup", "error", err)
+		}
+	})
+	slog.InfoContext(ctx, "Daemon stopped")
+
+	return err
+}
+
+func newSchedule(conf config.Schedule) (schedule.Schedule, error) {
+	switch {
+	case conf.Cron != "":
+		return schedule.ParseCron(conf.Cron)
+	case conf.Interval > 0:
+		return schedule.Interval(conf.Interval), nil
+	default:
+		return nil, errors.New("the daemon mode requires schedule.cron or schedule.interval in the config")
+	}
+}
+
+func describeSchedule(conf config.Schedule) string {
+	if conf.Cron != "" {
+		return conf.Cron
+	}
+
+	return "every " + conf.Interval.String()
+}
+
+func serveMetrics(ctx context.Context, env environment, address string) (stop func(), err error) {
+	if env.deps.Metrics == nil {
+		return nil, errors.New("metrics are not available")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to listen for metrics requests", "address", address, "error", err)
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", env.deps.Metrics)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.ErrorContext(ctx, "Metrics server failed", "error", err)
+		}
+	}()
+	slog.InfoContext(ctx, "Serving metrics", "address", listener.Addr().String())
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}, nil
+}
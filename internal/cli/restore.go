@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/AntonKosov/git-backups/internal/crypt"
+	"github.com/AntonKosov/git-backups/internal/redact"
+	"github.com/AntonKosov/git-backups/internal/restore"
+)
+
+type restoredRepo struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+func restoreCommand(ctx context.Context, env environment, args []string) error {
+	flags := newFlagSet(env, "restore")
+	source := flags.String("source", "", "backed up repository, bundle folder or root folder of them to restore")
+	urlTemplate := flags.String("url", "", "destination URL with {owner}, {name} and {path} placeholders")
+	githubProfile := flags.String("github-profile", "", "GitHub profile whose token creates the repositories before pushing")
+	owner := flags.String("owner", "", "organization to create the repositories in (the user of the token by default)")
+	public := flags.Bool("public", false, "create public repositories")
+	sshKey := flags.String("ssh-key", "", "private SSH key to push with (the key of the GitHub profile by default)")
+	lfs := flags.Bool("lfs", false, "push LFS objects stored in the backups (requires git-lfs)")
+	wiki := flags.Bool("wiki", false, "push wikis backed up next to the repositories as <name>.wiki")
+	identity := flags.String("identity", "", "age identity file or private SSH key to decrypt encrypted bundles")
+	refSnapshot := flags.String("ref-snapshot", "", "file with refs in the git show-ref format to restore instead of the current refs")
+	format := flags.String("format", "text", "output format: text or json")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(env.stderr, "invalid format %q\n", *format)
+		return errUsage
+	}
+
+	if *source == "" || (*urlTemplate == "") == (*githubProfile == "") {
+		fmt.Fprintln(env.stderr, "-source and exactly one of -url and -github-profile are required")
+		return errUsage
+	}
+
+	repos, err := restore.Find(*source)
+	if err != nil {
+		fmt.Fprintf(env.stderr, "failed to read the source: %v\n", err)
+		return errUsage
+	}
+
+	if len(repos) == 0 {
+		fmt.Fprintf(env.stderr, "no repositories found in %v\n", *source)
+		return errUsage
+	}
+
+	template := restore.Template(*urlTemplate)
+	if *urlTemplate != "" && len(repos) > 1 && !template.Distinct() {
+		fmt.Fprintln(env.stderr, "-url must contain {name} or {path} to restore several repositories")
+		return errUsage
+	}
+
+	options := restore.Options{LFS: *lfs, Wiki: *wiki}
+	if *refSnapshot != "" {
+		if len(repos) > 1 {
+			fmt.Fprintln(env.stderr, "-ref-snapshot requires a single repository as the source")
+			return errUsage
+		}
+
+		if options.Snapshot, err = readSnapshot(*refSnapshot); err != nil {
+			fmt.Fprintf(env.stderr, "failed to read the ref snapshot: %v\n", err)
+			return errUsage
+		}
+	}
+
+	if *identity != "" {
+		if options.Identities, err = crypt.ReadIdentities(*identity); err != nil {
+			fmt.Fprintf(env.stderr, "failed to read the identity: %v\n", err)
+			return errUsage
+		}
+	}
+
+	var destination restore.Destination = template
+	if *githubProfile != "" {
+		conf, err := env.readConfig(ctx)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, profile := range conf.Profiles.GitHubProfiles {
+			if profile.Name == *githubProfile {
+				found = true
+				options.PrivateSSHKey = profile.PrivateSSHKey
+				destination = restore.GitHub{Creator: env.deps.RepoCreator, Token: profile.Token, Owner: *owner, Private: !*public}
+			}
+		}
+
+		if !found {
+			fmt.Fprintf(env.stderr, "unknown GitHub profile %q\n", *githubProfile)
+			return errUsage
+		}
+	}
+
+	if *sshKey != "" {
+		options.PrivateSSHKey = sshKey
+	}
+
+	results, restoreErr := restore.Restore(ctx, env.deps.RestoreGit, repos, destination, options)
+
+	restored := make([]restoredRepo, 0, len(results))
+	for _, result := range results {
+		r := restoredRepo{Source: result.Repo.Path, Destination: redact.String(result.URL), Status: "ok"}
+		if result.Err != nil {
+			r.Status, r.Error = "failed", redact.String(result.Err.Error())
+		} else if result.Repo.Partial {
+			r.Status = "partial"
+		}
+		restored = append(restored, r)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(restored)
+	} else {
+		err = writeRestoreText(env, restored, len(repos))
+	}
+	if err != nil {
+		return err
+	}
+
+	if restoreErr != nil {
+		slog.ErrorContext(ctx, "Failed to restore", "error", restoreErr)
+	}
+
+	return restoreErr
+}
+
+func readSnapshot(fileName string) (map[string]string, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return restore.ReadSnapshot(file)
+}
+
+func writeRestoreText(env environment, restored []restoredRepo, found int) error {
+	writer := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATUS\tSOURCE\tDESTINATION")
+	failed := 0
+	for _, repo := range restored {
+		if repo.Error != "" {
+			failed++
+		}
+		fmt.Fprintf(writer, "%v\t%v\t%v\n", repo.Status, repo.Source, repo.Destination)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "\nRestored %v of %v repositories, %v failed\n", len(restored)-failed, found, failed)
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/history"
+	"github.com/AntonKosov/git-backups/internal/report"
+)
+
+func statusCommand(ctx context.Context, env environment, args []string) error {
+	flags := newFlagSet(env, "status")
+	staleAfter := flags.Duration("stale-after", 48*time.Hour, "highlight repositories without a successful backup within the duration")
+	format := flags.String("format", "text", "output format: text or json")
+	var profiles stringList
+	flags.Var(&profiles, "profile", "profile to show (repeatable)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(env.stderr, "invalid format %q\n", *format)
+		return errUsage
+	}
+
+	conf, err := env.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	profiles = append(profiles, env.profiles...)
+	var entries []report.Target
+	var readErr error
+	for _, rootFolder := range rootFolders(conf, profiles) {
+		rootEntries, err := history.Read(rootFolder)
+		if err != nil {
+			readErr = errors.Join(readErr, fmt.Errorf("failed to read the history of %v: %w", rootFolder, err))
+		}
+		entries = append(entries, rootEntries...)
+	}
+
+	entries = slices.DeleteFunc(entries, func(entry report.Target) bool {
+		return len(profiles) > 0 && !slices.Contains(profiles, entry.Profile)
+	})
+	statuses := history.Statuses(entries, time.Now(), *staleAfter)
+
+	write := writeStatusText
+	if *format == "json" {
+		write = writeStatusJSON
+	}
+
+	if err := write(env, statuses, *staleAfter); err != nil {
+		return err
+	}
+
+	if readErr != nil {
+		fmt.Fprintln(env.stderr, readErr)
+	}
+
+	return readErr
+}
+
+// rootFolders returns the root folders of the profiles, or of all profiles if none are given. Profiles
+// may share a root folder, so every folder is returned once.
+func rootFolders(conf config.Config, profiles []string) []string {
+	var folders []string
+	add := func(name, rootFolder string) {
+		if (len(profiles) == 0 || slices.Contains(profiles, name)) && !slices.Contains(folders, rootFolder) {
+			folders = append(folders, rootFolder)
+		}
+	}
+
+	for _, profile := range conf.Profiles.GenericProfiles {
+		add(profile.Name, profile.RootFolder)
+	}
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		add(profile.Name, profile.RootFolder)
+	}
+
+	return folders
+}
+
+func writeStatusText(env environment, statuses []history.RepoStatus, staleAfter time.Duration) error {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+
+		return t.Local().Format(time.DateTime)
+	}
+
+	writer := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATE\tPROFILE\tPATH\tLAST SUCCESS\tLAST FAILURE\tFAILURES\tREASON")
+	stale := 0
+	for _, status := range statuses {
+		state := "ok"
+		switch {
+		case status.Stale:
+			state = "STALE"
+			stale++
+		case status.ConsecutiveFailures > 0:
+			state = "failing"
+		}
+
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", state, status.Profile, status.Path,
+			formatTime(status.LastSuccess), formatTime(status.LastFailure), status.ConsecutiveFailures,
+			cmp.Or(status.LastErrorClass, "-"))
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if stale > 0 {
+		fmt.Fprintf(env.stdout, "\n%v of %v repositories were not backed up successfully within %v\n", stale, len(statuses), staleAfter)
+	}
+
+	return nil
+}
+
+func writeStatusJSON(env environment, statuses []history.RepoStatus, _ time.Duration) error {
+	encoder := json.NewEncoder(env.stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(statuses)
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/lock"
+	"github.com/AntonKosov/git-backups/internal/redact"
+)
+
+type verifiedRepo struct {
+	Profile string `json:"profile"`
+	Path    string `json:"path"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+func verifyCommand(ctx context.Context, env environment, args []string) error {
+	flags := newFlagSet(env, "verify")
+	sample := flags.Int("sample", 100, "percentage of repositories to verify today; every repository is verified once in 100/sample days")
+	format := flags.String("format", "text", "output format: text or json")
+	filters := addFilterFlags(env, flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(env.stderr, "invalid format %q\n", *format)
+		return errUsage
+	}
+
+	if *sample < 1 || *sample > 100 {
+		fmt.Fprintf(env.stderr, "invalid sample %v (expected 1-100)\n", *sample)
+		return errUsage
+	}
+
+	conf, err := env.readConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	options := append(filters(), launcher.WithSample(*sample, time.Now()), launcher.WithLocking())
+	backupService, closeBackupService := env.deps.NewBackupService(conf)
+	defer closeBackupService()
+
+	verification, verifyErr := launcher.Verify(ctx, conf, backupService, options...)
+
+	repos := make([]verifiedRepo, 0, len(verification.Repos))
+	for _, repo := range verification.Repos {
+		r := verifiedRepo{Profile: repo.Profile, Path: repo.Path, Status: "ok"}
+		switch {
+		case errors.Is(repo.Err, lock.ErrLocked):
+			r.Status, r.Error = "locked", redact.String(repo.Err.Error())
+		case repo.Err != nil:
+			r.Status, r.Error = "corrupt", redact.String(repo.Err.Error())
+		}
+		repos = append(repos, r)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(env.stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(struct {
+			Found    int            `json:"found"`
+			Verified []verifiedRepo `json:"verified"`
+		}{Found: verification.Found, Verified: repos})
+	} else {
+		err = writeVerificationText(env, verification, repos)
+	}
+	if err != nil {
+		return err
+	}
+
+	if verifyErr != nil {
+		slog.ErrorContext(ctx, "Failed to verify", "error", verifyErr)
+	}
+
+	return verifyErr
+}
+
+func writeVerificationText(env environment, verification launcher.Verification, repos []verifiedRepo) error {
+	writer := tabwriter.NewWriter(env.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATUS\tPROFILE\tPATH")
+	for _, repo := range repos {
+		fmt.Fprintf(writer, "%v\t%v\t%v\n", repo.Status, repo.Profile, repo.Path)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(env.stdout, "\nVerified %v of %v repositories, %v corrupted or incomplete\n",
+		len(verification.Repos), verification.Found, len(verification.Failed()))
+
+	return nil
+}
@@ -14,13 +14,9 @@ const (
 type contextKey struct{}
 
 func Add(ctx context.Context, kv ...any) context.Context {
-	var attrs []slog.Attr
-	if prevAttrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
-		attrs = make([]slog.Attr, len(prevAttrs), len(prevAttrs)+(len(kv)+1)/2)
-		copy(attrs, prevAttrs)
-	} else {
-		attrs = make([]slog.Attr, (len(kv)+1)/2)
-	}
+	prevAttrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	attrs := make([]slog.Attr, len(prevAttrs), len(prevAttrs)+(len(kv)+1)/2)
+	copy(attrs, prevAttrs)
 
 	for i := 0; i < len(kv); i += 2 {
 		var key string
@@ -38,8 +34,20 @@ func Add(ctx context.Context, kv ...any) context.Context {
 			val = kv[i+1]
 		}
 
-		attrs = append(attrs, slog.Any(key, val))
+		attrs = setAttr(attrs, slog.Any(key, val))
 	}
 
 	return context.WithValue(ctx, contextKey{}, attrs)
 }
+
+// setAttr replaces an attribute with the same key, so nested calls don't produce duplicate fields.
+func setAttr(attrs []slog.Attr, attr slog.Attr) []slog.Attr {
+	for i := range attrs {
+		if attrs[i].Key == attr.Key {
+			attrs[i] = attr
+			return attrs
+		}
+	}
+
+	return append(attrs, attr)
+}
@@ -105,3 +105,53 @@ var _ = Describe("Context tests", func() {
 		})
 	})
 })
+
+var _ = Describe("Structured output tests", func() {
+	var (
+		ctx    context.Context
+		logger *slog.Logger
+		output strings.Builder
+	)
+
+	readLog := func() map[string]any {
+		values := map[string]any{}
+		Expect(json.Unmarshal([]byte(output.String()), &values)).To(Succeed())
+
+		return values
+	}
+
+	BeforeEach(func() {
+		output = strings.Builder{}
+		logger = slog.New(clog.NewHandler(slog.NewJSONHandler(&output, nil)))
+		ctx = clog.Add(context.Background(), "profile", "github")
+	})
+
+	It("keeps context attributes in derived loggers", func() {
+		logger.With("component", "launcher").InfoContext(ctx, "message")
+		values := readLog()
+		Expect(values["component"]).To(Equal("launcher"))
+		Expect(values["profile"]).To(Equal("github"))
+	})
+
+	It("nests context attributes in groups", func() {
+		logger.WithGroup("backup").InfoContext(ctx, "message", "repo", "name")
+		Expect(readLog()["backup"]).To(Equal(map[string]any{"repo": "name", "profile": "github"}))
+	})
+
+	It("writes group and struct values as nested objects", func() {
+		ctx = clog.Add(ctx, "target", slog.GroupValue(slog.String("url", "git@example.com:repo.git")), "stats", struct {
+			Count int `json:"count"`
+		}{Count: 3})
+		logger.InfoContext(ctx, "message")
+		values := readLog()
+		Expect(values["target"]).To(Equal(map[string]any{"url": "git@example.com:repo.git"}))
+		Expect(values["stats"]).To(Equal(map[string]any{"count": 3.0}))
+	})
+
+	It("does not duplicate keys added again", func() {
+		ctx = clog.Add(ctx, "profile", "generic")
+		logger.InfoContext(ctx, "message")
+		Expect(strings.Count(output.String(), `"profile"`)).To(Equal(1))
+		Expect(readLog()["profile"]).To(Equal("generic"))
+	})
+})
@@ -2,7 +2,10 @@ package clog
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+
+	"github.com/AntonKosov/git-backups/internal/redact"
 )
 
 type Handler struct {
@@ -18,17 +21,63 @@ func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
 }
 
 func (h Handler) Handle(ctx context.Context, rec slog.Record) error {
+	redacted := slog.NewRecord(rec.Time, rec.Level, redact.String(rec.Message), rec.PC)
+	rec.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+
 	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
-		rec.AddAttrs(attrs...)
+		redacted.AddAttrs(redactAttrs(attrs)...)
 	}
 
-	return h.nextHandler.Handle(ctx, rec)
+	return h.nextHandler.Handle(ctx, redacted)
 }
 
 func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return h.nextHandler.WithAttrs(attrs)
+	return Handler{nextHandler: h.nextHandler.WithAttrs(redactAttrs(attrs))}
 }
 
 func (h Handler) WithGroup(name string) slog.Handler {
-	return h.nextHandler.WithGroup(name)
+	return Handler{nextHandler: h.nextHandler.WithGroup(name)}
+}
+
+func redactAttrs(attrs []slog.Attr) []slog.Attr {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = redactAttr(attr)
+	}
+
+	return redacted
+}
+
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, redact.String(value.String()))
+	case slog.KindGroup:
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redactAttrs(value.Group())...)}
+	case slog.KindAny:
+		return slog.Attr{Key: attr.Key, Value: redactAny(value)}
+	default:
+		return slog.Attr{Key: attr.Key, Value: value}
+	}
+}
+
+func redactAny(value slog.Value) slog.Value {
+	switch v := value.Any().(type) {
+	case error:
+		return slog.StringValue(redact.String(v.Error()))
+	case []string:
+		return slog.AnyValue(redact.Strings(v))
+	default:
+		// Arbitrary values (e.g. structs) are kept as they are unless their text form leaks something.
+		text := fmt.Sprintf("%+v", v)
+		if redactedText := redact.String(text); redactedText != text {
+			return slog.StringValue(redactedText)
+		}
+
+		return value
+	}
 }
@@ -0,0 +1,83 @@
+package clog_test
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/cmd"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/redact"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler tests", func() {
+	const (
+		token       = "very-secret-token-from-config"
+		urlPassword = "url-password-from-config"
+	)
+
+	var (
+		output strings.Builder
+		logger *slog.Logger
+		conf   config.Config
+	)
+
+	BeforeEach(func() {
+		output = strings.Builder{}
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{
+					Name: "generic",
+					Targets: []config.GenericTarget{{
+						URL:    "https://user:" + urlPassword + "@example.com/repo.git",
+						Folder: "repo",
+					}},
+				}},
+				GitHubProfiles: []config.GitHubProfile{{Name: "github", Token: token}},
+			},
+		}
+		redact.AddSecrets(conf.Secrets()...)
+	})
+
+	for _, format := range []string{"text", "json"} {
+		When("the output format is "+format, func() {
+			BeforeEach(func() {
+				options := &slog.HandlerOptions{Level: slog.LevelDebug}
+				if format == "json" {
+					logger = slog.New(clog.NewHandler(slog.NewJSONHandler(&output, options)))
+				} else {
+					logger = slog.New(clog.NewHandler(slog.NewTextHandler(&output, options)))
+				}
+			})
+
+			It("does not leak secrets", func() {
+				ctx := clog.Add(context.Background(), "profile", conf.Profiles.GitHubProfiles[0], "url", conf.Profiles.GenericProfiles[0].Targets[0].URL)
+				logger.InfoContext(ctx, "message with "+token)
+				logger.InfoContext(ctx, "attributes", "token", token, "profiles", conf.Profiles, "group", slog.GroupValue(slog.String("token", token)))
+				logger.With("token", token).InfoContext(ctx, "derived logger")
+				logger.ErrorContext(ctx, "command failed", "error", errors.Join(errors.New("exit status 128"), cmd.CommandError{
+					Name: "git",
+					Args: []string{"clone", "https://oauth2:" + token + "@example.com/repo.git"},
+					Err:  "fatal: Authentication failed for 'https://user:" + urlPassword + "@example.com/repo.git'",
+				}))
+				logger.InfoContext(ctx, "headers", "args", []string{"-H", "Authorization: Bearer " + token})
+
+				Expect(output.String()).NotTo(BeEmpty())
+				Expect(output.String()).NotTo(ContainSubstring(token))
+				Expect(output.String()).NotTo(ContainSubstring(urlPassword))
+				Expect(output.String()).To(ContainSubstring(redact.Mask))
+			})
+
+			It("keeps values without secrets", func() {
+				logger.Info("message", "count", 5, "name", "repo")
+				Expect(output.String()).To(ContainSubstring("message"))
+				Expect(output.String()).To(ContainSubstring("repo"))
+				Expect(output.String()).NotTo(ContainSubstring(redact.Mask))
+			})
+		})
+	}
+})
@@ -8,8 +8,10 @@ import (
 	"log/slog"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/redact"
 )
 
 type CommandError struct {
@@ -19,12 +21,14 @@ type CommandError struct {
 }
 
 func (ce CommandError) Error() string {
-	return fmt.Sprintf(`%v failed with "%v" (args: %v)`, ce.Name, ce.Err, ce.Args)
+	return fmt.Sprintf(`%v failed with "%v" (args: %v)`, ce.Name, redact.String(ce.Err), redact.Strings(ce.Args))
 }
 
 type Options struct {
 	args         []string
 	stdoutWriter io.Writer
+	stdinReader  io.Reader
+	timeout      time.Duration
 }
 
 type Option func(*Options)
@@ -41,6 +45,19 @@ func WithStdoutWriter(writer io.Writer) Option {
 	}
 }
 
+func WithStdinReader(reader io.Reader) Option {
+	return func(o *Options) {
+		o.stdinReader = reader
+	}
+}
+
+// WithTimeout kills the application if it doesn't finish in time.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.timeout = timeout
+	}
+}
+
 func Execute(ctx context.Context, name string, opts ...Option) error {
 	var options Options
 	for _, opt := range opts {
@@ -52,14 +69,29 @@ func Execute(ctx context.Context, name string, opts ...Option) error {
 	ctx = clog.Add(ctx, "name", name, "args", args)
 	slog.DebugContext(ctx, "Executing application...")
 	command := exec.Command(name, args...)
+	timeoutCtx := context.Background()
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		timeoutCtx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+		command = exec.CommandContext(timeoutCtx, name, args...)
+		// Children of a killed shell may keep the output open.
+		command.WaitDelay = time.Second
+	}
 
 	var stderr strings.Builder
 	command.Stderr = &stderr
 	if w := options.stdoutWriter; w != nil {
 		command.Stdout = w
 	}
+	if r := options.stdinReader; r != nil {
+		command.Stdin = r
+	}
 
 	if err := command.Run(); err != nil {
+		if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v: %w", options.timeout, err)
+		}
 		err = errors.Join(err, CommandError{Name: name, Args: args, Err: stderr.String()})
 		slog.ErrorContext(ctx, "Failed to run application", "error", err.Error())
 		return err
@@ -1,7 +1,109 @@
 package config
 
+import (
+	"net/url"
+	"time"
+)
+
 type Config struct {
-	Profiles Profiles
+	Schedule      Schedule
+	Notifications Notifications
+	Healthcheck   Healthcheck
+	Report        Report
+	Verify        Verify
+	Bundle        Bundle
+	Encryption    Encryption
+	S3            S3
+	Profiles      Profiles
+}
+
+// Schedule configures the daemon mode. Exactly one of Cron and Interval is set when the block is present.
+type Schedule struct {
+	Cron     string
+	Interval time.Duration
+	Jitter   time.Duration
+}
+
+type Notifications struct {
+	// StateFile keeps the outcome of the previous run to detect recoveries. Without it, recoveries are
+	// only detected between runs of the same daemon.
+	StateFile string
+	Channels  []NotificationChannel
+}
+
+type NotificationChannel struct {
+	// Name identifies the channel in the state file. It defaults to the type and the index of the channel.
+	Name string
+	// Type is webhook, slack, mattermost or email.
+	Type string
+	URL  string
+	// Events are always, failure and recovery.
+	Events []string
+	// Profiles limit the summary to these profiles. All profiles are included by default.
+	Profiles []string
+	SMTP     SMTP
+}
+
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	// TLS enables implicit TLS (usually on port 465). Otherwise STARTTLS is used when the server supports it.
+	TLS bool
+}
+
+// Healthcheck is a Healthchecks.io-style ping URL: "/start" is appended when a run starts, "/fail" when
+// it fails, and the URL itself is pinged on success. An empty URL disables pings.
+type Healthcheck struct {
+	URL string
+}
+
+// Report configures the JSON report written at the end of every run. An empty file disables the report.
+type Report struct {
+	File string
+}
+
+// Verify configures the integrity check of repositories after they are backed up.
+type Verify struct {
+	AfterBackup bool
+	// SamplePercent is the share of repositories verified per day, so that every repository is verified
+	// once in 100/SamplePercent days. Zero verifies all repositories.
+	SamplePercent int
+}
+
+// Bundle configures the export of repositories as git bundles into <Folder>/<profile>/<repository folder>.
+type Bundle struct {
+	Folder      string
+	AfterBackup bool
+	// Incremental bundles contain only objects which are missing from the previous bundle of the repository.
+	Incremental bool
+}
+
+// Encryption configures the recipients of exported artifacts. Artifacts are stored unencrypted if there
+// are no recipients.
+type Encryption struct {
+	// Recipients are age public keys or SSH public keys.
+	Recipients []string
+}
+
+// S3 configures the upload of bundles to S3-compatible object storage. Uploads are disabled without a bucket.
+type S3 struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket in the path instead of the host name, which MinIO requires.
+	PathStyle bool
+	// KeyPrefix is the layout of the keys of a repository with {profile} and {path} placeholders.
+	KeyPrefix string
+	// PartSize is the size of parts of multipart uploads. Smaller files are uploaded at once.
+	PartSize int64
+	// KeepChains is the number of bundle chains kept per repository. Zero keeps all of them.
+	KeepChains int
 }
 
 type Profiles struct {
@@ -14,11 +116,31 @@ type GenericProfile struct {
 	RootFolder    string
 	PrivateSSHKey *string
 	Targets       []GenericTarget
+	Healthcheck   Healthcheck
+	Remote        Remote
+	Maintenance   Maintenance
+	Disk          Disk
+	// Partial is inherited by the targets which don't override it.
+	Partial Partial
+	// Timeout limits every git command which talks to the remote. Zero is unlimited.
+	Timeout time.Duration
+	// LFS fetches the Git LFS objects of the repositories too.
+	LFS      bool
+	Schedule RepoSchedule
 }
 
+// GenericTarget inherits the settings of its profile which it doesn't override.
 type GenericTarget struct {
 	URL    string
 	Folder string
+	// PrivateSSHKey overrides the key of the profile if it's set.
+	PrivateSSHKey *string
+	Maintenance   Maintenance
+	MaxRepoSize   int64
+	Partial       Partial
+	Timeout       time.Duration
+	LFS           bool
+	Schedule      RepoSchedule
 }
 
 type GitHubProfile struct {
@@ -29,4 +151,168 @@ type GitHubProfile struct {
 	PrivateSSHKey *string
 	Include       []string
 	Exclude       []string
+	Filters       RepoFilters
+	Healthcheck   Healthcheck
+	Remote        Remote
+	Maintenance   Maintenance
+	Disk          Disk
+	Partial       Partial
+	Timeout       time.Duration
+	LFS           bool
+	Schedule      RepoSchedule
+	// Overrides replace the settings of the profile for some of its repositories.
+	Overrides []RepoOverride
+}
+
+// RepoOverride replaces the settings of a GitHub profile for the repositories matching the patterns, which
+// have the syntax of the include list. The last matching override applies. It inherits the settings which
+// it doesn't set from the profile.
+type RepoOverride struct {
+	Repos []string
+	// PrivateSSHKey overrides the key of the profile if it's set.
+	PrivateSSHKey *string
+	Maintenance   Maintenance
+	MaxRepoSize   int64
+	Partial       Partial
+	Timeout       time.Duration
+	LFS           bool
+	Schedule      RepoSchedule
+}
+
+// Remote is the storage which the repositories of a profile are kept in. The root folder is then a local
+// staging area. Repositories are kept only in the root folder if no remote is set.
+type Remote struct {
+	SFTP SFTP
+}
+
+// SFTP is a folder on an SSH server. It's disabled without an address.
+type SFTP struct {
+	// Address is the host with an optional port, 22 by default.
+	Address    string
+	User       string
+	PrivateKey string
+	// KnownHosts is a known_hosts file with the key of the server.
+	KnownHosts string
+	Folder     string
+}
+
+// Maintenance configures the housekeeping of the repositories of a profile after they are backed up.
+// It's disabled without an interval.
+type Maintenance struct {
+	// Interval is the time between maintenance runs of a repository.
+	Interval time.Duration
+	// Tasks are gc, commit-graph, incremental-repack and multi-pack-index, run in the given order.
+	Tasks []string
+	// PruneAfter is the age of unreachable objects which gc prunes.
+	PruneAfter time.Duration
+	// KeepSnapshots is the number of ref snapshots kept in every repository. Objects referenced by kept
+	// snapshots are never pruned.
+	KeepSnapshots int
+}
+
+// Disk guards the file system of the root folder of a profile. Zero values disable the checks.
+type Disk struct {
+	// MinFree is the free space in bytes which must be left. The profile isn't backed up if there is less,
+	// and repositories whose clone would leave less are not cloned.
+	MinFree int64
+	// MinFreePercent is the same limit relative to the size of the file system.
+	MinFreePercent float64
+	// MaxRepoSize skips repositories which are larger, either on GitHub or in the root folder.
+	MaxRepoSize int64
+}
+
+// Partial limits what is backed up from huge repositories, so that their backups are intentionally
+// incomplete. The whole repository is backed up if nothing is set.
+type Partial struct {
+	// BlobLimit leaves out blobs larger than this many bytes.
+	BlobLimit int64
+	// Refs are the refs to back up, e.g. refs/heads/main or refs/tags/*. Branches and tags are backed up
+	// if it's empty.
+	Refs []string
+	// ExcludeRefs are left out even if they match Refs.
+	ExcludeRefs []string
+	// ShallowSince leaves out commits older than this time.
+	ShallowSince time.Time
+}
+
+func (p Partial) IsZero() bool {
+	return p.BlobLimit == 0 && len(p.Refs) == 0 && len(p.ExcludeRefs) == 0 && p.ShallowSince.IsZero()
+}
+
+// RepoSchedule limits how often a repository is backed up. Runs skip the repository until the next time
+// after its last backup. At most one of Cron and Interval is set, and the repository is backed up on every
+// run if neither is.
+type RepoSchedule struct {
+	Cron     string
+	Interval time.Duration
+}
+
+func (s RepoSchedule) IsZero() bool {
+	return s.Cron == "" && s.Interval == 0
+}
+
+type RepoFilters struct {
+	Fork      *bool
+	Archived  *bool
+	Private   *bool
+	MinSize   *int64
+	MaxSize   *int64
+	Languages []string
+	Topics    []string
+}
+
+func (c Config) Secrets() []string {
+	secrets := make([]string, 0, len(c.Profiles.GitHubProfiles))
+	for _, profile := range c.Profiles.GenericProfiles {
+		for _, target := range profile.Targets {
+			if u, err := url.Parse(target.URL); err == nil && u.User != nil {
+				if password, ok := u.User.Password(); ok {
+					secrets = append(secrets, password)
+				}
+			}
+		}
+	}
+
+	for _, profile := range c.Profiles.GitHubProfiles {
+		secrets = append(secrets, profile.Token)
+	}
+
+	if c.S3.SecretAccessKey != "" {
+		secrets = append(secrets, c.S3.SecretAccessKey)
+	}
+
+	// Webhook URLs of chats contain tokens.
+	for _, channel := range c.Notifications.Channels {
+		for _, secret := range []string{channel.URL, channel.SMTP.Password} {
+			if secret != "" {
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+
+	// Ping URLs are enough to report a fake status.
+	for _, healthcheck := range c.healthchecks() {
+		secrets = append(secrets, healthcheck.URL)
+	}
+
+	return secrets
+}
+
+func (c Config) healthchecks() []Healthcheck {
+	var healthchecks []Healthcheck
+	add := func(healthcheck Healthcheck) {
+		if healthcheck.URL != "" {
+			healthchecks = append(healthchecks, healthcheck)
+		}
+	}
+
+	add(c.Healthcheck)
+	for _, profile := range c.Profiles.GenericProfiles {
+		add(profile.Healthcheck)
+	}
+	for _, profile := range c.Profiles.GitHubProfiles {
+		add(profile.Healthcheck)
+	}
+
+	return healthchecks
 }
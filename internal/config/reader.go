@@ -6,16 +6,46 @@ import (
 	yaml "github.com/goccy/go-yaml"
 )
 
+type Reader struct {
+}
+
+func (r Reader) Read(fileName string) (Config, error) {
+	return ReadConfig(fileName)
+}
+
+func (r Reader) Validate(fileName string) error {
+	return Validate(fileName)
+}
+
 func ReadConfig(fileName string) (Config, error) {
+	return readConfig(fileName, false)
+}
+
+func readConfig(fileName string, checkFS bool) (Config, error) {
 	configFile, err := os.ReadFile(fileName)
 	if err != nil {
 		return Config{}, err
 	}
 
+	// Unknown fields are reported by the validator together with the other problems.
 	var conf v1
 	if err := yaml.Unmarshal(configFile, &conf); err != nil {
 		return Config{}, err
 	}
 
-	return conf.transform(), nil
+	validator, err := newValidator(configFile, checkFS)
+	if err != nil {
+		return Config{}, err
+	}
+
+	if problems := validator.validate(conf); len(problems) > 0 {
+		return Config{}, ValidationError{FileName: fileName, Problems: problems}
+	}
+
+	result, err := conf.transform()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return result, nil
 }
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/cmd"
+)
+
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// secretCommandTimeout keeps a hanging helper, e.g. a password manager waiting for input, from blocking
+// the start.
+const secretCommandTimeout = time.Minute
+
+type secretSource struct {
+	value   string
+	file    string
+	command string
+}
+
+func (s secretSource) resolve(field string) (string, error) {
+	sources := 0
+	for _, source := range []string{s.value, s.file, s.command} {
+		if source != "" {
+			sources++
+		}
+	}
+
+	switch {
+	case sources > 1:
+		return "", fmt.Errorf("only one of %[1]v, %[1]v_file and %[1]v_command can be set", field)
+	case s.file != "":
+		return readSecretFile(field, s.file)
+	case s.command != "":
+		return runSecretCommand(field, s.command)
+	default:
+		return expandEnvVars(field, s.value)
+	}
+}
+
+func expandEnvVars(field, value string) (string, error) {
+	var missing []string
+	expanded := envVarPattern.ReplaceAllStringFunc(value, func(match string) string {
+		name := envVarPattern.FindStringSubmatch(match)[1]
+		envValue, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+
+		return envValue
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%v references unset environment variables: %v", field, strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+func readSecretFile(field, fileName string) (string, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %v_file %v: %w", field, fileName, unwrapPathError(err))
+	}
+
+	value := strings.TrimSpace(string(content))
+	if value == "" {
+		return "", fmt.Errorf("%v_file %v is empty", field, fileName)
+	}
+
+	return value, nil
+}
+
+func runSecretCommand(field, command string) (string, error) {
+	var stdout strings.Builder
+	err := cmd.Execute(
+		context.Background(),
+		"sh",
+		cmd.WithArguments("-c", command),
+		cmd.WithStdoutWriter(&stdout),
+		cmd.WithTimeout(secretCommandTimeout),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to run %v_command: %w", field, err)
+	}
+
+	value := strings.TrimSpace(stdout.String())
+	if value == "" {
+		return "", fmt.Errorf("%v_command returned an empty value", field)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses sizes like "512", "100KB" or "1.5GB". Units are binary: 1KB is 1024 bytes.
+func parseSize(size string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if rest, ok := strings.CutSuffix(text, unit.suffix); ok {
+			text, multiplier = strings.TrimSpace(rest), unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected a number with an optional B, KB, MB, GB or TB unit)", size)
+	}
+
+	return int64(value * float64(multiplier)), nil
+}
+
+func parseOptionalSize(size *string) (*int64, error) {
+	if size == nil {
+		return nil, nil
+	}
+
+	value, err := parseSize(*size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &value, nil
+}
+
+// parseFreeSpace parses a size or a percentage of the file system like "5%".
+func parseFreeSpace(text string) (size int64, percent float64, err error) {
+	if value, ok := strings.CutSuffix(strings.TrimSpace(text), "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, 0, fmt.Errorf("invalid percentage %q (expected a number from 0 to 100 followed by %%)", text)
+		}
+
+		return 0, percent, nil
+	}
+
+	size, err = parseSize(text)
+
+	return size, 0, err
+}
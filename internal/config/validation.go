@@ -0,0 +1,652 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/AntonKosov/git-backups/internal/crypt"
+	"github.com/AntonKosov/git-backups/internal/pattern"
+	"github.com/AntonKosov/git-backups/internal/schedule"
+	yaml "github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+const supportedVersion = 1
+
+var (
+	affiliations       = []string{"owner", "collaborator", "organization_member"}
+	notificationTypes  = []string{"webhook", "slack", "mattermost", "email"}
+	notificationEvents = []string{"always", "failure", "recovery"}
+)
+
+type Problem struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("line %v, column %v: %v: %v", p.Line, p.Column, p.Path, p.Message)
+}
+
+type ValidationError struct {
+	FileName string
+	Problems []Problem
+}
+
+func (ve ValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%v has %v problem(s):", ve.FileName, len(ve.Problems))
+	for _, problem := range ve.Problems {
+		sb.WriteString("\n  ")
+		sb.WriteString(problem.String())
+	}
+
+	return sb.String()
+}
+
+// Validate additionally checks that SSH keys are readable and root folders are writable.
+func Validate(fileName string) error {
+	_, err := readConfig(fileName, true)
+
+	return err
+}
+
+type validator struct {
+	file     *ast.File
+	checkFS  bool
+	problems []Problem
+}
+
+func newValidator(content []byte, checkFS bool) (*validator, error) {
+	file, err := parser.ParseBytes(content, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &validator{file: file, checkFS: checkFS}, nil
+}
+
+func (v *validator) validate(conf v1) []Problem {
+	if conf.Version != supportedVersion {
+		v.report("$.version", "unsupported version %v (supported: %v)", conf.Version, supportedVersion)
+	}
+
+	v.validateSchedule(conf.Schedule)
+	if conf.Verify.SamplePercent < 0 || conf.Verify.SamplePercent > 100 {
+		v.report("$.verify.sample_percent", "sample_percent must be between 0 and 100")
+	}
+	v.validateBundle(conf.Bundle)
+	for i, recipient := range conf.Encryption.Recipients {
+		if _, err := crypt.ParseRecipient(recipient); err != nil {
+			v.report(fmt.Sprintf("$.encryption.recipients[%v]", i), "%v", err)
+		}
+	}
+	v.validateS3(conf.S3, conf.Bundle.Folder)
+	v.validateNotifications(conf)
+	v.validateHealthcheck("$.healthcheck", conf.Healthcheck)
+	v.validateKey("$.defaults.private_ssh_key", conf.Defaults.PrivateSSHKey)
+	v.validateMaintenance("$.defaults.maintenance", conf.Defaults.Maintenance, maintenanceSettings{})
+	v.validateDisk("$.defaults.disk", conf.Defaults.Disk)
+	v.validatePartial("$.defaults.partial", conf.Defaults.Partial)
+	v.validateTimeout("$.defaults.timeout", conf.Defaults.Timeout)
+	v.validateRepoSchedule("$.defaults.schedule", conf.Defaults.Schedule)
+
+	targetFolders := map[string]string{}
+	checkTargetFolder := func(nodePath, rootFolder, folder string) {
+		fullPath := path.Clean(path.Join(rootFolder, folder))
+		if firstPath, ok := targetFolders[fullPath]; ok {
+			v.report(nodePath, "target folder %v is already used by %v", fullPath, firstPath)
+			return
+		}
+		targetFolders[fullPath] = nodePath
+	}
+
+	for i, profile := range conf.Profiles.Generic {
+		profilePath := fmt.Sprintf("$.profiles.generic[%v]", i)
+		v.validateProfile(profilePath, profile.Name, profile.RootFolder, profile.PrivateSSHKey)
+		v.validateHealthcheck(profilePath+".healthcheck", profile.Healthcheck)
+		if len(profile.Targets) == 0 {
+			v.report(profilePath+".targets", "at least one target is required")
+		}
+
+		for j, target := range profile.Targets {
+			targetPath := fmt.Sprintf("%v.targets[%v]", profilePath, j)
+			if target.URL == "" && target.URLFile == "" && target.URLCommand == "" {
+				v.report(targetPath+".url", "url is required")
+			}
+			if target.Folder == "" {
+				v.report(targetPath+".folder", "folder is required")
+				continue
+			}
+			if profile.RootFolder != "" {
+				checkTargetFolder(targetPath+".folder", profile.RootFolder, target.Folder)
+			}
+			v.validateKey(targetPath+".private_ssh_key", target.PrivateSSHKey)
+			v.validateMaintenance(targetPath+".maintenance", target.Maintenance, profile.Maintenance.inherit(conf.Defaults.Maintenance))
+			v.validateRepoDisk(targetPath+".disk", target.Disk)
+			v.validatePartial(targetPath+".partial", target.Partial)
+			v.validateTimeout(targetPath+".timeout", target.Timeout)
+			v.validateRepoSchedule(targetPath+".schedule", target.Schedule)
+		}
+		v.validateSFTP(profilePath+".remote.sftp", profile.Remote.SFTP)
+		v.validateMaintenance(profilePath+".maintenance", profile.Maintenance, conf.Defaults.Maintenance)
+		v.validateDisk(profilePath+".disk", profile.Disk)
+		v.validatePartial(profilePath+".partial", profile.Partial)
+		v.validateTimeout(profilePath+".timeout", profile.Timeout)
+		v.validateRepoSchedule(profilePath+".schedule", profile.Schedule)
+	}
+
+	for i, profile := range conf.Profiles.GitHub {
+		profilePath := fmt.Sprintf("$.profiles.github[%v]", i)
+		v.validateProfile(profilePath, profile.Name, profile.RootFolder, profile.PrivateSSHKey)
+		v.validateHealthcheck(profilePath+".healthcheck", profile.Healthcheck)
+		v.validateAffiliation(profilePath+".affiliation", profile.Affiliation)
+		v.validatePatterns(profilePath+".include", profile.Include)
+		v.validatePatterns(profilePath+".exclude", profile.Exclude)
+		v.validateSize(profilePath+".filters.min_size", profile.Filters.MinSize)
+		v.validateSize(profilePath+".filters.max_size", profile.Filters.MaxSize)
+		v.validateSFTP(profilePath+".remote.sftp", profile.Remote.SFTP)
+		v.validateMaintenance(profilePath+".maintenance", profile.Maintenance, conf.Defaults.Maintenance)
+		v.validateDisk(profilePath+".disk", profile.Disk)
+		v.validatePartial(profilePath+".partial", profile.Partial)
+		v.validateTimeout(profilePath+".timeout", profile.Timeout)
+		v.validateRepoSchedule(profilePath+".schedule", profile.Schedule)
+
+		for j, override := range profile.Overrides {
+			overridePath := fmt.Sprintf("%v.overrides[%v]", profilePath, j)
+			if len(override.Repos) == 0 {
+				v.report(overridePath+".repos", "at least one repository pattern is required")
+			}
+			v.validatePatterns(overridePath+".repos", override.Repos)
+			v.validateKey(overridePath+".private_ssh_key", override.PrivateSSHKey)
+			v.validateMaintenance(overridePath+".maintenance", override.Maintenance, profile.Maintenance.inherit(conf.Defaults.Maintenance))
+			v.validateRepoDisk(overridePath+".disk", override.Disk)
+			v.validatePartial(overridePath+".partial", override.Partial)
+			v.validateTimeout(overridePath+".timeout", override.Timeout)
+			v.validateRepoSchedule(overridePath+".schedule", override.Schedule)
+		}
+	}
+
+	for _, doc := range v.file.Docs {
+		v.validateFields("$", doc.Body, reflect.TypeFor[v1]())
+	}
+
+	// Problems are listed in the order they appear in the file.
+	slices.SortStableFunc(v.problems, func(a, b Problem) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Column, b.Column))
+	})
+
+	return v.problems
+}
+
+// validateFields reports keys which don't match any field of the config, since decoding ignores them.
+func (v *validator) validateFields(nodePath string, node ast.Node, typ reflect.Type) {
+	switch n := node.(type) {
+	case *ast.AnchorNode:
+		v.validateFields(nodePath, n.Value, typ)
+	case *ast.TagNode:
+		v.validateFields(nodePath, n.Value, typ)
+	case *ast.MappingNode:
+		for _, value := range n.Values {
+			v.validateFields(nodePath, value, typ)
+		}
+	case *ast.MappingValueNode:
+		for typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct || n.Key.IsMergeKey() {
+			return
+		}
+		key := n.Key.GetToken().Value
+		field, ok := yamlField(typ, key)
+		if !ok {
+			pos := n.Key.GetToken().Position
+			v.problems = append(v.problems, Problem{
+				Line:    pos.Line,
+				Column:  pos.Column,
+				Path:    nodePath + "." + key,
+				Message: fmt.Sprintf("unknown field %q", key),
+			})
+			return
+		}
+		v.validateFields(nodePath+"."+key, n.Value, field.Type)
+	case *ast.SequenceNode:
+		if typ.Kind() != reflect.Slice {
+			return
+		}
+		for i, value := range n.Values {
+			v.validateFields(fmt.Sprintf("%v[%v]", nodePath, i), value, typ.Elem())
+		}
+	}
+}
+
+func yamlField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	for _, field := range reflect.VisibleFields(typ) {
+		if name, _, _ := strings.Cut(field.Tag.Get("yaml"), ","); name == key {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+func (v *validator) validateSchedule(s scheduleSettings) {
+	if s.Cron != "" && s.Interval != "" {
+		v.report("$.schedule", "cron and interval are mutually exclusive")
+	}
+
+	if s.Cron != "" {
+		if _, err := schedule.ParseCron(s.Cron); err != nil {
+			v.report("$.schedule.cron", "%v", err)
+		}
+	}
+
+	if interval, err := parseOptionalDuration(s.Interval); err != nil {
+		v.report("$.schedule.interval", "%v", err)
+	} else if s.Interval != "" && interval == 0 {
+		v.report("$.schedule.interval", "interval must be positive")
+	}
+
+	if _, err := parseOptionalDuration(s.Jitter); err != nil {
+		v.report("$.schedule.jitter", "%v", err)
+	}
+}
+
+func (v *validator) validateNotifications(conf v1) {
+	profiles := map[string]bool{}
+	for _, profile := range conf.Profiles.Generic {
+		profiles[profile.Name] = true
+	}
+	for _, profile := range conf.Profiles.GitHub {
+		profiles[profile.Name] = true
+	}
+
+	names := map[string]bool{}
+	for i, channel := range conf.Notifications.Channels {
+		channelPath := fmt.Sprintf("$.notifications.channels[%v]", i)
+		if channel.Name != "" {
+			if names[channel.Name] {
+				v.report(channelPath+".name", "duplicate channel name %q", channel.Name)
+			}
+			names[channel.Name] = true
+		}
+
+		switch {
+		case channel.Type == "":
+			v.report(channelPath+".type", "type is required (allowed: %v)", strings.Join(notificationTypes, ", "))
+		case !slices.Contains(notificationTypes, channel.Type):
+			v.report(channelPath+".type", "unknown type %q (allowed: %v)", channel.Type, strings.Join(notificationTypes, ", "))
+		case channel.Type == "email":
+			v.validateSMTP(channelPath+".smtp", channel.SMTP)
+		case channel.URL == "" && channel.URLFile == "" && channel.URLCommand == "":
+			v.report(channelPath+".url", "url is required")
+		}
+
+		for j, event := range channel.Events {
+			if !slices.Contains(notificationEvents, event) {
+				v.report(fmt.Sprintf("%v.events[%v]", channelPath, j), "unknown event %q (allowed: %v)", event, strings.Join(notificationEvents, ", "))
+			}
+		}
+
+		for j, profile := range channel.Profiles {
+			if !profiles[profile] {
+				v.report(fmt.Sprintf("%v.profiles[%v]", channelPath, j), "unknown profile %q", profile)
+			}
+		}
+	}
+}
+
+func (v *validator) validateHealthcheck(healthcheckPath string, h healthcheck) {
+	// Environment variables are expanded later, so only URLs without references can be checked here.
+	if h.URL == "" || envVarPattern.MatchString(h.URL) {
+		return
+	}
+
+	if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.report(healthcheckPath+".url", "invalid URL %q (expected an http or https URL)", h.URL)
+	}
+}
+
+func (v *validator) validateSMTP(smtpPath string, smtp smtpSettings) {
+	if smtp.Host == "" {
+		v.report(smtpPath+".host", "host is required")
+	}
+	if smtp.From == "" {
+		v.report(smtpPath+".from", "from is required")
+	}
+	if len(smtp.To) == 0 {
+		v.report(smtpPath+".to", "at least one recipient is required")
+	}
+	if smtp.Port < 0 || smtp.Port > 65535 {
+		v.report(smtpPath+".port", "invalid port %v", smtp.Port)
+	}
+}
+
+func (v *validator) validateBundle(bundle bundleSettings) {
+	if bundle.Folder == "" {
+		if bundle.AfterBackup {
+			v.report("$.bundle", "folder is required to bundle after backups")
+		}
+
+		return
+	}
+
+	if v.checkFS {
+		if err := checkWritableFolder(bundle.Folder); err != nil {
+			v.report("$.bundle.folder", "%v", err)
+		}
+	}
+}
+
+// minS3PartSize is the smallest part of multipart uploads accepted by S3.
+const minS3PartSize = 5 << 20
+
+func (v *validator) validateS3(s3 s3Settings, bundleFolder string) {
+	if s3.Bucket == "" {
+		return
+	}
+
+	if bundleFolder == "" {
+		v.report("$.s3", "bundle folder is required to upload bundles")
+	}
+
+	if s3.Endpoint != "" {
+		if u, err := url.Parse(s3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.report("$.s3.endpoint", "invalid endpoint %q (expected an http or https URL)", s3.Endpoint)
+		}
+	}
+
+	if s3.AccessKeyID == "" {
+		v.report("$.s3.access_key_id", "access_key_id is required")
+	}
+
+	if s3.SecretAccessKey == "" && s3.SecretAccessKeyFile == "" && s3.SecretAccessKeyCommand == "" {
+		v.report("$.s3.secret_access_key", "secret_access_key is required")
+	}
+
+	if s3.KeyPrefix != "" && !strings.Contains(s3.KeyPrefix, "{path}") {
+		v.report("$.s3.key_prefix", "key_prefix must contain {path}")
+	}
+
+	if s3.PartSize != "" {
+		if size, err := parseSize(s3.PartSize); err != nil {
+			v.report("$.s3.part_size", "%v", err)
+		} else if size < minS3PartSize {
+			v.report("$.s3.part_size", "part_size must be at least 5MB")
+		}
+	}
+
+	if s3.KeepChains < 0 {
+		v.report("$.s3.keep_chains", "keep_chains must not be negative")
+	}
+}
+
+func (v *validator) validateProfile(profilePath, name, rootFolder string, privateSSHKey *string) {
+	if name == "" {
+		v.report(profilePath+".profile", "profile name is required")
+	}
+
+	if rootFolder == "" {
+		v.report(profilePath+".root_folder", "root_folder is required")
+	} else if v.checkFS {
+		if err := checkWritableFolder(rootFolder); err != nil {
+			v.report(profilePath+".root_folder", "%v", err)
+		}
+	}
+
+	v.validateKey(profilePath+".private_ssh_key", privateSSHKey)
+}
+
+func (v *validator) validateKey(nodePath string, privateSSHKey *string) {
+	if privateSSHKey != nil && v.checkFS {
+		if err := checkReadableFile(*privateSSHKey); err != nil {
+			v.report(nodePath, "%v", err)
+		}
+	}
+}
+
+func (v *validator) validateSFTP(nodePath string, sftp sftpSettings) {
+	if sftp == (sftpSettings{}) {
+		return
+	}
+
+	if sftp.Address == "" {
+		v.report(nodePath+".address", "address is required")
+	}
+	if sftp.User == "" {
+		v.report(nodePath+".user", "user is required")
+	}
+	if sftp.Folder == "" {
+		v.report(nodePath+".folder", "folder is required")
+	}
+
+	files := []struct{ field, fileName string }{{"private_key", sftp.PrivateKey}, {"known_hosts", sftp.KnownHosts}}
+	for _, file := range files {
+		if file.fileName == "" {
+			v.report(nodePath+"."+file.field, "%v is required", file.field)
+		} else if v.checkFS {
+			if err := checkReadableFile(file.fileName); err != nil {
+				v.report(nodePath+"."+file.field, "%v", err)
+			}
+		}
+	}
+}
+
+// validateMaintenance doesn't require an interval if the defaults have one. "never" and a zero interval
+// disable the maintenance.
+func (v *validator) validateMaintenance(nodePath string, m, defaults maintenanceSettings) {
+	if m.Interval == "" && defaults.Interval == "" {
+		if len(m.Tasks) > 0 || m.PruneAfter != "" || m.KeepSnapshots != nil {
+			v.report(nodePath+".interval", "interval is required")
+		}
+
+		return
+	}
+
+	if m.Interval != "" && m.Interval != maintenanceNever {
+		if _, err := parseInterval(m.Interval); err != nil {
+			v.report(nodePath+".interval", "%v", err)
+		}
+	}
+
+	for i, task := range m.Tasks {
+		if !slices.Contains(maintenanceTasks, task) {
+			v.report(fmt.Sprintf("%v.tasks[%v]", nodePath, i), "unknown task %q (allowed: %v)", task, strings.Join(maintenanceTasks, ", "))
+		}
+	}
+
+	if _, err := parseInterval(m.PruneAfter); err != nil {
+		v.report(nodePath+".prune_after", "%v", err)
+	}
+
+	if m.KeepSnapshots != nil && *m.KeepSnapshots < 0 {
+		v.report(nodePath+".keep_snapshots", "keep_snapshots must not be negative")
+	}
+}
+
+func (v *validator) validateDisk(nodePath string, d diskSettings) {
+	if d.MinFree != "" {
+		if _, _, err := parseFreeSpace(d.MinFree); err != nil {
+			v.report(nodePath+".min_free", "%v", err)
+		}
+	}
+
+	if d.MaxRepoSize != "" {
+		if _, err := parseSize(d.MaxRepoSize); err != nil {
+			v.report(nodePath+".max_repo_size", "%v", err)
+		}
+	}
+}
+
+// validateRepoDisk rejects min_free since the free space is checked for the root folder of the profile.
+func (v *validator) validateRepoDisk(nodePath string, d diskSettings) {
+	if d.MinFree != "" {
+		v.report(nodePath+".min_free", "min_free can only be set for a profile")
+	}
+
+	v.validateDisk(nodePath, diskSettings{MaxRepoSize: d.MaxRepoSize})
+}
+
+func (v *validator) validatePartial(nodePath string, p partialSettings) {
+	if p.BlobLimit != "" {
+		if _, err := parseSize(p.BlobLimit); err != nil {
+			v.report(nodePath+".blob_limit", "%v", err)
+		}
+	}
+
+	lists := []struct {
+		field string
+		refs  []string
+	}{{"refs", p.Refs}, {"exclude_refs", p.ExcludeRefs}}
+	for _, list := range lists {
+		for i, ref := range list.refs {
+			if !strings.HasPrefix(ref, "refs/") || strings.Count(ref, "*") > 1 {
+				v.report(fmt.Sprintf("%v.%v[%v]", nodePath, list.field, i), "invalid ref pattern %q (expected refs/... with at most one *)", ref)
+			}
+		}
+	}
+
+	if p.ShallowSince != "" {
+		if _, err := parseDate(p.ShallowSince); err != nil {
+			v.report(nodePath+".shallow_since", "%v", err)
+		}
+	}
+}
+
+func (v *validator) validateTimeout(nodePath, timeout string) {
+	if _, err := parseOptionalDuration(timeout); err != nil {
+		v.report(nodePath, "%v", err)
+	}
+}
+
+// validateRepoSchedule accepts a zero interval which backs up the repositories on every run.
+func (v *validator) validateRepoSchedule(nodePath string, s repoSchedule) {
+	if s.Cron != "" && s.Interval != "" {
+		v.report(nodePath, "cron and interval are mutually exclusive")
+	}
+
+	if s.Cron != "" {
+		if _, err := schedule.ParseCron(s.Cron); err != nil {
+			v.report(nodePath+".cron", "%v", err)
+		}
+	}
+
+	if _, err := parseInterval(s.Interval); err != nil {
+		v.report(nodePath+".interval", "%v", err)
+	}
+}
+
+func (v *validator) validateAffiliation(nodePath, affiliation string) {
+	if affiliation == "" {
+		v.report(nodePath, "affiliation is required (allowed: %v)", strings.Join(affiliations, ", "))
+		return
+	}
+
+	for value := range strings.SplitSeq(affiliation, ",") {
+		if !slices.Contains(affiliations, strings.TrimSpace(value)) {
+			v.report(nodePath, "unknown affiliation %q (allowed: %v)", value, strings.Join(affiliations, ", "))
+		}
+	}
+}
+
+func (v *validator) validatePatterns(nodePath string, patterns []string) {
+	for i, text := range patterns {
+		if _, err := pattern.Compile(text); err != nil {
+			v.report(fmt.Sprintf("%v[%v]", nodePath, i), "%v", err)
+		}
+	}
+}
+
+func (v *validator) validateSize(nodePath string, size *string) {
+	if size == nil {
+		return
+	}
+
+	if _, err := parseSize(*size); err != nil {
+		v.report(nodePath, "%v", err)
+	}
+}
+
+func (v *validator) report(nodePath, format string, args ...any) {
+	line, column := v.position(nodePath)
+	v.problems = append(v.problems, Problem{
+		Line:    line,
+		Column:  column,
+		Path:    nodePath,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// position returns the location of the node or, if the node is missing, of its closest existing parent.
+func (v *validator) position(nodePath string) (line, column int) {
+	for nodePath != "$" && nodePath != "" {
+		if p, err := yaml.PathString(nodePath); err == nil {
+			if node, err := p.FilterFile(v.file); err == nil && node != nil {
+				if mapping, ok := node.(*ast.MappingNode); ok && len(mapping.Values) > 0 {
+					node = mapping.Values[0].Key
+				}
+				pos := node.GetToken().Position
+				return pos.Line, pos.Column
+			}
+		}
+
+		nodePath = nodePath[:max(strings.LastIndexAny(nodePath, ".["), 0)]
+	}
+
+	return 1, 1
+}
+
+func checkReadableFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return fmt.Errorf("file %v is not readable: %w", fileName, unwrapPathError(err))
+	}
+
+	return file.Close()
+}
+
+func checkWritableFolder(folder string) error {
+	// A missing folder is created on the first backup, so its closest existing parent must be writable.
+	existing := filepath.Clean(folder)
+	for {
+		info, err := os.Stat(existing)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%v is not a folder", existing)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) || filepath.Dir(existing) == existing {
+			return fmt.Errorf("folder %v is not accessible: %w", existing, unwrapPathError(err))
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	file, err := os.CreateTemp(existing, ".git-backups-write-check-*")
+	if err != nil {
+		return fmt.Errorf("folder %v is not writable: %w", existing, unwrapPathError(err))
+	}
+	file.Close()
+
+	return os.Remove(file.Name())
+}
+
+func unwrapPathError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+
+	return err
+}
@@ -0,0 +1,83 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+)
+
+// Extension is appended to the names of encrypted files.
+const Extension = ".age"
+
+type (
+	Recipient = age.Recipient
+	Identity  = age.Identity
+)
+
+// ParseRecipient parses an age public key ("age1...") or an SSH public key ("ssh-ed25519 ..." or
+// "ssh-rsa ...").
+func ParseRecipient(value string) (Recipient, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "ssh-") {
+		return agessh.ParseRecipient(value)
+	}
+
+	return age.ParseX25519Recipient(value)
+}
+
+func ParseRecipients(values []string) ([]Recipient, error) {
+	recipients := make([]Recipient, 0, len(values))
+	for _, value := range values {
+		recipient, err := ParseRecipient(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", value, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// ReadIdentities reads an age identity file or an unencrypted SSH private key.
+func ReadIdentities(fileName string) ([]Identity, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(content, []byte("PRIVATE KEY-----")) {
+		identity, err := agessh.ParseIdentity(content)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Identity{identity}, nil
+	}
+
+	return age.ParseIdentities(bytes.NewReader(content))
+}
+
+// Encrypt returns a writer which encrypts everything written to it into the destination. The writer
+// must be closed to flush the last chunk.
+func Encrypt(destination io.Writer, recipients []Recipient) (io.WriteCloser, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	return age.Encrypt(destination, recipients...)
+}
+
+// Decrypt returns a reader of the decrypted source. Data is decrypted in chunks while it's read.
+func Decrypt(source io.Reader, identities []Identity) (io.Reader, error) {
+	if len(identities) == 0 {
+		return nil, errors.New("no identities to decrypt with")
+	}
+
+	return age.Decrypt(source, identities...)
+}
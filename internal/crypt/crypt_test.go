@@ -0,0 +1,79 @@
+package crypt_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/AntonKosov/git-backups/internal/crypt"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+)
+
+var _ = Describe("Crypt tests", func() {
+	var identityFile string
+
+	roundTrip := func(recipient string) []byte {
+		recipients, err := crypt.ParseRecipients([]string{recipient})
+		Expect(err).NotTo(HaveOccurred())
+
+		var encrypted bytes.Buffer
+		writer, err := crypt.Encrypt(&encrypted, recipients)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = io.WriteString(writer, "secret bundle")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(writer.Close()).To(Succeed())
+		Expect(encrypted.String()).NotTo(ContainSubstring("secret bundle"))
+
+		identities, err := crypt.ReadIdentities(identityFile)
+		Expect(err).NotTo(HaveOccurred())
+		reader, err := crypt.Decrypt(&encrypted, identities)
+		Expect(err).NotTo(HaveOccurred())
+		decrypted, err := io.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+
+		return decrypted
+	}
+
+	BeforeEach(func() {
+		identityFile = filepath.Join(GinkgoT().TempDir(), "identity")
+	})
+
+	It("encrypts for age recipients", func() {
+		identity, err := age.GenerateX25519Identity()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(identityFile, []byte("# test key\n"+identity.String()+"\n"), 0o600)).To(Succeed())
+
+		Expect(roundTrip(identity.Recipient().String())).To(Equal([]byte("secret bundle")))
+	})
+
+	It("encrypts for SSH recipients", func() {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		block, err := ssh.MarshalPrivateKey(privateKey, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(identityFile, pem.EncodeToMemory(block), 0o600)).To(Succeed())
+		sshPublicKey, err := ssh.NewPublicKey(publicKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(roundTrip(string(ssh.MarshalAuthorizedKey(sshPublicKey)))).To(Equal([]byte("secret bundle")))
+	})
+
+	It("rejects malformed recipients", func() {
+		_, err := crypt.ParseRecipients([]string{"age1nope"})
+		Expect(err).To(MatchError(ContainSubstring(`invalid recipient "age1nope"`)))
+	})
+
+	It("requires recipients and identities", func() {
+		_, err := crypt.Encrypt(io.Discard, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = crypt.Decrypt(bytes.NewReader(nil), nil)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,36 @@
+package fsutil
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteAtomically replaces the file with the output of write, so that readers never see a partially
+// written file. The file is left unchanged if writing fails.
+func WriteAtomically(fileName string, write func(io.Writer) error) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	if err := write(file); err != nil {
+		return errors.Join(err, file.Close())
+	}
+
+	if err := file.Chmod(0o644); err != nil {
+		return errors.Join(err, file.Close())
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), fileName)
+}
@@ -0,0 +1,51 @@
+package fsutil_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/AntonKosov/git-backups/internal/fsutil"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WriteAtomically", func() {
+	var (
+		folder   string
+		fileName string
+	)
+
+	BeforeEach(func() {
+		folder = GinkgoT().TempDir()
+		fileName = filepath.Join(folder, "file.txt")
+		Expect(os.WriteFile(fileName, []byte("old"), 0o600)).To(Succeed())
+	})
+
+	It("replaces the file", func() {
+		err := fsutil.WriteAtomically(fileName, func(w io.Writer) error {
+			_, err := io.WriteString(w, "new")
+			return err
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.ReadFile(fileName)).To(Equal([]byte("new")))
+		info, err := os.Stat(fileName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0o644)))
+	})
+
+	When("writing fails", func() {
+		It("keeps the file and removes the temporary one", func() {
+			err := fsutil.WriteAtomically(fileName, func(w io.Writer) error {
+				_, _ = io.WriteString(w, "partial")
+				return errors.New("something went wrong")
+			})
+
+			Expect(err).To(MatchError("something went wrong"))
+			Expect(os.ReadFile(fileName)).To(Equal([]byte("old")))
+			Expect(os.ReadDir(folder)).To(HaveLen(1))
+		})
+	})
+})
@@ -4,18 +4,21 @@ package backupfakes
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/AntonKosov/git-backups/internal/git"
 	"github.com/AntonKosov/git-backups/internal/git/backup"
 )
 
 type FakeGit struct {
-	CloneStub        func(context.Context, string, string, *string) error
+	CloneStub        func(context.Context, string, string, *string, git.FetchOptions) error
 	cloneMutex       sync.RWMutex
 	cloneArgsForCall []struct {
 		arg1 context.Context
 		arg2 string
 		arg3 string
 		arg4 *string
+		arg5 git.FetchOptions
 	}
 	cloneReturns struct {
 		result1 error
@@ -23,12 +26,13 @@ type FakeGit struct {
 	cloneReturnsOnCall map[int]struct {
 		result1 error
 	}
-	FetchStub        func(context.Context, string, *string) error
+	FetchStub        func(context.Context, string, *string, git.FetchOptions) error
 	fetchMutex       sync.RWMutex
 	fetchArgsForCall []struct {
 		arg1 context.Context
 		arg2 string
 		arg3 *string
+		arg4 git.FetchOptions
 	}
 	fetchReturns struct {
 		result1 error
@@ -36,11 +40,81 @@ type FakeGit struct {
 	fetchReturnsOnCall map[int]struct {
 		result1 error
 	}
+	MaintainStub        func(context.Context, string, []string, time.Duration, []string) error
+	maintainMutex       sync.RWMutex
+	maintainArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+		arg4 time.Duration
+		arg5 []string
+	}
+	maintainReturns struct {
+		result1 error
+	}
+	maintainReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ObjectsSizeStub        func(context.Context, string) (int64, error)
+	objectsSizeMutex       sync.RWMutex
+	objectsSizeArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	objectsSizeReturns struct {
+		result1 int64
+		result2 error
+	}
+	objectsSizeReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	RefCountStub        func(context.Context, string) (int, error)
+	refCountMutex       sync.RWMutex
+	refCountArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	refCountReturns struct {
+		result1 int
+		result2 error
+	}
+	refCountReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	RefsStub        func(context.Context, string) (map[string]string, error)
+	refsMutex       sync.RWMutex
+	refsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	refsReturns struct {
+		result1 map[string]string
+		result2 error
+	}
+	refsReturnsOnCall map[int]struct {
+		result1 map[string]string
+		result2 error
+	}
+	VerifyStub        func(context.Context, string, bool) error
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}
+	verifyReturns struct {
+		result1 error
+	}
+	verifyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeGit) Clone(arg1 context.Context, arg2 string, arg3 string, arg4 *string) error {
+func (fake *FakeGit) Clone(arg1 context.Context, arg2 string, arg3 string, arg4 *string, arg5 git.FetchOptions) error {
 	fake.cloneMutex.Lock()
 	ret, specificReturn := fake.cloneReturnsOnCall[len(fake.cloneArgsForCall)]
 	fake.cloneArgsForCall = append(fake.cloneArgsForCall, struct {
@@ -48,13 +122,14 @@ func (fake *FakeGit) Clone(arg1 context.Context, arg2 string, arg3 string, arg4
 		arg2 string
 		arg3 string
 		arg4 *string
-	}{arg1, arg2, arg3, arg4})
+		arg5 git.FetchOptions
+	}{arg1, arg2, arg3, arg4, arg5})
 	stub := fake.CloneStub
 	fakeReturns := fake.cloneReturns
-	fake.recordInvocation("Clone", []interface{}{arg1, arg2, arg3, arg4})
+	fake.recordInvocation("Clone", []interface{}{arg1, arg2, arg3, arg4, arg5})
 	fake.cloneMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3, arg4)
+		return stub(arg1, arg2, arg3, arg4, arg5)
 	}
 	if specificReturn {
 		return ret.result1
@@ -68,17 +143,17 @@ func (fake *FakeGit) CloneCallCount() int {
 	return len(fake.cloneArgsForCall)
 }
 
-func (fake *FakeGit) CloneCalls(stub func(context.Context, string, string, *string) error) {
+func (fake *FakeGit) CloneCalls(stub func(context.Context, string, string, *string, git.FetchOptions) error) {
 	fake.cloneMutex.Lock()
 	defer fake.cloneMutex.Unlock()
 	fake.CloneStub = stub
 }
 
-func (fake *FakeGit) CloneArgsForCall(i int) (context.Context, string, string, *string) {
+func (fake *FakeGit) CloneArgsForCall(i int) (context.Context, string, string, *string, git.FetchOptions) {
 	fake.cloneMutex.RLock()
 	defer fake.cloneMutex.RUnlock()
 	argsForCall := fake.cloneArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
 func (fake *FakeGit) CloneReturns(result1 error) {
@@ -104,20 +179,21 @@ func (fake *FakeGit) CloneReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeGit) Fetch(arg1 context.Context, arg2 string, arg3 *string) error {
+func (fake *FakeGit) Fetch(arg1 context.Context, arg2 string, arg3 *string, arg4 git.FetchOptions) error {
 	fake.fetchMutex.Lock()
 	ret, specificReturn := fake.fetchReturnsOnCall[len(fake.fetchArgsForCall)]
 	fake.fetchArgsForCall = append(fake.fetchArgsForCall, struct {
 		arg1 context.Context
 		arg2 string
 		arg3 *string
-	}{arg1, arg2, arg3})
+		arg4 git.FetchOptions
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.FetchStub
 	fakeReturns := fake.fetchReturns
-	fake.recordInvocation("Fetch", []interface{}{arg1, arg2, arg3})
+	fake.recordInvocation("Fetch", []interface{}{arg1, arg2, arg3, arg4})
 	fake.fetchMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2, arg3)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -131,17 +207,17 @@ func (fake *FakeGit) FetchCallCount() int {
 	return len(fake.fetchArgsForCall)
 }
 
-func (fake *FakeGit) FetchCalls(stub func(context.Context, string, *string) error) {
+func (fake *FakeGit) FetchCalls(stub func(context.Context, string, *string, git.FetchOptions) error) {
 	fake.fetchMutex.Lock()
 	defer fake.fetchMutex.Unlock()
 	fake.FetchStub = stub
 }
 
-func (fake *FakeGit) FetchArgsForCall(i int) (context.Context, string, *string) {
+func (fake *FakeGit) FetchArgsForCall(i int) (context.Context, string, *string, git.FetchOptions) {
 	fake.fetchMutex.RLock()
 	defer fake.fetchMutex.RUnlock()
 	argsForCall := fake.fetchArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeGit) FetchReturns(result1 error) {
@@ -167,6 +243,339 @@ func (fake *FakeGit) FetchReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeGit) Maintain(arg1 context.Context, arg2 string, arg3 []string, arg4 time.Duration, arg5 []string) error {
+	var arg3Copy []string
+	if arg3 != nil {
+		arg3Copy = make([]string, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	var arg5Copy []string
+	if arg5 != nil {
+		arg5Copy = make([]string, len(arg5))
+		copy(arg5Copy, arg5)
+	}
+	fake.maintainMutex.Lock()
+	ret, specificReturn := fake.maintainReturnsOnCall[len(fake.maintainArgsForCall)]
+	fake.maintainArgsForCall = append(fake.maintainArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 []string
+		arg4 time.Duration
+		arg5 []string
+	}{arg1, arg2, arg3Copy, arg4, arg5Copy})
+	stub := fake.MaintainStub
+	fakeReturns := fake.maintainReturns
+	fake.recordInvocation("Maintain", []interface{}{arg1, arg2, arg3Copy, arg4, arg5Copy})
+	fake.maintainMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) MaintainCallCount() int {
+	fake.maintainMutex.RLock()
+	defer fake.maintainMutex.RUnlock()
+	return len(fake.maintainArgsForCall)
+}
+
+func (fake *FakeGit) MaintainCalls(stub func(context.Context, string, []string, time.Duration, []string) error) {
+	fake.maintainMutex.Lock()
+	defer fake.maintainMutex.Unlock()
+	fake.MaintainStub = stub
+}
+
+func (fake *FakeGit) MaintainArgsForCall(i int) (context.Context, string, []string, time.Duration, []string) {
+	fake.maintainMutex.RLock()
+	defer fake.maintainMutex.RUnlock()
+	argsForCall := fake.maintainArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeGit) MaintainReturns(result1 error) {
+	fake.maintainMutex.Lock()
+	defer fake.maintainMutex.Unlock()
+	fake.MaintainStub = nil
+	fake.maintainReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) MaintainReturnsOnCall(i int, result1 error) {
+	fake.maintainMutex.Lock()
+	defer fake.maintainMutex.Unlock()
+	fake.MaintainStub = nil
+	if fake.maintainReturnsOnCall == nil {
+		fake.maintainReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.maintainReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) ObjectsSize(arg1 context.Context, arg2 string) (int64, error) {
+	fake.objectsSizeMutex.Lock()
+	ret, specificReturn := fake.objectsSizeReturnsOnCall[len(fake.objectsSizeArgsForCall)]
+	fake.objectsSizeArgsForCall = append(fake.objectsSizeArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ObjectsSizeStub
+	fakeReturns := fake.objectsSizeReturns
+	fake.recordInvocation("ObjectsSize", []interface{}{arg1, arg2})
+	fake.objectsSizeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGit) ObjectsSizeCallCount() int {
+	fake.objectsSizeMutex.RLock()
+	defer fake.objectsSizeMutex.RUnlock()
+	return len(fake.objectsSizeArgsForCall)
+}
+
+func (fake *FakeGit) ObjectsSizeCalls(stub func(context.Context, string) (int64, error)) {
+	fake.objectsSizeMutex.Lock()
+	defer fake.objectsSizeMutex.Unlock()
+	fake.ObjectsSizeStub = stub
+}
+
+func (fake *FakeGit) ObjectsSizeArgsForCall(i int) (context.Context, string) {
+	fake.objectsSizeMutex.RLock()
+	defer fake.objectsSizeMutex.RUnlock()
+	argsForCall := fake.objectsSizeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) ObjectsSizeReturns(result1 int64, result2 error) {
+	fake.objectsSizeMutex.Lock()
+	defer fake.objectsSizeMutex.Unlock()
+	fake.ObjectsSizeStub = nil
+	fake.objectsSizeReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) ObjectsSizeReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.objectsSizeMutex.Lock()
+	defer fake.objectsSizeMutex.Unlock()
+	fake.ObjectsSizeStub = nil
+	if fake.objectsSizeReturnsOnCall == nil {
+		fake.objectsSizeReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.objectsSizeReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) RefCount(arg1 context.Context, arg2 string) (int, error) {
+	fake.refCountMutex.Lock()
+	ret, specificReturn := fake.refCountReturnsOnCall[len(fake.refCountArgsForCall)]
+	fake.refCountArgsForCall = append(fake.refCountArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RefCountStub
+	fakeReturns := fake.refCountReturns
+	fake.recordInvocation("RefCount", []interface{}{arg1, arg2})
+	fake.refCountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGit) RefCountCallCount() int {
+	fake.refCountMutex.RLock()
+	defer fake.refCountMutex.RUnlock()
+	return len(fake.refCountArgsForCall)
+}
+
+func (fake *FakeGit) RefCountCalls(stub func(context.Context, string) (int, error)) {
+	fake.refCountMutex.Lock()
+	defer fake.refCountMutex.Unlock()
+	fake.RefCountStub = stub
+}
+
+func (fake *FakeGit) RefCountArgsForCall(i int) (context.Context, string) {
+	fake.refCountMutex.RLock()
+	defer fake.refCountMutex.RUnlock()
+	argsForCall := fake.refCountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) RefCountReturns(result1 int, result2 error) {
+	fake.refCountMutex.Lock()
+	defer fake.refCountMutex.Unlock()
+	fake.RefCountStub = nil
+	fake.refCountReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) RefCountReturnsOnCall(i int, result1 int, result2 error) {
+	fake.refCountMutex.Lock()
+	defer fake.refCountMutex.Unlock()
+	fake.RefCountStub = nil
+	if fake.refCountReturnsOnCall == nil {
+		fake.refCountReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.refCountReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) Refs(arg1 context.Context, arg2 string) (map[string]string, error) {
+	fake.refsMutex.Lock()
+	ret, specificReturn := fake.refsReturnsOnCall[len(fake.refsArgsForCall)]
+	fake.refsArgsForCall = append(fake.refsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RefsStub
+	fakeReturns := fake.refsReturns
+	fake.recordInvocation("Refs", []interface{}{arg1, arg2})
+	fake.refsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeGit) RefsCallCount() int {
+	fake.refsMutex.RLock()
+	defer fake.refsMutex.RUnlock()
+	return len(fake.refsArgsForCall)
+}
+
+func (fake *FakeGit) RefsCalls(stub func(context.Context, string) (map[string]string, error)) {
+	fake.refsMutex.Lock()
+	defer fake.refsMutex.Unlock()
+	fake.RefsStub = stub
+}
+
+func (fake *FakeGit) RefsArgsForCall(i int) (context.Context, string) {
+	fake.refsMutex.RLock()
+	defer fake.refsMutex.RUnlock()
+	argsForCall := fake.refsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) RefsReturns(result1 map[string]string, result2 error) {
+	fake.refsMutex.Lock()
+	defer fake.refsMutex.Unlock()
+	fake.RefsStub = nil
+	fake.refsReturns = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) RefsReturnsOnCall(i int, result1 map[string]string, result2 error) {
+	fake.refsMutex.Lock()
+	defer fake.refsMutex.Unlock()
+	fake.RefsStub = nil
+	if fake.refsReturnsOnCall == nil {
+		fake.refsReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+			result2 error
+		})
+	}
+	fake.refsReturnsOnCall[i] = struct {
+		result1 map[string]string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeGit) Verify(arg1 context.Context, arg2 string, arg3 bool) error {
+	fake.verifyMutex.Lock()
+	ret, specificReturn := fake.verifyReturnsOnCall[len(fake.verifyArgsForCall)]
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 bool
+	}{arg1, arg2, arg3})
+	stub := fake.VerifyStub
+	fakeReturns := fake.verifyReturns
+	fake.recordInvocation("Verify", []interface{}{arg1, arg2, arg3})
+	fake.verifyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *FakeGit) VerifyCalls(stub func(context.Context, string, bool) error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = stub
+}
+
+func (fake *FakeGit) VerifyArgsForCall(i int) (context.Context, string, bool) {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	argsForCall := fake.verifyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGit) VerifyReturns(result1 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) VerifyReturnsOnCall(i int, result1 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	if fake.verifyReturnsOnCall == nil {
+		fake.verifyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.verifyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeGit) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -174,6 +583,16 @@ func (fake *FakeGit) Invocations() map[string][][]interface{} {
 	defer fake.cloneMutex.RUnlock()
 	fake.fetchMutex.RLock()
 	defer fake.fetchMutex.RUnlock()
+	fake.maintainMutex.RLock()
+	defer fake.maintainMutex.RUnlock()
+	fake.objectsSizeMutex.RLock()
+	defer fake.objectsSizeMutex.RUnlock()
+	fake.refCountMutex.RLock()
+	defer fake.refCountMutex.RUnlock()
+	fake.refsMutex.RLock()
+	defer fake.refsMutex.RUnlock()
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,194 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package backupfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+)
+
+type FakeStorage struct {
+	PullStub        func(context.Context, string, string) error
+	pullMutex       sync.RWMutex
+	pullArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	pullReturns struct {
+		result1 error
+	}
+	pullReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PushStub        func(context.Context, string, string) error
+	pushMutex       sync.RWMutex
+	pushArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	pushReturns struct {
+		result1 error
+	}
+	pushReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeStorage) Pull(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.pullMutex.Lock()
+	ret, specificReturn := fake.pullReturnsOnCall[len(fake.pullArgsForCall)]
+	fake.pullArgsForCall = append(fake.pullArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.PullStub
+	fakeReturns := fake.pullReturns
+	fake.recordInvocation("Pull", []interface{}{arg1, arg2, arg3})
+	fake.pullMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorage) PullCallCount() int {
+	fake.pullMutex.RLock()
+	defer fake.pullMutex.RUnlock()
+	return len(fake.pullArgsForCall)
+}
+
+func (fake *FakeStorage) PullCalls(stub func(context.Context, string, string) error) {
+	fake.pullMutex.Lock()
+	defer fake.pullMutex.Unlock()
+	fake.PullStub = stub
+}
+
+func (fake *FakeStorage) PullArgsForCall(i int) (context.Context, string, string) {
+	fake.pullMutex.RLock()
+	defer fake.pullMutex.RUnlock()
+	argsForCall := fake.pullArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStorage) PullReturns(result1 error) {
+	fake.pullMutex.Lock()
+	defer fake.pullMutex.Unlock()
+	fake.PullStub = nil
+	fake.pullReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorage) PullReturnsOnCall(i int, result1 error) {
+	fake.pullMutex.Lock()
+	defer fake.pullMutex.Unlock()
+	fake.PullStub = nil
+	if fake.pullReturnsOnCall == nil {
+		fake.pullReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pullReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorage) Push(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.pushMutex.Lock()
+	ret, specificReturn := fake.pushReturnsOnCall[len(fake.pushArgsForCall)]
+	fake.pushArgsForCall = append(fake.pushArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.PushStub
+	fakeReturns := fake.pushReturns
+	fake.recordInvocation("Push", []interface{}{arg1, arg2, arg3})
+	fake.pushMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorage) PushCallCount() int {
+	fake.pushMutex.RLock()
+	defer fake.pushMutex.RUnlock()
+	return len(fake.pushArgsForCall)
+}
+
+func (fake *FakeStorage) PushCalls(stub func(context.Context, string, string) error) {
+	fake.pushMutex.Lock()
+	defer fake.pushMutex.Unlock()
+	fake.PushStub = stub
+}
+
+func (fake *FakeStorage) PushArgsForCall(i int) (context.Context, string, string) {
+	fake.pushMutex.RLock()
+	defer fake.pushMutex.RUnlock()
+	argsForCall := fake.pushArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStorage) PushReturns(result1 error) {
+	fake.pushMutex.Lock()
+	defer fake.pushMutex.Unlock()
+	fake.PushStub = nil
+	fake.pushReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorage) PushReturnsOnCall(i int, result1 error) {
+	fake.pushMutex.Lock()
+	defer fake.pushMutex.Unlock()
+	fake.PushStub = nil
+	if fake.pushReturnsOnCall == nil {
+		fake.pushReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pushReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorage) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.pullMutex.RLock()
+	defer fake.pullMutex.RUnlock()
+	fake.pushMutex.RLock()
+	defer fake.pushMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeStorage) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ backup.Storage = new(FakeStorage)
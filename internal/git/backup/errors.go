@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"github.com/AntonKosov/git-backups/internal/lock"
+)
+
+type ErrorClass string
+
+const (
+	ErrorClassNone     ErrorClass = ""
+	ErrorClassCanceled ErrorClass = "canceled"
+	ErrorClassAuth     ErrorClass = "auth"
+	ErrorClassNotFound ErrorClass = "not_found"
+	ErrorClassNetwork  ErrorClass = "network"
+	ErrorClassDisk     ErrorClass = "disk"
+	ErrorClassCorrupt  ErrorClass = "corrupt"
+	ErrorClassLocked   ErrorClass = "locked"
+	ErrorClassOther    ErrorClass = "other"
+)
+
+// ErrCorrupt marks repositories which failed verification.
+var ErrCorrupt = errors.New("the repository is corrupted or incomplete")
+
+// ErrInsufficientSpace marks repositories which weren't backed up to keep free space on the disk.
+var ErrInsufficientSpace = errors.New("not enough free space")
+
+// errorClassMarkers match the messages of git, ssh and the hosting services. They are specific, since
+// generic phrases like "not found" also appear in unrelated failures, e.g. a missing git executable.
+var errorClassMarkers = []struct {
+	class   ErrorClass
+	markers *regexp.Regexp
+}{
+	{ErrorClassAuth, regexp.MustCompile(`(?i)permission denied \(publickey|authentication failed|could not read username|host key verification failed|access denied`)},
+	{ErrorClassNotFound, regexp.MustCompile(`(?i)repository not found|repository '[^']*' not found|does not appear to be a git repository|project you were looking for could not be found`)},
+	{ErrorClassNetwork, regexp.MustCompile(`(?i)could not resolve host|connection timed out|connection refused|network is unreachable|operation timed out|the remote end hung up`)},
+	{ErrorClassDisk, regexp.MustCompile(`(?i)no space left on device|disk quota exceeded|read-only file system`)},
+}
+
+// ClassifyError groups errors by their cause, mostly based on the git output.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassCanceled
+	}
+
+	if errors.Is(err, ErrCorrupt) {
+		return ErrorClassCorrupt
+	}
+
+	if errors.Is(err, lock.ErrLocked) {
+		return ErrorClassLocked
+	}
+
+	if errors.Is(err, ErrInsufficientSpace) {
+		return ErrorClassDisk
+	}
+
+	message := err.Error()
+	for _, class := range errorClassMarkers {
+		if class.markers.MatchString(message) {
+			return class.class
+		}
+	}
+
+	return ErrorClassOther
+}
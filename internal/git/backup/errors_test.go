@@ -0,0 +1,32 @@
+package backup_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/lock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("ClassifyError", func(err error, expectedClass backup.ErrorClass) {
+	Expect(backup.ClassifyError(err)).To(Equal(expectedClass))
+},
+	Entry("no error", nil, backup.ErrorClassNone),
+	Entry("canceled", fmt.Errorf("clone: %w", context.Canceled), backup.ErrorClassCanceled),
+	Entry("auth", errors.New("git@github.com: Permission denied (publickey)."), backup.ErrorClassAuth),
+	Entry("not found", errors.New("remote: Repository not found."), backup.ErrorClassNotFound),
+	Entry("not found over HTTPS", errors.New("fatal: repository 'https://example.com/repo.git/' not found"), backup.ErrorClassNotFound),
+	Entry("not a repository", errors.New("fatal: '/srv/repo.git' does not appear to be a git repository"), backup.ErrorClassNotFound),
+	Entry("missing git", errors.New(`exec: "git": executable file not found in $PATH`), backup.ErrorClassOther),
+	Entry("missing branch", errors.New("fatal: Remote branch main not found in upstream origin"), backup.ErrorClassOther),
+	Entry("missing path", errors.New("fatal: path 'README.md' does not exist in 'HEAD'"), backup.ErrorClassOther),
+	Entry("network", errors.New("ssh: Could not resolve hostname github.com"), backup.ErrorClassNetwork),
+	Entry("disk", errors.New("fatal: write error: No space left on device"), backup.ErrorClassDisk),
+	Entry("insufficient space", fmt.Errorf("%w in /backups", backup.ErrInsufficientSpace), backup.ErrorClassDisk),
+	Entry("locked", &lock.LockedError{FileName: "/backups/repo.lock"}, backup.ErrorClassLocked),
+	Entry("corrupt", fmt.Errorf("%w: error: object file is empty", backup.ErrCorrupt), backup.ErrorClassCorrupt),
+	Entry("other", errors.New("something went wrong"), backup.ErrorClassOther),
+)
@@ -0,0 +1,101 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+)
+
+// maintain records a ref snapshot if the refs changed and runs the maintenance tasks if they are due.
+// Objects referenced by the kept snapshots are never pruned, so that the repository can be restored to
+// any of them.
+func (s Service) maintain(ctx context.Context, path string, maintenance config.Maintenance) error {
+	snapshots, err := s.snapshot(ctx, path, maintenance.KeepSnapshots)
+	if err != nil {
+		return fmt.Errorf("failed to record a ref snapshot: %w", err)
+	}
+
+	metadata, err := ReadMetadata(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the metadata: %w", err)
+	}
+
+	if time.Since(metadata.LastMaintenance) < maintenance.Interval {
+		slog.DebugContext(ctx, "Maintenance is not due", "last_maintenance", metadata.LastMaintenance)
+		return nil
+	}
+
+	keep, err := snapshotObjects(snapshots)
+	if err != nil {
+		return fmt.Errorf("failed to read ref snapshots: %w", err)
+	}
+
+	started := time.Now()
+	if err := s.git.Maintain(ctx, path, maintenance.Tasks, maintenance.PruneAfter, keep); err != nil {
+		return err
+	}
+
+	metadata.LastMaintenance = started.UTC()
+	if err := metadata.WriteFile(path); err != nil {
+		return fmt.Errorf("failed to write the metadata: %w", err)
+	}
+
+	return nil
+}
+
+// snapshot records the refs of the repository if they differ from the last snapshot, removes snapshots
+// beyond the number to keep and returns the remaining ones. Existing snapshots are all removed if none
+// are kept, since their objects are no longer protected from pruning.
+func (s Service) snapshot(ctx context.Context, path string, keep int) ([]string, error) {
+	snapshots, err := Snapshots(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if keep > 0 {
+		if snapshots, err = s.recordSnapshot(ctx, path, snapshots); err != nil {
+			return nil, err
+		}
+	}
+
+	for len(snapshots) > keep {
+		if err := os.Remove(snapshots[0]); err != nil {
+			return nil, err
+		}
+		snapshots = snapshots[1:]
+	}
+
+	return snapshots, nil
+}
+
+// recordSnapshot writes a snapshot of the refs if they differ from the last one and returns the snapshots.
+func (s Service) recordSnapshot(ctx context.Context, path string, snapshots []string) ([]string, error) {
+	refs, err := s.git.Refs(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := len(refs) > 0
+	if len(snapshots) > 0 && changed {
+		last, err := readSnapshot(snapshots[len(snapshots)-1])
+		if err != nil {
+			return nil, err
+		}
+		changed = !maps.Equal(last, refs)
+	}
+
+	if !changed {
+		return snapshots, nil
+	}
+
+	if err := writeSnapshot(path, time.Now(), refs); err != nil {
+		return nil, err
+	}
+
+	return Snapshots(path)
+}
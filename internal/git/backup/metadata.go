@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/fsutil"
+)
+
+const (
+	// MetadataFolder is the folder in every repository which keeps the files of git-backups. Git ignores
+	// unknown folders in repositories.
+	MetadataFolder = "git-backups"
+	metadataName   = "metadata.json"
+	// SnapshotsFolder keeps the refs of the repository after backups which changed them, one file per
+	// backup in the format of "git show-ref".
+	SnapshotsFolder = "snapshots"
+	snapshotExt     = ".refs"
+)
+
+// Metadata describes the backup of a repository.
+type Metadata struct {
+	// LastBackup is the start of the last successful backup.
+	LastBackup      time.Time `json:"last_backup,omitzero"`
+	LastMaintenance time.Time `json:"last_maintenance,omitzero"`
+	// Partial is set once the repository was backed up partially.
+	Partial *Partial `json:"partial,omitempty"`
+}
+
+// Partial describes what a partial mirror leaves out.
+type Partial struct {
+	BlobLimit    int64     `json:"blob_limit,omitempty"`
+	Refs         []string  `json:"refs,omitempty"`
+	ExcludeRefs  []string  `json:"exclude_refs,omitempty"`
+	ShallowSince time.Time `json:"shallow_since,omitzero"`
+}
+
+// ReadMetadata returns the metadata of the repository or an empty one if it doesn't exist yet.
+func ReadMetadata(repo string) (Metadata, error) {
+	var metadata Metadata
+	data, err := os.ReadFile(filepath.Join(repo, MetadataFolder, metadataName))
+	if errors.Is(err, os.ErrNotExist) {
+		return metadata, nil
+	}
+	if err != nil {
+		return metadata, err
+	}
+
+	return metadata, json.Unmarshal(data, &metadata)
+}
+
+// WriteFile replaces the metadata of the repository atomically.
+func (m Metadata) WriteFile(repo string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFile(filepath.Join(repo, MetadataFolder, metadataName), append(data, '\n'))
+}
+
+// Snapshots returns the ref snapshot files of the repository from the oldest to the newest.
+func Snapshots(repo string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(repo, MetadataFolder, SnapshotsFolder, "*"+snapshotExt))
+	slices.Sort(files)
+
+	return files, err
+}
+
+// snapshotObjects returns the object names which the refs of the snapshot files point to.
+func snapshotObjects(files []string) ([]string, error) {
+	objects := map[string]bool{}
+	for _, fileName := range files {
+		refs, err := readSnapshot(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, objectName := range refs {
+			objects[objectName] = true
+		}
+	}
+
+	return slices.Sorted(maps.Keys(objects)), nil
+}
+
+func readSnapshot(fileName string) (map[string]string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := map[string]string{}
+	for line := range strings.Lines(string(data)) {
+		if objectName, refName, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			refs[refName] = objectName
+		}
+	}
+
+	return refs, nil
+}
+
+func writeSnapshot(repo string, created time.Time, refs map[string]string) error {
+	var sb strings.Builder
+	for _, refName := range slices.Sorted(maps.Keys(refs)) {
+		fmt.Fprintf(&sb, "%v %v\n", refs[refName], refName)
+	}
+
+	fileName := created.UTC().Format("20060102T150405Z") + snapshotExt
+
+	return writeFile(filepath.Join(repo, MetadataFolder, SnapshotsFolder, fileName), []byte(sb.String()))
+}
+
+// writeFile replaces the file atomically and creates its folder if it's missing.
+func writeFile(fileName string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+		return err
+	}
+
+	return fsutil.WriteAtomically(fileName, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,47 @@
+package backup
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git"
+)
+
+func fetchOptions(settings Settings) git.FetchOptions {
+	partial := settings.Partial
+	options := git.FetchOptions{
+		Refs:         partial.Refs,
+		ExcludeRefs:  partial.ExcludeRefs,
+		ShallowSince: partial.ShallowSince,
+		Timeout:      settings.Timeout,
+		LFS:          settings.LFS,
+	}
+	if partial.BlobLimit > 0 {
+		options.Filter = fmt.Sprintf("blob:limit=%d", partial.BlobLimit)
+	}
+
+	return options
+}
+
+// recordBackup keeps the time of the backup and the partial settings in the metadata, so that the mirror
+// is known to be partial later. A mirror stays partial when the settings are removed since the missing
+// objects aren't fetched.
+func recordBackup(path string, started time.Time, partial config.Partial) error {
+	metadata, err := ReadMetadata(path)
+	if err != nil {
+		return err
+	}
+
+	metadata.LastBackup = started.UTC()
+	if !partial.IsZero() {
+		metadata.Partial = &Partial{
+			BlobLimit:    partial.BlobLimit,
+			Refs:         partial.Refs,
+			ExcludeRefs:  partial.ExcludeRefs,
+			ShallowSince: partial.ShallowSince,
+		}
+	}
+
+	return metadata.WriteFile(path)
+}
@@ -0,0 +1,44 @@
+package backup
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FindRepos returns bare repositories in the root folder which don't belong to any known target.
+// Known targets and found repositories are not descended into.
+func FindRepos(rootFolder string, known map[string]bool) []string {
+	var repos []string
+	_ = filepath.WalkDir(rootFolder, func(folder string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() || folder == rootFolder {
+			return nil
+		}
+
+		if known[path.Clean(filepath.ToSlash(folder))] {
+			return fs.SkipDir
+		}
+
+		if IsRepo(folder) {
+			repos = append(repos, folder)
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+
+	return repos
+}
+
+// IsRepo reports whether the folder is a bare repository.
+func IsRepo(folder string) bool {
+	head, err := os.Stat(filepath.Join(folder, "HEAD"))
+	if err != nil || head.IsDir() {
+		return false
+	}
+
+	objects, err := os.Stat(filepath.Join(folder, "objects"))
+
+	return err == nil && objects.IsDir()
+}
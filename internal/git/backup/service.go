@@ -3,39 +3,271 @@ package backup
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git"
 )
 
 //counterfeiter:generate . Git
 type Git interface {
-	Clone(ctx context.Context, url, path string, privateSSHKey *string) error
-	Fetch(ctx context.Context, path string, privateSSHKey *string) error
+	Clone(ctx context.Context, url, path string, privateSSHKey *string, options git.FetchOptions) error
+	Fetch(ctx context.Context, path string, privateSSHKey *string, options git.FetchOptions) error
+	RefCount(ctx context.Context, path string) (int, error)
+	ObjectsSize(ctx context.Context, path string) (int64, error)
+	Refs(ctx context.Context, path string) (map[string]string, error)
+	Verify(ctx context.Context, path string, partial bool) error
+	Maintain(ctx context.Context, path string, tasks []string, pruneAfter time.Duration, keep []string) error
+}
+
+// Storage keeps repositories outside of the local root folder, which is then a staging area that
+// repositories are cloned and fetched in.
+//
+//counterfeiter:generate . Storage
+type Storage interface {
+	// Pull copies the repository into the missing local folder. It returns fs.ErrNotExist if the storage
+	// doesn't have the repository.
+	Pull(ctx context.Context, relativePath, localFolder string) error
+	// Push copies the changes of the local folder to the storage.
+	Push(ctx context.Context, relativePath, localFolder string) error
+}
+
+type Action string
+
+const (
+	ActionClone Action = "clone"
+	ActionFetch Action = "fetch"
+)
+
+type Result struct {
+	URL      string
+	Path     string
+	Action   Action
+	Started  time.Time
+	Duration time.Duration
+	// Size is the size of the repository folder in bytes after the backup.
+	Size int64
+	// RefsBefore and RefsAfter are the numbers of refs in the repository.
+	RefsBefore int
+	RefsAfter  int
+	// ObjectsBefore and ObjectsAfter are the sizes of the objects of the repository in bytes.
+	ObjectsBefore int64
+	ObjectsAfter  int64
+}
+
+// Fetched returns the number of bytes of objects added to the repository by the backup.
+func (r Result) Fetched() int64 {
+	return max(r.ObjectsAfter-r.ObjectsBefore, 0)
+}
+
+// Settings are the settings of the repository which is backed up.
+type Settings struct {
+	PrivateSSHKey *string
+	// Maintenance is disabled without an interval.
+	Maintenance config.Maintenance
+	Partial     config.Partial
+	// Timeout limits every git command which talks to the remote. Zero is unlimited.
+	Timeout time.Duration
+	// LFS fetches the Git LFS objects too.
+	LFS bool
 }
 
 type Service struct {
-	git Git
+	git      Git
+	storages []rootStorage
+}
+
+type rootStorage struct {
+	rootFolder string
+	storage    Storage
 }
 
 func NewService(git Git) Service {
 	return Service{git: git}
 }
 
-func (s Service) Run(ctx context.Context, url, targetFolder string, privateSSHKey *string) error {
-	ctx = clog.Add(ctx, "target folder", targetFolder)
-	exists, err := folderExists(targetFolder)
+// WithStorage returns a copy of the service which keeps the repositories of the root folder in the storage.
+func (s Service) WithStorage(rootFolder string, storage Storage) Service {
+	s.storages = append(slices.Clip(s.storages), rootStorage{rootFolder: filepath.Clean(rootFolder), storage: storage})
+
+	return s
+}
+
+// Run clones or fetches the repository. The result describes the backup even if it fails.
+func (s Service) Run(ctx context.Context, url, targetFolder string, settings Settings) (Result, error) {
+	ctx = clog.Add(ctx, "target_folder", targetFolder)
+	result := Result{URL: url, Path: targetFolder, Started: time.Now()}
+	err := s.run(ctx, &result, settings)
+	result.Duration = time.Since(result.Started)
+
+	return result, err
+}
+
+func (s Service) run(ctx context.Context, result *Result, settings Settings) error {
+	storage, relativePath := s.storage(result.Path)
+	if storage != nil {
+		if err := pull(ctx, storage, relativePath, result.Path); err != nil {
+			slog.ErrorContext(ctx, "Failed to pull from the remote storage", "error", err)
+			return fmt.Errorf("failed to pull from the remote storage: %w", err)
+		}
+	}
+
+	action, err := s.Action(result.Path)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to check folder", "error", err)
 		return err
 	}
+	result.Action = action
+
+	options := fetchOptions(settings)
+	if action == ActionFetch {
+		result.RefsBefore = s.refCount(ctx, result.Path)
+		result.ObjectsBefore = s.objectsSize(ctx, result.Path)
+		err = s.git.Fetch(ctx, result.Path, settings.PrivateSSHKey, options)
+	} else {
+		err = s.git.Clone(ctx, result.URL, result.Path, settings.PrivateSSHKey, options)
+	}
+	result.Size = FolderSize(result.Path)
+	if err != nil {
+		result.RefsAfter = result.RefsBefore
+		result.ObjectsAfter = result.ObjectsBefore
+		return err
+	}
+	result.RefsAfter = s.refCount(ctx, result.Path)
+	result.ObjectsAfter = s.objectsSize(ctx, result.Path)
+
+	if err := recordBackup(result.Path, result.Started, settings.Partial); err != nil {
+		slog.ErrorContext(ctx, "Failed to record the backup", "error", err)
+		return fmt.Errorf("failed to record the backup: %w", err)
+	}
+
+	if settings.Maintenance.Interval > 0 {
+		if err := s.maintain(ctx, result.Path, settings.Maintenance); err != nil {
+			slog.ErrorContext(ctx, "Failed to maintain", "error", err)
+			return fmt.Errorf("failed to maintain: %w", err)
+		}
+	}
+
+	if storage != nil {
+		if err := storage.Push(ctx, relativePath, result.Path); err != nil {
+			slog.ErrorContext(ctx, "Failed to push to the remote storage", "error", err)
+			return fmt.Errorf("failed to push to the remote storage: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// storage returns the storage of the root folder which contains the target folder and the path within it.
+func (s Service) storage(targetFolder string) (Storage, string) {
+	for _, rs := range s.storages {
+		if relative, ok := within(rs.rootFolder, targetFolder); ok {
+			return rs.storage, relative
+		}
+	}
+
+	return nil, ""
+}
+
+// within returns the slash-separated path of the target folder within the root folder.
+func within(rootFolder, targetFolder string) (string, bool) {
+	relative, err := filepath.Rel(rootFolder, filepath.Clean(targetFolder))
+	if err != nil || relative == "." || relative == ".." || strings.HasPrefix(relative, "../") {
+		return "", false
+	}
+
+	return filepath.ToSlash(relative), true
+}
+
+// pull restores the staging copy of the repository from the storage if it's missing, e.g. on a new host.
+// The staging copy is considered up to date otherwise.
+func pull(ctx context.Context, storage Storage, relativePath, folder string) error {
+	exists, err := folderExists(folder)
+	if err != nil || exists {
+		return err
+	}
+
+	err = storage.Pull(ctx, relativePath, folder)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.DebugContext(ctx, "The remote storage doesn't have the repository")
+		return nil
+	}
+
+	return err
+}
+
+// refCount returns 0 if the refs can't be counted since the count is informational.
+func (s Service) refCount(ctx context.Context, path string) int {
+	count, err := s.git.RefCount(ctx, path)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to count refs", "error", err)
+	}
+
+	return count
+}
+
+// objectsSize returns 0 if the objects can't be measured since the size is informational.
+func (s Service) objectsSize(ctx context.Context, path string) int64 {
+	size, err := s.git.ObjectsSize(ctx, path)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to measure objects", "error", err)
+	}
+
+	return size
+}
+
+// Verify checks the integrity of the backed up repository. Problems are reported as ErrCorrupt.
+func (s Service) Verify(ctx context.Context, targetFolder string) error {
+	ctx = clog.Add(ctx, "target_folder", targetFolder)
+	metadata, err := ReadMetadata(targetFolder)
+	if err != nil {
+		return fmt.Errorf("failed to read the metadata: %w", err)
+	}
+
+	err = s.git.Verify(ctx, targetFolder, metadata.Partial != nil)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrCorrupt, err)
+}
+
+func (s Service) Action(targetFolder string) (Action, error) {
+	exists, err := folderExists(targetFolder)
+	if err != nil {
+		return "", err
+	}
 
 	if exists {
-		return s.git.Fetch(ctx, targetFolder, privateSSHKey)
+		return ActionFetch, nil
 	}
 
-	return s.git.Clone(ctx, url, targetFolder, privateSSHKey)
+	return ActionClone, nil
+}
+
+// FolderSize returns the total size of files in the folder. Errors are ignored since the size is informational.
+func FolderSize(folder string) (size int64) {
+	_ = filepath.WalkDir(folder, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size
 }
 
 func folderExists(folder string) (bool, error) {
@@ -1,8 +1,15 @@
 package backup_test
 
 import (
+	"context"
 	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git"
 	"github.com/AntonKosov/git-backups/internal/git/backup"
 	"github.com/AntonKosov/git-backups/internal/git/backup/backupfakes"
 	. "github.com/onsi/ginkgo/v2"
@@ -10,13 +17,11 @@ import (
 )
 
 var _ = Describe("Service tests", func() {
-	const (
-		sourceURL     = "https://www.abc.com"
-		targetFolder  = "../../../test/data"
-		missingFolder = "missing_folder"
-	)
+	const sourceURL = "https://www.abc.com"
 
 	var (
+		targetFolder  string
+		missingFolder string
 		privateSSHKey *string
 		fakeGit       *backupfakes.FakeGit
 		service       backup.Service
@@ -24,13 +29,15 @@ var _ = Describe("Service tests", func() {
 	)
 
 	BeforeEach(func() {
+		targetFolder = GinkgoT().TempDir()
+		missingFolder = filepath.Join(targetFolder, "missing_folder")
 		privateSSHKey = nil
 		fakeGit = &backupfakes.FakeGit{}
 		service = backup.NewService(fakeGit)
 	})
 
 	JustBeforeEach(func() {
-		err = service.Run(ctx, sourceURL, missingFolder, privateSSHKey)
+		_, err = service.Run(ctx, sourceURL, missingFolder, backup.Settings{PrivateSSHKey: privateSSHKey})
 	})
 
 	It("does not return an error", func() {
@@ -39,7 +46,7 @@ var _ = Describe("Service tests", func() {
 
 	It("clones with correct arguments", func() {
 		Expect(fakeGit.CloneCallCount()).To(Equal(1))
-		_, url, path, privateSSHKey := fakeGit.CloneArgsForCall(0)
+		_, url, path, privateSSHKey, _ := fakeGit.CloneArgsForCall(0)
 		Expect(url).To(Equal(sourceURL))
 		Expect(path).To(Equal(missingFolder))
 		Expect(privateSSHKey).To(BeNil())
@@ -67,7 +74,7 @@ var _ = Describe("Service tests", func() {
 
 		It("clones with correct arguments", func() {
 			Expect(fakeGit.CloneCallCount()).To(Equal(1))
-			_, url, path, privateSSHKey := fakeGit.CloneArgsForCall(0)
+			_, url, path, privateSSHKey, _ := fakeGit.CloneArgsForCall(0)
 			Expect(url).To(Equal(sourceURL))
 			Expect(path).To(Equal(missingFolder))
 			Expect(*privateSSHKey).To(Equal("/path/to/ssh/key"))
@@ -76,7 +83,7 @@ var _ = Describe("Service tests", func() {
 
 	When("target folder exists", func() {
 		JustBeforeEach(func() {
-			err = service.Run(ctx, sourceURL, targetFolder, privateSSHKey)
+			_, err = service.Run(ctx, sourceURL, targetFolder, backup.Settings{PrivateSSHKey: privateSSHKey})
 		})
 
 		It("does not return an error", func() {
@@ -85,7 +92,7 @@ var _ = Describe("Service tests", func() {
 
 		It("fetches with correct arguments", func() {
 			Expect(fakeGit.FetchCallCount()).To(Equal(1))
-			_, path, privateSSHKey := fakeGit.FetchArgsForCall(0)
+			_, path, privateSSHKey, _ := fakeGit.FetchArgsForCall(0)
 			Expect(path).To(Equal(targetFolder))
 			Expect(privateSSHKey).To(BeNil())
 		})
@@ -102,7 +109,7 @@ var _ = Describe("Service tests", func() {
 
 			It("fetches with correct arguments", func() {
 				Expect(fakeGit.FetchCallCount()).To(Equal(1))
-				_, path, privateSSHKey := fakeGit.FetchArgsForCall(0)
+				_, path, privateSSHKey, _ := fakeGit.FetchArgsForCall(0)
 				Expect(path).To(Equal(targetFolder))
 				Expect(*privateSSHKey).To(Equal("/path/to/ssh/key"))
 			})
@@ -119,3 +126,406 @@ var _ = Describe("Service tests", func() {
 		})
 	})
 })
+
+var _ = DescribeTable("Action", func(folder string, expectedAction backup.Action) {
+	action, err := backup.NewService(&backupfakes.FakeGit{}).Action(folder)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(action).To(Equal(expectedAction))
+},
+	Entry("missing folder", "missing_folder", backup.ActionClone),
+	Entry("existing folder", "../../../test/data", backup.ActionFetch),
+)
+
+var _ = Describe("Verify", func() {
+	It("reports problems as corruption", func() {
+		fakeGit := &backupfakes.FakeGit{}
+		fakeGit.VerifyReturns(errors.New("error: refs/heads/main: invalid sha1 pointer"))
+
+		err := backup.NewService(fakeGit).Verify(ctx, "../../../test/data")
+		Expect(err).To(MatchError(backup.ErrCorrupt))
+		Expect(err).To(MatchError(ContainSubstring("invalid sha1 pointer")))
+		_, path, _ := fakeGit.VerifyArgsForCall(0)
+		Expect(path).To(Equal("../../../test/data"))
+	})
+})
+
+var _ = Describe("Result tests", func() {
+	var (
+		fakeGit *backupfakes.FakeGit
+		target  string
+		result  backup.Result
+		err     error
+	)
+
+	BeforeEach(func() {
+		target = GinkgoT().TempDir()
+		Expect(os.WriteFile(filepath.Join(target, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644)).To(Succeed())
+		fakeGit = &backupfakes.FakeGit{}
+		fakeGit.RefCountReturnsOnCall(0, 3, nil)
+		fakeGit.RefCountReturnsOnCall(1, 5, nil)
+		fakeGit.ObjectsSizeReturnsOnCall(0, 1000, nil)
+		fakeGit.ObjectsSizeReturnsOnCall(1, 1500, nil)
+	})
+
+	JustBeforeEach(func() {
+		result, err = backup.NewService(fakeGit).Run(ctx, "https://www.abc.com", target, backup.Settings{})
+	})
+
+	It("describes the backup", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.URL).To(Equal("https://www.abc.com"))
+		Expect(result.Path).To(Equal(target))
+		Expect(result.Action).To(Equal(backup.ActionFetch))
+		Expect(result.Size).To(BeNumerically(">", 0))
+		Expect(result.RefsBefore).To(Equal(3))
+		Expect(result.RefsAfter).To(Equal(5))
+		Expect(result.Fetched()).To(Equal(int64(500)))
+	})
+
+	It("records the time of the backup", func() {
+		metadata, err := backup.ReadMetadata(target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metadata.LastBackup).To(BeTemporally("==", result.Started))
+	})
+
+	When("fetch returns an error", func() {
+		BeforeEach(func() {
+			fakeGit.FetchReturns(errors.New("something went wrong"))
+		})
+
+		It("describes the failed backup", func() {
+			Expect(err).To(MatchError("something went wrong"))
+			Expect(result.Action).To(Equal(backup.ActionFetch))
+			Expect(result.RefsAfter).To(Equal(3))
+			Expect(result.Fetched()).To(BeZero())
+
+			metadata, err := backup.ReadMetadata(target)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.LastBackup).To(BeZero())
+		})
+	})
+})
+
+var _ = Describe("Storage tests", func() {
+	var (
+		fakeGit     *backupfakes.FakeGit
+		fakeStorage *backupfakes.FakeStorage
+		rootFolder  string
+		target      string
+		err         error
+	)
+
+	BeforeEach(func() {
+		fakeGit = &backupfakes.FakeGit{}
+		fakeStorage = &backupfakes.FakeStorage{}
+		fakeStorage.PullReturns(fs.ErrNotExist)
+		rootFolder = GinkgoT().TempDir()
+		target = filepath.Join(rootFolder, "owner", "repo")
+	})
+
+	JustBeforeEach(func() {
+		service := backup.NewService(fakeGit).WithStorage(rootFolder, fakeStorage)
+		_, err = service.Run(ctx, "https://www.abc.com", target, backup.Settings{})
+	})
+
+	It("clones new repositories and pushes them", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeStorage.PullCallCount()).To(Equal(1))
+		Expect(fakeGit.CloneCallCount()).To(Equal(1))
+		Expect(fakeStorage.PushCallCount()).To(Equal(1))
+		_, relativePath, localFolder := fakeStorage.PushArgsForCall(0)
+		Expect(relativePath).To(Equal("owner/repo"))
+		Expect(localFolder).To(Equal(target))
+	})
+
+	When("the storage has the repository", func() {
+		BeforeEach(func() {
+			fakeStorage.PullStub = func(_ context.Context, _, localFolder string) error {
+				return os.MkdirAll(localFolder, 0o755)
+			}
+		})
+
+		It("fetches the pulled repository", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeGit.CloneCallCount()).To(BeZero())
+			Expect(fakeGit.FetchCallCount()).To(Equal(1))
+		})
+	})
+
+	When("the staging folder exists", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(target, 0o755)).To(Succeed())
+		})
+
+		It("doesn't pull", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeStorage.PullCallCount()).To(BeZero())
+			Expect(fakeGit.FetchCallCount()).To(Equal(1))
+			Expect(fakeStorage.PushCallCount()).To(Equal(1))
+		})
+	})
+
+	When("the pull fails", func() {
+		BeforeEach(func() {
+			fakeStorage.PullReturns(errors.New("connection lost"))
+		})
+
+		It("doesn't clone", func() {
+			Expect(err).To(MatchError("failed to pull from the remote storage: connection lost"))
+			Expect(fakeGit.CloneCallCount()).To(BeZero())
+		})
+	})
+
+	When("the backup fails", func() {
+		BeforeEach(func() {
+			fakeGit.CloneReturns(errors.New("something went wrong"))
+		})
+
+		It("doesn't push", func() {
+			Expect(err).To(MatchError("something went wrong"))
+			Expect(fakeStorage.PushCallCount()).To(BeZero())
+		})
+	})
+
+	When("the target is outside of the root folder", func() {
+		BeforeEach(func() {
+			target = filepath.Join(GinkgoT().TempDir(), "repo")
+		})
+
+		It("keeps the repository locally", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeStorage.PullCallCount()).To(BeZero())
+			Expect(fakeStorage.PushCallCount()).To(BeZero())
+		})
+	})
+})
+
+var _ = Describe("Maintenance tests", func() {
+	var (
+		fakeGit     *backupfakes.FakeGit
+		maintenance config.Maintenance
+		rootFolder  string
+		target      string
+		err         error
+	)
+
+	snapshots := func() []string {
+		files, err := backup.Snapshots(target)
+		Expect(err).NotTo(HaveOccurred())
+		return files
+	}
+
+	BeforeEach(func() {
+		fakeGit = &backupfakes.FakeGit{}
+		fakeGit.RefsReturns(map[string]string{"refs/heads/main": "1111"}, nil)
+		maintenance = config.Maintenance{
+			Interval:      7 * 24 * time.Hour,
+			Tasks:         []string{"gc", "commit-graph"},
+			PruneAfter:    14 * 24 * time.Hour,
+			KeepSnapshots: 2,
+		}
+		rootFolder = GinkgoT().TempDir()
+		target = filepath.Join(rootFolder, "owner", "repo")
+		Expect(os.MkdirAll(target, 0o755)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		_, err = backup.NewService(fakeGit).Run(ctx, "https://www.abc.com", target, backup.Settings{Maintenance: maintenance})
+	})
+
+	It("records a ref snapshot and maintains the repository", func() {
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(snapshots()).To(HaveLen(1))
+		content, err := os.ReadFile(snapshots()[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal("1111 refs/heads/main\n"))
+
+		Expect(fakeGit.MaintainCallCount()).To(Equal(1))
+		_, path, tasks, pruneAfter, keep := fakeGit.MaintainArgsForCall(0)
+		Expect(path).To(Equal(target))
+		Expect(tasks).To(Equal([]string{"gc", "commit-graph"}))
+		Expect(pruneAfter).To(Equal(14 * 24 * time.Hour))
+		Expect(keep).To(Equal([]string{"1111"}))
+
+		metadata, err := backup.ReadMetadata(target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metadata.LastMaintenance).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	When("the repository was maintained recently", func() {
+		BeforeEach(func() {
+			Expect(backup.Metadata{LastMaintenance: time.Now().Add(-24 * time.Hour)}.WriteFile(target)).To(Succeed())
+		})
+
+		It("skips the maintenance", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeGit.MaintainCallCount()).To(BeZero())
+			Expect(snapshots()).To(HaveLen(1))
+		})
+	})
+
+	When("there are older snapshots", func() {
+		BeforeEach(func() {
+			folder := filepath.Join(target, backup.MetadataFolder, backup.SnapshotsFolder)
+			Expect(os.MkdirAll(folder, 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(folder, "20240101T000000Z.refs"), []byte("aaaa refs/heads/main\n"), 0o644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(folder, "20240201T000000Z.refs"), []byte("bbbb refs/heads/main\nbbbb refs/tags/v1\n"), 0o644)).To(Succeed())
+		})
+
+		It("keeps the objects of the kept snapshots only", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshots()).To(HaveLen(2))
+			Expect(filepath.Base(snapshots()[0])).To(Equal("20240201T000000Z.refs"))
+
+			_, _, _, _, keep := fakeGit.MaintainArgsForCall(0)
+			Expect(keep).To(Equal([]string{"1111", "bbbb"}))
+		})
+
+		When("no snapshots are kept", func() {
+			BeforeEach(func() {
+				maintenance.KeepSnapshots = 0
+			})
+
+			It("removes them, so that their objects aren't kept forever", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(snapshots()).To(BeEmpty())
+
+				_, _, _, _, keep := fakeGit.MaintainArgsForCall(0)
+				Expect(keep).To(BeEmpty())
+			})
+		})
+
+		When("the refs didn't change", func() {
+			BeforeEach(func() {
+				fakeGit.RefsReturns(map[string]string{"refs/heads/main": "bbbb", "refs/tags/v1": "bbbb"}, nil)
+			})
+
+			It("doesn't record a new snapshot", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(snapshots()).To(HaveLen(2))
+				Expect(filepath.Base(snapshots()[1])).To(Equal("20240201T000000Z.refs"))
+			})
+		})
+	})
+
+	When("the maintenance fails", func() {
+		BeforeEach(func() {
+			fakeGit.MaintainReturns(errors.New("task gc failed"))
+		})
+
+		It("returns an error and tries again next time", func() {
+			Expect(err).To(MatchError("failed to maintain: task gc failed"))
+			metadata, err := backup.ReadMetadata(target)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.LastMaintenance).To(BeZero())
+		})
+	})
+
+	When("the maintenance is disabled", func() {
+		BeforeEach(func() {
+			maintenance = config.Maintenance{}
+		})
+
+		It("is not maintained", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeGit.MaintainCallCount()).To(BeZero())
+			Expect(snapshots()).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Partial tests", func() {
+	var (
+		fakeGit    *backupfakes.FakeGit
+		service    backup.Service
+		rootFolder string
+		target     string
+		partial    config.Partial
+		settings   backup.Settings
+		err        error
+	)
+
+	BeforeEach(func() {
+		fakeGit = &backupfakes.FakeGit{}
+		rootFolder = GinkgoT().TempDir()
+		target = filepath.Join(rootFolder, "repo")
+		Expect(os.MkdirAll(target, 0o755)).To(Succeed())
+		partial = config.Partial{BlobLimit: 1 << 20}
+		settings = backup.Settings{}
+		service = backup.NewService(fakeGit)
+	})
+
+	JustBeforeEach(func() {
+		settings.Partial = partial
+		_, err = service.Run(ctx, "https://www.abc.com", target, settings)
+	})
+
+	It("fetches partially and records it", func() {
+		Expect(err).NotTo(HaveOccurred())
+		_, _, _, options := fakeGit.FetchArgsForCall(0)
+		Expect(options).To(Equal(git.FetchOptions{Filter: "blob:limit=1048576"}))
+
+		metadata, err := backup.ReadMetadata(target)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metadata.Partial).To(Equal(&backup.Partial{BlobLimit: 1 << 20}))
+
+		Expect(service.Verify(ctx, target)).To(Succeed())
+		_, _, partialMirror := fakeGit.VerifyArgsForCall(0)
+		Expect(partialMirror).To(BeTrue())
+	})
+
+	When("a timeout and LFS are set", func() {
+		BeforeEach(func() {
+			settings = backup.Settings{Timeout: time.Hour, LFS: true}
+		})
+
+		It("passes them to git", func() {
+			Expect(err).NotTo(HaveOccurred())
+			_, _, _, options := fakeGit.FetchArgsForCall(0)
+			Expect(options).To(Equal(git.FetchOptions{Filter: "blob:limit=1048576", Timeout: time.Hour, LFS: true}))
+		})
+	})
+
+	When("only some refs and recent history are backed up", func() {
+		BeforeEach(func() {
+			partial = config.Partial{Refs: []string{"refs/heads/main"}, ShallowSince: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)}
+		})
+
+		It("fetches them only", func() {
+			Expect(err).NotTo(HaveOccurred())
+			_, _, _, options := fakeGit.FetchArgsForCall(0)
+			Expect(options).To(Equal(git.FetchOptions{Refs: partial.Refs, ShallowSince: partial.ShallowSince}))
+		})
+	})
+
+	When("the settings are removed", func() {
+		BeforeEach(func() {
+			Expect(backup.Metadata{Partial: &backup.Partial{BlobLimit: 1}}.WriteFile(target)).To(Succeed())
+			partial = config.Partial{}
+		})
+
+		It("still knows that the mirror is partial", func() {
+			Expect(err).NotTo(HaveOccurred())
+			_, _, _, options := fakeGit.FetchArgsForCall(0)
+			Expect(options).To(Equal(git.FetchOptions{}))
+
+			metadata, err := backup.ReadMetadata(target)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.Partial).To(Equal(&backup.Partial{BlobLimit: 1}))
+		})
+	})
+
+	When("the backup fails", func() {
+		BeforeEach(func() {
+			fakeGit.FetchReturns(errors.New("something went wrong"))
+		})
+
+		It("doesn't record the settings", func() {
+			Expect(err).To(HaveOccurred())
+			metadata, err := backup.ReadMetadata(target)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.Partial).To(BeNil())
+		})
+	})
+})
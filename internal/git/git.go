@@ -2,8 +2,18 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/AntonKosov/git-backups/internal/clog"
 	"github.com/AntonKosov/git-backups/internal/cmd"
@@ -12,44 +22,563 @@ import (
 type Git struct {
 }
 
-func (g Git) Clone(ctx context.Context, url, path string, privateSSHKey *string) error {
+// FetchOptions control what a mirror fetches. The zero value fetches everything without a time limit.
+type FetchOptions struct {
+	// Filter is an object filter of the remote, e.g. blob:limit=1048576.
+	Filter string
+	// Refs are the refs to fetch, e.g. refs/heads/main or refs/tags/*. Branches and tags are fetched if
+	// it's empty.
+	Refs []string
+	// ExcludeRefs are left out even if they match Refs.
+	ExcludeRefs []string
+	// ShallowSince leaves out commits older than this time.
+	ShallowSince time.Time
+	// Timeout kills every git command which talks to the remote and takes longer. Zero is unlimited.
+	Timeout time.Duration
+	// LFS fetches the Git LFS objects of all refs as well. It requires git-lfs.
+	LFS bool
+}
+
+// partial reports whether the options leave out a part of the repository.
+func (o FetchOptions) partial() bool {
+	return o.Filter != "" || len(o.Refs) > 0 || len(o.ExcludeRefs) > 0 || !o.ShallowSince.IsZero()
+}
+
+// Clone creates a bare mirror of the repository. A partial mirror is fetched into an empty repository
+// which is configured to keep fetching only what the options allow.
+func (g Git) Clone(ctx context.Context, url, path string, privateSSHKey *string, options FetchOptions) error {
 	ctx = clog.Add(ctx, "path", path)
 	slog.InfoContext(ctx, "Cloning repository...")
 
+	var err error
+	if options.partial() {
+		err = g.clonePartial(ctx, url, path, privateSSHKey, options)
+	} else {
+		err = cmd.Execute(
+			ctx,
+			"git",
+			argumentsWithSSHKey(privateSSHKey, "clone", "--bare", url, path),
+			cmd.WithTimeout(options.Timeout),
+		)
+	}
+	if err == nil && options.LFS {
+		err = g.fetchLFS(ctx, path, privateSSHKey, options.Timeout)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to clone", "error", err.Error())
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully cloned repository")
+	return nil
+}
+
+// Fetch updates the mirror. The options are applied again, so that changes to them take effect, but
+// objects which were left out before are not fetched.
+func (g Git) Fetch(ctx context.Context, path string, privateSSHKey *string, options FetchOptions) error {
+	ctx = clog.Add(ctx, "path", path)
+	slog.InfoContext(ctx, "Fetching repository...")
+
+	var err error
+	if options.partial() {
+		err = g.fetchPartial(ctx, path, privateSSHKey, options)
+	} else {
+		err = cmd.Execute(
+			ctx,
+			"git",
+			argumentsWithSSHKey(privateSSHKey, "-C", path, "--bare", "fetch"),
+			cmd.WithTimeout(options.Timeout),
+		)
+	}
+	if err == nil && options.LFS {
+		err = g.fetchLFS(ctx, path, privateSSHKey, options.Timeout)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to fetch", "error", err.Error())
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully fetched repository")
+	return nil
+}
+
+func (g Git) clonePartial(ctx context.Context, url, path string, privateSSHKey *string, options FetchOptions) (err error) {
+	if err := g.InitBare(ctx, path); err != nil {
+		return err
+	}
+	// Like git clone, a failed clone leaves nothing behind.
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(path)
+		}
+	}()
+
+	if err := cmd.Execute(ctx, "git", cmd.WithArguments("-C", path, "config", "remote.origin.url", url)); err != nil {
+		return err
+	}
+
+	if err := g.fetchPartial(ctx, path, privateSSHKey, options); err != nil {
+		return err
+	}
+
+	var output strings.Builder
+	err = cmd.Execute(
+		ctx,
+		"git",
+		argumentsWithSSHKey(privateSSHKey, "-C", path, "ls-remote", "--symref", "origin", "HEAD"),
+		cmd.WithStdoutWriter(&output),
+		cmd.WithTimeout(options.Timeout),
+	)
+	if err != nil {
+		return err
+	}
+
+	// The first line is "ref: refs/heads/<branch>\tHEAD" unless HEAD of the remote is detached.
+	if target, ok := strings.CutPrefix(output.String(), "ref: "); ok {
+		head, _, _ := strings.Cut(target, "\t")
+		return cmd.Execute(ctx, "git", cmd.WithArguments("-C", path, "symbolic-ref", "HEAD", head))
+	}
+
+	return nil
+}
+
+func (g Git) fetchPartial(ctx context.Context, path string, privateSSHKey *string, options FetchOptions) error {
+	if err := g.configurePartial(ctx, path, options); err != nil {
+		return fmt.Errorf("failed to configure the partial mirror: %w", err)
+	}
+
+	args := []string{"-C", path, "fetch", "--quiet"}
+	if !options.ShallowSince.IsZero() {
+		args = append(args, "--shallow-since="+options.ShallowSince.UTC().Format(time.RFC3339))
+	}
+
+	return cmd.Execute(ctx, "git", argumentsWithSSHKey(privateSSHKey, append(args, "origin")...), cmd.WithTimeout(options.Timeout))
+}
+
+// fetchLFS downloads the LFS objects of all refs of the origin remote into the mirror.
+func (g Git) fetchLFS(ctx context.Context, path string, privateSSHKey *string, timeout time.Duration) error {
+	return cmd.Execute(
+		ctx,
+		"git",
+		argumentsWithSSHKey(privateSSHKey, "-C", path, "lfs", "fetch", "--all", "origin"),
+		cmd.WithTimeout(timeout),
+	)
+}
+
+// configurePartial replaces the fetched refs of the origin remote and makes it a promisor remote which
+// sends only the objects passing the filter. The filter is removed if there is none, but the objects
+// which were left out are still missing.
+func (g Git) configurePartial(ctx context.Context, path string, options FetchOptions) error {
+	gitConfig := func(args ...string) error {
+		return cmd.Execute(ctx, "git", cmd.WithArguments(append([]string{"-C", path, "config"}, args...)...))
+	}
+
+	refs := options.Refs
+	if len(refs) == 0 {
+		refs = []string{"refs/heads/*", "refs/tags/*"}
+	}
+	if err := gitConfig("--unset-all", "remote.origin.fetch"); err != nil && !nothingToUnset(err) {
+		return err
+	}
+	for _, ref := range refs {
+		if err := gitConfig("--add", "remote.origin.fetch", "+"+ref+":"+ref); err != nil {
+			return err
+		}
+	}
+	for _, ref := range options.ExcludeRefs {
+		if err := gitConfig("--add", "remote.origin.fetch", "^"+ref); err != nil {
+			return err
+		}
+	}
+
+	if options.Filter == "" {
+		if err := gitConfig("--unset", "remote.origin.partialCloneFilter"); err != nil && !nothingToUnset(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	settings := [][]string{
+		{"core.repositoryFormatVersion", "1"},
+		{"extensions.partialClone", "origin"},
+		{"remote.origin.promisor", "true"},
+		{"remote.origin.partialCloneFilter", options.Filter},
+	}
+	for _, setting := range settings {
+		if err := gitConfig(setting...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nothingToUnset reports whether git config failed because the key to unset doesn't exist.
+func nothingToUnset(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == 5
+}
+
+// RefCount returns the number of refs (branches, tags, etc.) in the repository.
+func (g Git) RefCount(ctx context.Context, path string) (int, error) {
+	var output strings.Builder
 	err := cmd.Execute(
 		ctx,
 		"git",
-		argumentsWithSSHKey(privateSSHKey, "clone", "--bare", url, path),
+		cmd.WithArguments("-C", path, "for-each-ref", "--format=%(refname)"),
+		cmd.WithStdoutWriter(&output),
 	)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to clone", "error", err.Error())
+		return 0, err
+	}
+
+	return strings.Count(output.String(), "\n"), nil
+}
+
+// ObjectsSize returns the size of the loose and packed objects of the repository in bytes.
+func (g Git) ObjectsSize(ctx context.Context, path string) (int64, error) {
+	var output strings.Builder
+	err := cmd.Execute(
+		ctx,
+		"git",
+		cmd.WithArguments("-C", path, "count-objects", "-v"),
+		cmd.WithStdoutWriter(&output),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for line := range strings.Lines(output.String()) {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+		if key != "size" && key != "size-pack" {
+			continue
+		}
+
+		kibibytes, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected output of count-objects: %q", line)
+		}
+		size += kibibytes << 10
+	}
 
+	return size, nil
+}
+
+// Verify checks the objects and their connectivity, that every ref resolves to an existing object and
+// that HEAD points to a commit unless the repository is empty. HEAD of a partial mirror may point to a
+// branch which isn't backed up, so it isn't checked.
+func (g Git) Verify(ctx context.Context, path string, partial bool) error {
+	ctx = clog.Add(ctx, "path", path)
+	slog.InfoContext(ctx, "Verifying repository...")
+
+	if err := cmd.Execute(ctx, "git", cmd.WithArguments("-C", path, "fsck", "--full", "--no-dangling", "--no-progress")); err != nil {
 		return err
 	}
 
-	slog.InfoContext(ctx, "Successfully cloned repository")
+	var refs strings.Builder
+	err := cmd.Execute(
+		ctx,
+		"git",
+		cmd.WithArguments("-C", path, "for-each-ref", "--format=%(objectname) %(refname)"),
+		cmd.WithStdoutWriter(&refs),
+	)
+	if err != nil {
+		return err
+	}
+
+	if refs.Len() == 0 {
+		slog.InfoContext(ctx, "Repository is empty")
+		return nil
+	}
+
+	// Missing objects are reported as "<object name> missing", so refs are matched by their object names.
+	var objects strings.Builder
+	err = cmd.Execute(
+		ctx,
+		"git",
+		cmd.WithArguments("-C", path, "cat-file", "--batch-check=%(objectname) %(objecttype) %(rest)"),
+		cmd.WithStdinReader(strings.NewReader(refs.String())),
+		cmd.WithStdoutWriter(&objects),
+	)
+	if err != nil {
+		return err
+	}
+
+	missing := map[string]bool{}
+	for line := range strings.Lines(objects.String()) {
+		if objectName, found := strings.CutSuffix(strings.TrimSpace(line), " missing"); found {
+			missing[objectName] = true
+		}
+	}
+
+	var unresolved []string
+	for line := range strings.Lines(refs.String()) {
+		if objectName, refName, ok := strings.Cut(strings.TrimSpace(line), " "); ok && missing[objectName] {
+			unresolved = append(unresolved, refName)
+		}
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("refs point to missing objects: %v", strings.Join(unresolved, ", "))
+	}
+
+	if partial {
+		slog.InfoContext(ctx, "Partial repository is valid")
+		return nil
+	}
+
+	if err := cmd.Execute(ctx, "git", cmd.WithArguments("-C", path, "rev-parse", "--verify", "--quiet", "HEAD^{commit}")); err != nil {
+		return fmt.Errorf("HEAD does not point to a commit: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Repository is valid")
 	return nil
 }
 
-func (g Git) Fetch(ctx context.Context, path string, privateSSHKey *string) error {
+// PushMirror pushes all refs of the repository to the remote and deletes remote refs which don't exist
+// locally.
+func (g Git) PushMirror(ctx context.Context, path, url string, privateSSHKey *string) error {
 	ctx = clog.Add(ctx, "path", path)
-	slog.InfoContext(ctx, "Fetching repository...")
+	slog.InfoContext(ctx, "Pushing repository...")
 
 	err := cmd.Execute(
 		ctx,
 		"git",
-		argumentsWithSSHKey(privateSSHKey, "-C", path, "--bare", "fetch"),
+		argumentsWithSSHKey(privateSSHKey, "-C", path, "push", "--mirror", url),
 	)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to fetch", "error", err.Error())
+		slog.ErrorContext(ctx, "Failed to push", "error", err.Error())
 
 		return err
 	}
 
-	slog.InfoContext(ctx, "Successfully fetched repository")
+	slog.InfoContext(ctx, "Successfully pushed repository")
+	return nil
+}
+
+// PushRefs force-pushes the refs to the objects they had in a snapshot. Objects must still exist in the
+// repository.
+func (g Git) PushRefs(ctx context.Context, path, url string, refs map[string]string, privateSSHKey *string) error {
+	ctx = clog.Add(ctx, "path", path)
+	slog.InfoContext(ctx, "Pushing refs...", "refs", len(refs))
+
+	args := []string{"-C", path, "push", "--force", url}
+	for _, ref := range slices.Sorted(maps.Keys(refs)) {
+		args = append(args, fmt.Sprintf("%v:%v", refs[ref], ref))
+	}
+
+	if err := cmd.Execute(ctx, "git", argumentsWithSSHKey(privateSSHKey, args...)); err != nil {
+		slog.ErrorContext(ctx, "Failed to push refs", "error", err.Error())
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully pushed refs")
+	return nil
+}
+
+// PushLFS uploads all LFS objects stored in the repository. It requires git-lfs.
+func (g Git) PushLFS(ctx context.Context, path, url string, privateSSHKey *string) error {
+	ctx = clog.Add(ctx, "path", path)
+	slog.InfoContext(ctx, "Pushing LFS objects...")
+
+	err := cmd.Execute(
+		ctx,
+		"git",
+		argumentsWithSSHKey(privateSSHKey, "-C", path, "lfs", "push", "--all", url),
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to push LFS objects", "error", err.Error())
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully pushed LFS objects")
+	return nil
+}
+
+// Refs returns the object name of every ref in the repository.
+func (g Git) Refs(ctx context.Context, path string) (map[string]string, error) {
+	var output strings.Builder
+	err := cmd.Execute(
+		ctx,
+		"git",
+		cmd.WithArguments("-C", path, "for-each-ref", "--format=%(objectname) %(refname)"),
+		cmd.WithStdoutWriter(&output),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := map[string]string{}
+	for line := range strings.Lines(output.String()) {
+		if objectName, refName, ok := strings.Cut(strings.TrimSpace(line), " "); ok {
+			refs[refName] = objectName
+		}
+	}
+
+	return refs, nil
+}
+
+// CreateBundle streams a bundle with all refs of the repository into the output. Objects reachable from
+// the prerequisites are left out, so that the bundle can only be applied on top of them.
+func (g Git) CreateBundle(ctx context.Context, path string, output io.Writer, prerequisites []string) error {
+	ctx = clog.Add(ctx, "path", path)
+	slog.InfoContext(ctx, "Creating bundle...")
+
+	args := []string{"-C", path, "bundle", "create", "--quiet", "-", "--all"}
+	if len(prerequisites) > 0 {
+		args = append(append(args, "--not"), prerequisites...)
+	}
+
+	if err := cmd.Execute(ctx, "git", cmd.WithArguments(args...), cmd.WithStdoutWriter(output)); err != nil {
+		slog.ErrorContext(ctx, "Failed to create bundle", "error", err.Error())
+
+		return err
+	}
+
+	slog.InfoContext(ctx, "Successfully created bundle")
 	return nil
 }
 
+// InitBare creates an empty bare repository.
+func (g Git) InitBare(ctx context.Context, path string) error {
+	return cmd.Execute(ctx, "git", cmd.WithArguments("init", "--quiet", "--bare", path))
+}
+
+// FetchBundle updates the refs of the repository to the ones in the bundle file. Refs missing from the
+// bundle are deleted.
+func (g Git) FetchBundle(ctx context.Context, path, file string) error {
+	ctx = clog.Add(ctx, "path", path)
+	slog.InfoContext(ctx, "Fetching bundle...", "file", file)
+
+	err := cmd.Execute(
+		ctx,
+		"git",
+		cmd.WithArguments("-C", path, "fetch", "--quiet", "--prune", "--force", file, "refs/*:refs/*"),
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to fetch bundle", "error", err.Error())
+
+		return err
+	}
+
+	return nil
+}
+
+// keepRefPrefix is the namespace of temporary refs which keep objects reachable during maintenance.
+const keepRefPrefix = "refs/git-backups/keep/"
+
+// Maintain runs the tasks in the given order: gc, commit-graph, incremental-repack or multi-pack-index.
+// Automatic gc is disabled in the repository, so that objects are only pruned by maintenance. gc prunes
+// unreachable objects older than pruneAfter, except for the objects to keep, which are referenced by
+// temporary refs while the tasks run.
+func (g Git) Maintain(ctx context.Context, path string, tasks []string, pruneAfter time.Duration, keep []string) (err error) {
+	ctx = clog.Add(ctx, "path", path)
+	slog.InfoContext(ctx, "Maintaining repository...", "tasks", tasks)
+
+	for _, setting := range [][]string{{"gc.auto", "0"}, {"maintenance.auto", "false"}} {
+		if err := cmd.Execute(ctx, "git", cmd.WithArguments(append([]string{"-C", path, "config"}, setting...)...)); err != nil {
+			return err
+		}
+	}
+
+	// Refs left by an interrupted maintenance are removed before new ones are created.
+	if err := g.deleteKeepRefs(ctx, path); err != nil {
+		return err
+	}
+	if err := g.createKeepRefs(ctx, path, keep); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, g.deleteKeepRefs(context.WithoutCancel(ctx), path))
+	}()
+
+	hasPacks := func() bool {
+		packs, _ := filepath.Glob(filepath.Join(path, "objects", "pack", "*.pack"))
+		return len(packs) > 0
+	}
+
+	for _, task := range tasks {
+		var args []string
+		switch task {
+		case "gc":
+			args = []string{"gc", "--quiet", fmt.Sprintf("--prune=%d.seconds.ago", int64(pruneAfter.Seconds()))}
+		case "commit-graph":
+			args = []string{"commit-graph", "write", "--reachable", "--split"}
+		case "incremental-repack":
+			args = []string{"maintenance", "run", "--task=incremental-repack"}
+		case "multi-pack-index":
+			args = []string{"multi-pack-index", "write"}
+		default:
+			return fmt.Errorf("unknown maintenance task %q", task)
+		}
+
+		// The multi-pack-index can't be written without packs, e.g. in an empty repository.
+		if (task == "incremental-repack" || task == "multi-pack-index") && !hasPacks() {
+			continue
+		}
+
+		if err := cmd.Execute(ctx, "git", cmd.WithArguments(append([]string{"-C", path}, args...)...)); err != nil {
+			slog.ErrorContext(ctx, "Failed to maintain", "task", task, "error", err.Error())
+
+			return fmt.Errorf("task %v failed: %w", task, err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Successfully maintained repository")
+	return nil
+}
+
+// createKeepRefs creates a temporary ref for every object to keep which still exists in the repository.
+func (g Git) createKeepRefs(ctx context.Context, path string, keep []string) error {
+	if len(keep) == 0 {
+		return nil
+	}
+
+	var objects strings.Builder
+	err := cmd.Execute(
+		ctx,
+		"git",
+		cmd.WithArguments("-C", path, "cat-file", "--batch-check=%(objectname)"),
+		cmd.WithStdinReader(strings.NewReader(strings.Join(keep, "\n")+"\n")),
+		cmd.WithStdoutWriter(&objects),
+	)
+	if err != nil {
+		return err
+	}
+
+	var commands strings.Builder
+	for line := range strings.Lines(objects.String()) {
+		if objectName := strings.TrimSpace(line); !strings.HasSuffix(objectName, " missing") {
+			fmt.Fprintf(&commands, "create %v%v %v\n", keepRefPrefix, objectName, objectName)
+		}
+	}
+	if commands.Len() == 0 {
+		return nil
+	}
+
+	return cmd.Execute(ctx, "git", cmd.WithArguments("-C", path, "update-ref", "--stdin"), cmd.WithStdinReader(strings.NewReader(commands.String())))
+}
+
+func (g Git) deleteKeepRefs(ctx context.Context, path string) error {
+	var refs strings.Builder
+	err := cmd.Execute(
+		ctx,
+		"git",
+		cmd.WithArguments("-C", path, "for-each-ref", "--format=delete %(refname)", keepRefPrefix),
+		cmd.WithStdoutWriter(&refs),
+	)
+	if err != nil || refs.Len() == 0 {
+		return err
+	}
+
+	return cmd.Execute(ctx, "git", cmd.WithArguments("-C", path, "update-ref", "--stdin"), cmd.WithStdinReader(strings.NewReader(refs.String())))
+}
+
 func argumentsWithSSHKey(privateSSHKey *string, otherArgs ...string) cmd.Option {
 	if privateSSHKey != nil {
 		sshCommand := fmt.Sprintf(
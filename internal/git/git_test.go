@@ -111,7 +111,7 @@ var _ = Describe("Git tests", func() {
 		})
 
 		JustBeforeEach(func() {
-			err = worker.Clone(ctx, source, targetPath, privateSSHKey)
+			err = worker.Clone(ctx, source, targetPath, privateSSHKey, git.FetchOptions{})
 		})
 
 		It("does not return an error", func() {
@@ -149,13 +149,13 @@ var _ = Describe("Git tests", func() {
 
 		BeforeEach(func() {
 			privateSSHKey = nil
-			err := worker.Clone(ctx, sourcePath, targetPath, privateSSHKey)
+			err := worker.Clone(ctx, sourcePath, targetPath, privateSSHKey, git.FetchOptions{})
 			Expect(err).NotTo(HaveOccurred())
 			unzipArchiveToSource(secondCommitArchive)
 		})
 
 		JustBeforeEach(func() {
-			err = worker.Fetch(ctx, targetPath, nil)
+			err = worker.Fetch(ctx, targetPath, nil, git.FetchOptions{})
 		})
 
 		It("does not return an error", func() {
@@ -166,6 +166,18 @@ var _ = Describe("Git tests", func() {
 			verifyID(secondCommitID)
 		})
 
+		It("counts refs", func() {
+			count, err := worker.RefCount(ctx, targetPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(count).To(BeNumerically(">", 0))
+		})
+
+		It("measures the objects", func() {
+			size, err := worker.ObjectsSize(ctx, targetPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(size).To(BeNumerically(">", 0))
+		})
+
 		When("source is unavailable", func() {
 			BeforeEach(func() {
 				clearSource()
@@ -187,4 +199,256 @@ var _ = Describe("Git tests", func() {
 			})
 		})
 	})
+
+	Context("Verify", func() {
+		BeforeEach(func() {
+			Expect(worker.Clone(ctx, sourcePath, targetPath, nil, git.FetchOptions{})).To(Succeed())
+		})
+
+		JustBeforeEach(func() {
+			err = worker.Verify(ctx, targetPath, false)
+		})
+
+		It("does not return an error", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("a ref points to a missing object", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(targetPath+"/refs/heads/broken", []byte(strings.Repeat("1", 40)+"\n"), 0o644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("refs/heads/broken: invalid sha1 pointer")))
+			})
+		})
+
+		When("HEAD points to a missing branch", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(targetPath+"/HEAD", []byte("ref: refs/heads/missing\n"), 0o644)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring("HEAD does not point to a commit")))
+			})
+		})
+	})
+
+	Context("Partial", func() {
+		const blobID = "c1acd67603a38fd7000a137926887ce9cabe6dcb"
+
+		var options git.FetchOptions
+
+		runGit := func(path string, args ...string) string {
+			var output strings.Builder
+			err := cmd.Execute(ctx, "git", cmd.WithArguments(append([]string{"-C", path}, args...)...), cmd.WithStdoutWriter(&output))
+			Expect(err).NotTo(HaveOccurred())
+
+			return strings.TrimSpace(output.String())
+		}
+
+		BeforeEach(func() {
+			unzipArchiveToSource(secondCommitArchive)
+			runGit(sourcePath, "branch", "feature", firstCommitID)
+			runGit(sourcePath, "tag", "v1", firstCommitID)
+			runGit(sourcePath, "config", "uploadpack.allowFilter", "true")
+			options = git.FetchOptions{Filter: "blob:limit=1", ExcludeRefs: []string{"refs/heads/feature"}}
+		})
+
+		JustBeforeEach(func() {
+			// Filters are ignored by clones of local paths.
+			err = worker.Clone(ctx, "file://"+sourcePath, targetPath, nil, options)
+		})
+
+		It("leaves out large blobs and excluded refs", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runGit(targetPath, "symbolic-ref", "HEAD")).To(Equal("refs/heads/master"))
+			Expect(runGit(targetPath, "for-each-ref", "--format=%(refname)")).To(Equal("refs/heads/master\nrefs/tags/v1"))
+			Expect(runGit(targetPath, "rev-list", "--objects", "--missing=print", "--all")).To(ContainSubstring("?" + blobID))
+			Expect(worker.Verify(ctx, targetPath, true)).To(Succeed())
+		})
+
+		It("keeps the options when fetching", func() {
+			runGit(sourcePath, "branch", "feature_2", firstCommitID)
+			options.ExcludeRefs = append(options.ExcludeRefs, "refs/heads/feature_*")
+			Expect(worker.Fetch(ctx, targetPath, nil, options)).To(Succeed())
+			Expect(runGit(targetPath, "for-each-ref", "--format=%(refname)", "refs/heads/")).To(Equal("refs/heads/master"))
+			Expect(runGit(targetPath, "config", "remote.origin.partialCloneFilter")).To(Equal("blob:limit=1"))
+		})
+
+		When("only some refs are backed up since a date", func() {
+			BeforeEach(func() {
+				options = git.FetchOptions{
+					Refs:         []string{"refs/heads/master"},
+					ShallowSince: time.Date(2025, 7, 3, 0, 40, 0, 0, time.UTC),
+				}
+			})
+
+			It("fetches only the recent history of the refs", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(runGit(targetPath, "for-each-ref", "--format=%(refname)")).To(Equal("refs/heads/master"))
+				Expect(runGit(targetPath, "rev-list", "--all")).To(Equal(secondCommitID))
+				Expect(targetPath + "/shallow").To(BeAnExistingFile())
+				Expect(worker.Verify(ctx, targetPath, true)).To(Succeed())
+			})
+		})
+
+		When("source is unavailable", func() {
+			BeforeEach(func() {
+				clearSource()
+			})
+
+			It("removes the folder", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(targetPath).NotTo(BeADirectory())
+			})
+		})
+	})
+
+	Context("Push", func() {
+		var remotePath string
+
+		revParse := func(ref string) string {
+			var output strings.Builder
+			err := cmd.Execute(
+				ctx,
+				"git",
+				cmd.WithArguments("-C", remotePath, "rev-parse", ref),
+				cmd.WithStdoutWriter(&output),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			return strings.TrimSpace(output.String())
+		}
+
+		BeforeEach(func() {
+			unzipArchiveToSource(secondCommitArchive)
+			Expect(worker.Clone(ctx, sourcePath, targetPath, nil, git.FetchOptions{})).To(Succeed())
+			remotePath = mkdirTemp("remote")
+			DeferCleanup(rmdir, remotePath)
+			Expect(cmd.Execute(ctx, "git", cmd.WithArguments("init", "--bare", remotePath))).To(Succeed())
+		})
+
+		It("pushes all refs", func() {
+			Expect(worker.PushMirror(ctx, targetPath, remotePath, nil)).To(Succeed())
+			Expect(revParse("HEAD")).To(Equal(secondCommitID))
+		})
+
+		It("pushes refs to the objects from a snapshot", func() {
+			refs := map[string]string{"refs/heads/restored": firstCommitID}
+			Expect(worker.PushRefs(ctx, targetPath, remotePath, refs, nil)).To(Succeed())
+			Expect(revParse("refs/heads/restored")).To(Equal(firstCommitID))
+		})
+
+		When("the remote is unavailable", func() {
+			It("returns an error", func() {
+				Expect(worker.PushMirror(ctx, targetPath, remotePath+"/missing", nil)).NotTo(Succeed())
+			})
+		})
+	})
+
+	Context("Bundle", func() {
+		BeforeEach(func() {
+			unzipArchiveToSource(secondCommitArchive)
+			Expect(worker.Clone(ctx, sourcePath, targetPath, nil, git.FetchOptions{})).To(Succeed())
+		})
+
+		It("returns the refs", func() {
+			refs, err := worker.Refs(ctx, targetPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(refs).NotTo(BeEmpty())
+			Expect(refs).To(HaveEach(secondCommitID))
+		})
+
+		It("creates full and incremental bundles", func() {
+			for _, prerequisites := range [][]string{nil, {firstCommitID}} {
+				fileName := fmt.Sprintf("%v/%v.bundle", sourcePath, len(prerequisites))
+				file, err := os.Create(fileName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(worker.CreateBundle(ctx, targetPath, file, prerequisites)).To(Succeed())
+				Expect(file.Close()).To(Succeed())
+				Expect(cmd.Execute(ctx, "git", cmd.WithArguments("-C", targetPath, "bundle", "verify", "--quiet", fileName))).To(Succeed())
+			}
+		})
+
+		It("restores a repository from a bundle", func() {
+			fileName := sourcePath + "/full.bundle"
+			file, err := os.Create(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(worker.CreateBundle(ctx, targetPath, file, nil)).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			restoredPath := mkdirTemp("restored")
+			DeferCleanup(rmdir, restoredPath)
+			Expect(worker.InitBare(ctx, restoredPath)).To(Succeed())
+			Expect(worker.FetchBundle(ctx, restoredPath, fileName)).To(Succeed())
+
+			original, err := worker.Refs(ctx, targetPath)
+			Expect(err).NotTo(HaveOccurred())
+			restored, err := worker.Refs(ctx, restoredPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).To(Equal(original))
+		})
+	})
+
+	Context("Maintain", func() {
+		var unreachable string
+
+		runGit := func(args ...string) string {
+			var output strings.Builder
+			err := cmd.Execute(ctx, "git", cmd.WithArguments(append([]string{"-C", targetPath}, args...)...), cmd.WithStdoutWriter(&output))
+			Expect(err).NotTo(HaveOccurred())
+
+			return strings.TrimSpace(output.String())
+		}
+
+		exists := func(object string) bool {
+			return cmd.Execute(ctx, "git", cmd.WithArguments("-C", targetPath, "cat-file", "-e", object)) == nil
+		}
+
+		BeforeEach(func() {
+			unzipArchiveToSource(secondCommitArchive)
+			Expect(worker.Clone(ctx, sourcePath, targetPath, nil, git.FetchOptions{})).To(Succeed())
+
+			// An object left behind by a force push is an old unreachable loose object.
+			var output strings.Builder
+			err := cmd.Execute(
+				ctx,
+				"git",
+				cmd.WithArguments("-C", targetPath, "hash-object", "-w", "--stdin"),
+				cmd.WithStdinReader(strings.NewReader("force-pushed away")),
+				cmd.WithStdoutWriter(&output),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			unreachable = strings.TrimSpace(output.String())
+			old := time.Now().Add(-time.Hour)
+			Expect(os.Chtimes(fmt.Sprintf("%v/objects/%v/%v", targetPath, unreachable[:2], unreachable[2:]), old, old)).To(Succeed())
+		})
+
+		It("runs the tasks", func() {
+			tasks := []string{"gc", "commit-graph", "incremental-repack", "multi-pack-index"}
+			Expect(worker.Maintain(ctx, targetPath, tasks, 14*24*time.Hour, nil)).To(Succeed())
+
+			Expect(runGit("config", "gc.auto")).To(Equal("0"))
+			commitGraphs := []string{targetPath + "/objects/info/commit-graph", targetPath + "/objects/info/commit-graphs/commit-graph-chain"}
+			Expect(commitGraphs).To(ContainElement(BeAnExistingFile()))
+			Expect(targetPath + "/objects/pack/multi-pack-index").To(BeAnExistingFile())
+			Expect(exists(unreachable)).To(BeTrue())
+			Expect(worker.Verify(ctx, targetPath, false)).To(Succeed())
+		})
+
+		It("prunes unreachable objects older than the prune window", func() {
+			Expect(worker.Maintain(ctx, targetPath, []string{"gc"}, time.Minute, nil)).To(Succeed())
+
+			Expect(exists(unreachable)).To(BeFalse())
+		})
+
+		It("keeps objects which are still referenced", func() {
+			keep := []string{unreachable, strings.Repeat("1", 40)}
+			Expect(worker.Maintain(ctx, targetPath, []string{"gc"}, time.Minute, keep)).To(Succeed())
+
+			Expect(exists(unreachable)).To(BeTrue())
+			Expect(runGit("for-each-ref", "refs/git-backups/")).To(BeEmpty())
+		})
+	})
 })
@@ -14,9 +14,16 @@ const (
 )
 
 type Repo struct {
-	Name   string
-	Owner  string
-	SSHURL string
+	Name     string
+	Owner    string
+	SSHURL   string
+	Fork     bool
+	Archived bool
+	Private  bool
+	// Size is the size of the repository in bytes as reported by GitHub.
+	Size     int64
+	Language string
+	Topics   []string
 }
 
 type jsonRepo struct {
@@ -24,8 +31,14 @@ type jsonRepo struct {
 	Owner struct {
 		Login string `json:"login"`
 	} `json:"owner"`
-	CloneURL string `json:"clone_url"`
-	SSHURL   string `json:"ssh_url"`
+	CloneURL string   `json:"clone_url"`
+	SSHURL   string   `json:"ssh_url"`
+	Fork     bool     `json:"fork"`
+	Archived bool     `json:"archived"`
+	Private  bool     `json:"private"`
+	Size     int64    `json:"size"`
+	Language string   `json:"language"`
+	Topics   []string `json:"topics"`
 }
 
 type Reader struct {
@@ -42,7 +55,7 @@ func (r Reader) AllRepos(ctx context.Context, token, affiliation string) iter.Se
 			}
 
 			for _, repo := range repos {
-				if !yield(Repo{Name: repo.Name, Owner: repo.Owner.Login, SSHURL: repo.SSHURL}, nil) {
+				if !yield(repo.transform(), nil) {
 					return
 				}
 			}
@@ -54,6 +67,20 @@ func (r Reader) AllRepos(ctx context.Context, token, affiliation string) iter.Se
 	}
 }
 
+func (r jsonRepo) transform() Repo {
+	return Repo{
+		Name:     r.Name,
+		Owner:    r.Owner.Login,
+		SSHURL:   r.SSHURL,
+		Fork:     r.Fork,
+		Archived: r.Archived,
+		Private:  r.Private,
+		Size:     r.Size * 1024, // GitHub reports the size in kilobytes.
+		Language: r.Language,
+		Topics:   r.Topics,
+	}
+}
+
 func readPage(ctx context.Context, affiliation string, token string, page int) ([]jsonRepo, error) {
 	client := http.Client{}
 	url := fmt.Sprintf("https://api.github.com/user/repos?affiliation=%v&per_page=%v&page=%v", affiliation, pageSize, page)
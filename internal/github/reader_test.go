@@ -3,7 +3,6 @@ package github_test
 import (
 	"fmt"
 	"iter"
-	"maps"
 	"net/http"
 	"strings"
 
@@ -30,26 +29,60 @@ var _ = Describe("Reader tests", func() {
 	})
 
 	It("correctly reads one page of repositories", func() {
-		repos := maps.Collect(allRepos)
-		Expect(repos).To(Equal(map[github.Repo]error{
+		repos, errs := collect(allRepos)
+		Expect(errs).To(BeEmpty())
+		Expect(repos).To(Equal([]github.Repo{
 			{
 				Name:   "Repo1Name",
 				Owner:  "User",
 				SSHURL: "git:github.com/repo-owner1/hello-world.git",
-			}: nil,
+			},
 			{
 				Name:   "Repo2Name",
 				Owner:  "User",
 				SSHURL: "git:github.com/repo-owner2/hello-world.git",
-			}: nil,
+			},
 			{
 				Name:   "Repo3Name",
 				Owner:  "User",
 				SSHURL: "git:github.com/repo-owner3/hello-world.git",
-			}: nil,
+			},
 		}))
 	})
 
+	When("repositories have attributes", func() {
+		BeforeEach(func() {
+			responder := httpmock.NewStringResponder(http.StatusOK, `[{
+				"name": "RepoName",
+				"owner": {"login": "User"},
+				"ssh_url": "git:github.com/User/RepoName.git",
+				"fork": true,
+				"archived": true,
+				"private": true,
+				"size": 2048,
+				"language": "Go",
+				"topics": ["backup", "git"]
+			}]`)
+			httpmock.RegisterResponder(http.MethodGet, getRepositoriesURL(1), responder)
+		})
+
+		It("reads the attributes", func() {
+			repos, errs := collect(allRepos)
+			Expect(errs).To(BeEmpty())
+			Expect(repos).To(Equal([]github.Repo{{
+				Name:     "RepoName",
+				Owner:    "User",
+				SSHURL:   "git:github.com/User/RepoName.git",
+				Fork:     true,
+				Archived: true,
+				Private:  true,
+				Size:     2 * 1024 * 1024,
+				Language: "Go",
+				Topics:   []string{"backup", "git"},
+			}}))
+		})
+	})
+
 	When("an unexpected code is returned", func() {
 		BeforeEach(func() {
 			responder := httpmock.NewStringResponder(http.StatusBadRequest, "")
@@ -57,9 +90,10 @@ var _ = Describe("Reader tests", func() {
 		})
 
 		It("returns an error", func() {
-			repos := maps.Collect(allRepos)
-			Expect(repos).To(HaveLen(1))
-			Expect(repos[github.Repo{}].Error()).To(ContainSubstring("unexpected status code: 400 (400 Bad Request)"))
+			repos, errs := collect(allRepos)
+			Expect(repos).To(Equal([]github.Repo{{}}))
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0].Error()).To(ContainSubstring("unexpected status code: 400 (400 Bad Request)"))
 		})
 	})
 
@@ -70,9 +104,10 @@ var _ = Describe("Reader tests", func() {
 		})
 
 		It("returns an error", func() {
-			repos := maps.Collect(allRepos)
-			Expect(repos).To(HaveLen(1))
-			Expect(repos[github.Repo{}]).To(MatchError("invalid character 'I' looking for beginning of value"))
+			repos, errs := collect(allRepos)
+			Expect(repos).To(Equal([]github.Repo{{}}))
+			Expect(errs).To(HaveLen(1))
+			Expect(errs[0]).To(MatchError("invalid character 'I' looking for beginning of value"))
 		})
 	})
 
@@ -104,6 +139,17 @@ var _ = Describe("Reader tests", func() {
 	})
 })
 
+func collect(allRepos iter.Seq2[github.Repo, error]) (repos []github.Repo, errs []error) {
+	for repo, err := range allRepos {
+		repos = append(repos, repo)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return repos, errs
+}
+
 func getRepositoriesURL(page int) string {
 	return fmt.Sprintf("https://api.github.com/user/repos?affiliation=owner&per_page=100&page=%v", page)
 }
@@ -0,0 +1,95 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const apiURL = "https://api.github.com"
+
+type Writer struct {
+}
+
+// CreateRepo creates a repository in the organization, or for the authenticated user if the owner is
+// empty or is the user. An existing repository is returned as is, so that restores can be repeated.
+func (w Writer) CreateRepo(ctx context.Context, token, owner, name string, private bool) (Repo, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := send(ctx, http.MethodGet, apiURL+"/user", token, nil, http.StatusOK, &user); err != nil {
+		return Repo{}, fmt.Errorf("failed to read the authenticated user: %w", err)
+	}
+
+	url := fmt.Sprintf("%v/orgs/%v/repos", apiURL, owner)
+	if owner == "" || owner == user.Login {
+		owner, url = user.Login, apiURL+"/user/repos"
+	}
+
+	request := struct {
+		Name    string `json:"name"`
+		Private bool   `json:"private"`
+		HasWiki bool   `json:"has_wiki"`
+	}{Name: name, Private: private, HasWiki: true}
+
+	var repo jsonRepo
+	err := send(ctx, http.MethodPost, url, token, request, http.StatusCreated, &repo)
+	var statusErr statusError
+	if errors.As(err, &statusErr) && statusErr.code == http.StatusUnprocessableEntity {
+		err = send(ctx, http.MethodGet, fmt.Sprintf("%v/repos/%v/%v", apiURL, owner, name), token, nil, http.StatusOK, &repo)
+	}
+	if err != nil {
+		return Repo{}, fmt.Errorf("failed to create repository %v/%v: %w", owner, name, err)
+	}
+
+	return repo.transform(), nil
+}
+
+type statusError struct {
+	code   int
+	status string
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %v (%v)", e.code, e.status)
+}
+
+func send(ctx context.Context, method, url, token string, body any, expectedStatus int, response any) error {
+	var requestBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(data)
+	}
+
+	client := http.Client{}
+	req, err := http.NewRequestWithContext(ctx, method, url, requestBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %v", token))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != expectedStatus {
+		return statusError{code: res.StatusCode, status: res.Status}
+	}
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, response)
+}
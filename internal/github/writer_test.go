@@ -0,0 +1,71 @@
+package github_test
+
+import (
+	"net/http"
+
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/jarcoal/httpmock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Writer tests", func() {
+	const createdRepo = `{"name": "hello", "owner": {"login": "org"}, "ssh_url": "git@github.com:org/hello.git", "private": true}`
+
+	var (
+		owner string
+		repo  github.Repo
+		err   error
+	)
+
+	BeforeEach(func() {
+		owner = "org"
+		httpmock.RegisterResponder(http.MethodGet, "https://api.github.com/user", httpmock.NewStringResponder(http.StatusOK, `{"login": "me"}`))
+		httpmock.RegisterResponder(http.MethodPost, "https://api.github.com/orgs/org/repos", httpmock.NewStringResponder(http.StatusCreated, createdRepo))
+	})
+
+	JustBeforeEach(func() {
+		repo, err = github.Writer{}.CreateRepo(ctx, "GH_XXX", owner, "hello", true)
+	})
+
+	It("creates the repository in the organization", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(repo).To(Equal(github.Repo{Name: "hello", Owner: "org", SSHURL: "git@github.com:org/hello.git", Private: true}))
+	})
+
+	When("the owner is the authenticated user", func() {
+		BeforeEach(func() {
+			owner = "me"
+			responder := httpmock.NewStringResponder(http.StatusCreated, `{"name": "hello", "owner": {"login": "me"}, "ssh_url": "git@github.com:me/hello.git"}`)
+			httpmock.RegisterResponder(http.MethodPost, "https://api.github.com/user/repos", responder)
+		})
+
+		It("creates the repository for the user", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repo.SSHURL).To(Equal("git@github.com:me/hello.git"))
+		})
+	})
+
+	When("the repository exists", func() {
+		BeforeEach(func() {
+			responder := httpmock.NewStringResponder(http.StatusUnprocessableEntity, `{"message": "Repository creation failed."}`)
+			httpmock.RegisterResponder(http.MethodPost, "https://api.github.com/orgs/org/repos", responder)
+			httpmock.RegisterResponder(http.MethodGet, "https://api.github.com/repos/org/hello", httpmock.NewStringResponder(http.StatusOK, createdRepo))
+		})
+
+		It("returns the existing repository", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repo.SSHURL).To(Equal("git@github.com:org/hello.git"))
+		})
+	})
+
+	When("the token is invalid", func() {
+		BeforeEach(func() {
+			httpmock.RegisterResponder(http.MethodGet, "https://api.github.com/user", httpmock.NewStringResponder(http.StatusUnauthorized, `{}`))
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring("401")))
+		})
+	})
+})
@@ -0,0 +1,180 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/notify"
+)
+
+const pingTimeout = 10 * time.Second
+
+type event string
+
+const (
+	eventStart   event = "start"
+	eventSuccess event = "success"
+	eventFail    event = "fail"
+)
+
+// suffix is appended to the check URL. Success is reported to the URL itself.
+func (e event) suffix() string {
+	if e == eventSuccess {
+		return ""
+	}
+
+	return "/" + string(e)
+}
+
+type check struct {
+	// profile is empty for the global check which covers the whole run.
+	profile string
+	url     string
+}
+
+// Pinger pings the configured URLs following the Healthchecks.io protocol: <url>/start when the run or
+// the profile starts, <url> on success and <url>/fail on failure. Failures to ping are logged and
+// don't affect the run.
+type Pinger struct {
+	client *http.Client
+	global *check
+	checks map[string]check
+
+	mutex   sync.Mutex
+	started map[string]bool
+}
+
+// New returns nil if the config has no healthcheck URLs.
+func New(conf config.Config) *Pinger {
+	p := &Pinger{client: http.DefaultClient, checks: map[string]check{}, started: map[string]bool{}}
+	if conf.Healthcheck.URL != "" {
+		p.global = &check{url: conf.Healthcheck.URL}
+	}
+
+	for _, profile := range conf.Profiles.GenericProfiles {
+		p.addProfile(profile.Name, profile.Healthcheck)
+	}
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		p.addProfile(profile.Name, profile.Healthcheck)
+	}
+
+	if p.global == nil && len(p.checks) == 0 {
+		return nil
+	}
+
+	return p
+}
+
+func (p *Pinger) addProfile(profile string, conf config.Healthcheck) {
+	if conf.URL != "" {
+		p.checks[profile] = check{profile: profile, url: conf.URL}
+	}
+}
+
+func (p *Pinger) RunStarted(ctx context.Context, started time.Time) {
+	p.mutex.Lock()
+	clear(p.started)
+	p.mutex.Unlock()
+
+	if p.global != nil {
+		p.ping(ctx, *p.global, eventStart, "")
+	}
+}
+
+func (p *Pinger) ProfileStarted(ctx context.Context, profile string) {
+	c, ok := p.checks[profile]
+	if !ok {
+		return
+	}
+
+	p.mutex.Lock()
+	p.started[profile] = true
+	p.mutex.Unlock()
+
+	p.ping(ctx, c, eventStart, "")
+}
+
+func (p *Pinger) TargetFinished(ctx context.Context, result launcher.TargetResult) {}
+
+func (p *Pinger) RunFinished(ctx context.Context, summary launcher.Summary) {
+	// The next run will start the checks again, so the interrupted one isn't reported as a failure.
+	if notify.Interrupted(summary) {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if p.global != nil {
+		p.finish(ctx, *p.global, notify.NewReport(summary, nil))
+	}
+
+	p.mutex.Lock()
+	var started []check
+	for profile := range p.started {
+		started = append(started, p.checks[profile])
+	}
+	p.mutex.Unlock()
+
+	for _, c := range started {
+		p.finish(ctx, c, notify.NewReport(summary, []string{c.profile}))
+	}
+}
+
+func (p *Pinger) finish(ctx context.Context, c check, report notify.Report) {
+	if !report.Failed() {
+		p.ping(ctx, c, eventSuccess, "")
+		return
+	}
+
+	p.ping(ctx, c, eventFail, report.Title()+"\n\n"+report.Text())
+}
+
+func (p *Pinger) ping(ctx context.Context, c check, e event, body string) {
+	if c.profile != "" {
+		ctx = clog.Add(ctx, "profile", c.profile)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	if err := p.send(ctx, c.url, e.suffix(), body); err != nil {
+		slog.WarnContext(ctx, "Failed to ping the healthcheck", "event", e, "error", err)
+	}
+}
+
+func (p *Pinger) send(ctx context.Context, baseURL, suffix, body string) error {
+	pingURL, err := url.Parse(baseURL)
+	if err != nil {
+		return err
+	}
+	pingURL.Path = strings.TrimSuffix(pingURL.Path, "/") + suffix
+	pingURL.RawPath = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pingURL.String(), strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("healthcheck responded with status %v", resp.Status)
+	}
+
+	return nil
+}
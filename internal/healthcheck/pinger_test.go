@@ -0,0 +1,156 @@
+package healthcheck_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/healthcheck"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Pinger tests", func() {
+	type ping struct {
+		Path string
+		Body string
+	}
+
+	var (
+		server *httptest.Server
+		mutex  sync.Mutex
+		pings  []ping
+		conf   config.Config
+	)
+
+	var received = func() []ping {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return append([]ping(nil), pings...)
+	}
+
+	var summary = func(err error) launcher.Summary {
+		return launcher.Summary{
+			Started:  time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Duration: time.Minute,
+			Targets: []launcher.TargetResult{
+				{Target: launcher.Target{Profile: "generic", URL: "https://example.com/repo_1.git", Path: "/backups/repo_1"}},
+				{Target: launcher.Target{Profile: "github", URL: "git@github.com:owner/repo_2.git", Path: "/backups/repo_2"}, Err: err},
+			},
+			Err: err,
+		}
+	}
+
+	var run = func(summary launcher.Summary, profiles ...string) {
+		pinger := healthcheck.New(conf)
+		Expect(pinger).NotTo(BeNil())
+
+		pinger.RunStarted(ctx, summary.Started)
+		for _, profile := range profiles {
+			pinger.ProfileStarted(ctx, profile)
+		}
+		pinger.RunFinished(ctx, summary)
+	}
+
+	BeforeEach(func() {
+		pings = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mutex.Lock()
+			pings = append(pings, ping{Path: r.URL.Path, Body: string(body)})
+			mutex.Unlock()
+		}))
+		DeferCleanup(server.Close)
+
+		conf = config.Config{
+			Healthcheck: config.Healthcheck{URL: server.URL + "/ping/global"},
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{
+					{Name: "generic", Healthcheck: config.Healthcheck{URL: server.URL + "/ping/generic"}},
+				},
+				GitHubProfiles: []config.GitHubProfile{
+					{Name: "github", Healthcheck: config.Healthcheck{URL: server.URL + "/ping/github"}},
+				},
+			},
+		}
+	})
+
+	It("is not created without healthcheck URLs", func() {
+		Expect(healthcheck.New(config.Config{})).To(BeNil())
+	})
+
+	It("pings the start and the success of the run and the profiles", func() {
+		run(summary(nil), "generic", "github")
+
+		Expect(received()).To(ConsistOf(
+			ping{Path: "/ping/global/start"},
+			ping{Path: "/ping/generic/start"},
+			ping{Path: "/ping/github/start"},
+			ping{Path: "/ping/global"},
+			ping{Path: "/ping/generic"},
+			ping{Path: "/ping/github"},
+		))
+	})
+
+	It("pings the failure with the summary in the body", func() {
+		run(summary(errors.New("remote: Repository not found.")), "generic", "github")
+
+		pings := received()
+		Expect(pings).To(ContainElements(ping{Path: "/ping/global/start"}, ping{Path: "/ping/generic"}))
+		Expect(pings).NotTo(ContainElement(HaveField("Path", "/ping/github")))
+
+		var fails []ping
+		for _, p := range pings {
+			if p.Path == "/ping/global/fail" || p.Path == "/ping/github/fail" {
+				fails = append(fails, p)
+			}
+		}
+		Expect(fails).To(HaveLen(2))
+		for _, fail := range fails {
+			Expect(fail.Body).To(HavePrefix("Git backups failed: 1 of"))
+			Expect(fail.Body).To(ContainSubstring("github: git@github.com:owner/repo_2.git"))
+		}
+	})
+
+	It("pings only the profiles which were processed", func() {
+		run(summary(nil), "generic")
+
+		Expect(received()).NotTo(ContainElement(HaveField("Path", HavePrefix("/ping/github"))))
+	})
+
+	It("doesn't ping the end of an interrupted run", func() {
+		run(summary(context.Canceled), "generic")
+
+		Expect(received()).To(ConsistOf(ping{Path: "/ping/global/start"}, ping{Path: "/ping/generic/start"}))
+	})
+
+	It("keeps the query of the URL", func() {
+		var query string
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query = r.URL.RawQuery
+		})
+		conf = config.Config{Healthcheck: config.Healthcheck{URL: server.URL + "/ping?create=1"}}
+
+		healthcheck.New(conf).RunStarted(ctx, time.Now())
+
+		Expect(query).To(Equal("create=1"))
+	})
+
+	It("ignores unreachable and failing endpoints", func() {
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		unreachable := httptest.NewServer(http.NotFoundHandler())
+		unreachable.Close()
+		conf.Profiles.GenericProfiles[0].Healthcheck.URL = unreachable.URL
+
+		Expect(func() { run(summary(nil), "generic", "github") }).NotTo(Panic())
+	})
+})
@@ -0,0 +1,156 @@
+package history_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/history"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/report"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Journal tests", func() {
+	var (
+		rootFolder string
+		conf       config.Config
+		journal    *history.Journal
+		started    time.Time
+	)
+
+	var backupTarget = func(name string, offset time.Duration, err error) {
+		journal.TargetFinished(ctx, launcher.TargetResult{
+			Target:   launcher.Target{Profile: "profile", URL: "https://example.com/" + name + ".git", Path: filepath.Join(rootFolder, name)},
+			Started:  started.Add(offset),
+			Duration: time.Minute,
+			Backup:   backup.Result{Action: backup.ActionFetch},
+			Err:      err,
+		})
+	}
+
+	BeforeEach(func() {
+		rootFolder = GinkgoT().TempDir()
+		started = time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{Name: "profile", RootFolder: rootFolder}},
+			},
+		}
+		journal = history.NewJournal(conf)
+
+		backupTarget("repo_1", 0, nil)
+		backupTarget("repo_2", 0, errors.New("remote: Repository not found."))
+		backupTarget("repo_1", 24*time.Hour, errors.New("Connection timed out"))
+		backupTarget("repo_2", 24*time.Hour, errors.New("Connection timed out"))
+		backupTarget("repo_1", 48*time.Hour, errors.New("Connection timed out"))
+	})
+
+	It("appends every attempt to the journal", func() {
+		entries, err := history.Read(rootFolder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(5))
+		Expect(entries[0].Path).To(Equal(filepath.Join(rootFolder, "repo_1")))
+		Expect(entries[0].Status).To(Equal(report.StatusSuccess))
+		Expect(entries[1].ErrorClass).To(Equal(string(backup.ErrorClassNotFound)))
+	})
+
+	It("skips lines which can't be parsed", func() {
+		file, err := os.OpenFile(filepath.Join(rootFolder, history.FileName), os.O_APPEND|os.O_WRONLY, 0)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = file.WriteString(`{"profile": "profile", "pa`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		entries, err := history.Read(rootFolder)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(5))
+	})
+
+	It("returns no entries without a journal", func() {
+		entries, err := history.Read(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("ignores missing root folders", func() {
+		rootFolder = filepath.Join(rootFolder, "missing")
+
+		Expect(func() { backupTarget("repo_1", 0, nil) }).NotTo(Panic())
+		Expect(filepath.Join(rootFolder, history.FileName)).NotTo(BeAnExistingFile())
+	})
+
+	It("summarizes the status of every repository", func() {
+		entries, err := history.Read(rootFolder)
+		Expect(err).NotTo(HaveOccurred())
+
+		statuses := history.Statuses(entries, started.Add(49*time.Hour), 36*time.Hour)
+		Expect(statuses).To(Equal([]history.RepoStatus{
+			{
+				Profile:             "profile",
+				URL:                 "https://example.com/repo_1.git",
+				Path:                filepath.Join(rootFolder, "repo_1"),
+				LastAttempt:         started.Add(48*time.Hour + time.Minute),
+				LastSuccess:         started.Add(time.Minute),
+				LastFailure:         started.Add(48*time.Hour + time.Minute),
+				LastErrorClass:      string(backup.ErrorClassNetwork),
+				ConsecutiveFailures: 2,
+				Stale:               true,
+			},
+			{
+				Profile:             "profile",
+				URL:                 "https://example.com/repo_2.git",
+				Path:                filepath.Join(rootFolder, "repo_2"),
+				LastAttempt:         started.Add(24*time.Hour + time.Minute),
+				LastFailure:         started.Add(24*time.Hour + time.Minute),
+				LastErrorClass:      string(backup.ErrorClassNetwork),
+				ConsecutiveFailures: 2,
+				Stale:               true,
+			},
+		}))
+	})
+
+	It("resets the failure count after a success", func() {
+		backupTarget("repo_1", 72*time.Hour, nil)
+		entries, err := history.Read(rootFolder)
+		Expect(err).NotTo(HaveOccurred())
+
+		statuses := history.Statuses(entries, started.Add(73*time.Hour), 36*time.Hour)
+		Expect(statuses[0].ConsecutiveFailures).To(BeZero())
+		Expect(statuses[0].LastErrorClass).To(BeEmpty())
+		Expect(statuses[0].Stale).To(BeFalse())
+	})
+
+	When("the run finishes", func() {
+		BeforeEach(func() {
+			journal = history.NewJournal(conf, history.WithMaxEntriesPerRepo(1))
+			backupTarget("repo_2", 72*time.Hour, errors.New("Connection timed out"))
+			journal.RunFinished(ctx, launcher.Summary{})
+		})
+
+		It("keeps the latest entries and the latest success of every repository", func() {
+			entries, err := history.Read(rootFolder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[0].Path).To(Equal(filepath.Join(rootFolder, "repo_1")))
+			Expect(entries[0].Status).To(Equal(report.StatusSuccess))
+			Expect(entries[1].Path).To(Equal(filepath.Join(rootFolder, "repo_1")))
+			Expect(entries[1].Started).To(Equal(started.Add(48 * time.Hour)))
+			Expect(entries[2].Path).To(Equal(filepath.Join(rootFolder, "repo_2")))
+			Expect(entries[2].Started).To(Equal(started.Add(72 * time.Hour)))
+		})
+
+		It("appends new entries to the trimmed journal", func() {
+			backupTarget("repo_1", 96*time.Hour, nil)
+
+			entries, err := history.Read(rootFolder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(4))
+			Expect(entries[3].Started).To(Equal(started.Add(96 * time.Hour)))
+		})
+	})
+})
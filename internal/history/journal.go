@@ -0,0 +1,184 @@
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/fsutil"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/report"
+)
+
+// FileName is the name of the journal in the root folder of every profile.
+const FileName = ".git-backups-history.jsonl"
+
+const defaultMaxEntriesPerRepo = 100
+
+// Journal appends every backup attempt to the journal in the root folder of its profile, and drops
+// the oldest entries of every repository at the end of the run. Failures to write are logged and
+// don't affect the run.
+type Journal struct {
+	mutex             sync.Mutex
+	rootFolders       map[string]string
+	updated           map[string]bool
+	maxEntriesPerRepo int
+}
+
+type JournalOption func(*Journal)
+
+// WithMaxEntriesPerRepo sets how many of the latest entries of every repository are kept. The latest
+// success is kept in addition, so that the status still shows it.
+func WithMaxEntriesPerRepo(n int) JournalOption {
+	return func(j *Journal) {
+		j.maxEntriesPerRepo = n
+	}
+}
+
+func NewJournal(conf config.Config, options ...JournalOption) *Journal {
+	j := &Journal{
+		rootFolders:       map[string]string{},
+		updated:           map[string]bool{},
+		maxEntriesPerRepo: defaultMaxEntriesPerRepo,
+	}
+	for _, profile := range conf.Profiles.GenericProfiles {
+		j.rootFolders[profile.Name] = profile.RootFolder
+	}
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		j.rootFolders[profile.Name] = profile.RootFolder
+	}
+	for _, option := range options {
+		option(j)
+	}
+
+	return j
+}
+
+func (j *Journal) RunStarted(ctx context.Context, started time.Time) {}
+
+func (j *Journal) ProfileStarted(ctx context.Context, profile string) {}
+
+func (j *Journal) TargetFinished(ctx context.Context, result launcher.TargetResult) {
+	rootFolder, ok := j.rootFolders[result.Target.Profile]
+	if !ok {
+		return
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if err := appendEntry(rootFolder, report.NewTarget(result)); err != nil {
+		slog.WarnContext(ctx, "Failed to update the history", "error", err)
+		return
+	}
+	j.updated[rootFolder] = true
+}
+
+// RunFinished trims the journals once per run rather than on every append, which would rewrite them
+// for every repository.
+func (j *Journal) RunFinished(ctx context.Context, summary launcher.Summary) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	for rootFolder := range j.updated {
+		if err := trim(rootFolder, j.maxEntriesPerRepo); err != nil {
+			slog.WarnContext(ctx, "Failed to trim the history", "root_folder", rootFolder, "error", err)
+		}
+	}
+	clear(j.updated)
+}
+
+func appendEntry(rootFolder string, entry report.Target) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// The root folder is created by git, so a missing one means there is nothing to keep a history of.
+	file, err := os.OpenFile(filepath.Join(rootFolder, FileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(line, '\n'))
+
+	return errors.Join(err, file.Close())
+}
+
+// trim keeps the latest maxEntries entries and the latest success of every repository. The journal is
+// replaced atomically, so that a crash never loses the entries which are kept.
+func trim(rootFolder string, maxEntries int) error {
+	entries, err := Read(rootFolder)
+	if err != nil {
+		return err
+	}
+
+	type key struct{ profile, path string }
+	counts := map[key]int{}
+	hasSuccess := map[key]bool{}
+	keep := make([]bool, len(entries))
+	kept := 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		k := key{profile: entries[i].Profile, path: entries[i].Path}
+		counts[k]++
+		lastSuccess := entries[i].Status == report.StatusSuccess && !hasSuccess[k]
+		hasSuccess[k] = hasSuccess[k] || entries[i].Status == report.StatusSuccess
+		if counts[k] <= maxEntries || lastSuccess {
+			keep[i] = true
+			kept++
+		}
+	}
+	if kept == len(entries) {
+		return nil
+	}
+
+	return fsutil.WriteAtomically(filepath.Join(rootFolder, FileName), func(w io.Writer) error {
+		writer := bufio.NewWriter(w)
+		encoder := json.NewEncoder(writer)
+		for i, entry := range entries {
+			if keep[i] {
+				if err := encoder.Encode(entry); err != nil {
+					return err
+				}
+			}
+		}
+
+		return writer.Flush()
+	})
+}
+
+// Read returns the entries of the journal in the root folder. Lines which can't be parsed, e.g. the
+// last line written during a crash, are skipped.
+func Read(rootFolder string) ([]report.Target, error) {
+	file, err := os.Open(filepath.Join(rootFolder, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []report.Target
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry report.Target
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
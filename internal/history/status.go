@@ -0,0 +1,72 @@
+package history
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/report"
+)
+
+// RepoStatus summarizes the journal entries of a repository.
+type RepoStatus struct {
+	Profile     string    `json:"profile"`
+	URL         string    `json:"url"`
+	Path        string    `json:"path"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	LastFailure time.Time `json:"last_failure,omitzero"`
+	// LastErrorClass is the failure reason of the last attempt if it failed.
+	LastErrorClass      string `json:"last_error_class,omitempty"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	// Stale is set if the repository wasn't backed up successfully within the threshold.
+	Stale bool `json:"stale"`
+}
+
+// Statuses returns the status of every repository in the entries sorted by profile and path.
+func Statuses(entries []report.Target, now time.Time, staleAfter time.Duration) []RepoStatus {
+	type key struct{ profile, path string }
+	statuses := map[key]*RepoStatus{}
+
+	slices.SortStableFunc(entries, func(a, b report.Target) int {
+		return a.Started.Compare(b.Started)
+	})
+
+	for _, entry := range entries {
+		if entry.Status != report.StatusSuccess && entry.Status != report.StatusFailure {
+			continue
+		}
+
+		k := key{profile: entry.Profile, path: entry.Path}
+		status, ok := statuses[k]
+		if !ok {
+			status = &RepoStatus{Profile: entry.Profile, Path: entry.Path}
+			statuses[k] = status
+		}
+
+		finished := entry.Started.Add(time.Duration(entry.DurationSeconds * float64(time.Second)))
+		status.URL = entry.URL
+		status.LastAttempt = finished
+		if entry.Status == report.StatusSuccess {
+			status.LastSuccess = finished
+			status.LastErrorClass = ""
+			status.ConsecutiveFailures = 0
+		} else {
+			status.LastFailure = finished
+			status.LastErrorClass = entry.ErrorClass
+			status.ConsecutiveFailures++
+		}
+	}
+
+	result := make([]RepoStatus, 0, len(statuses))
+	for _, status := range statuses {
+		status.Stale = status.LastSuccess.IsZero() || now.Sub(status.LastSuccess) > staleAfter
+		result = append(result, *status)
+	}
+
+	slices.SortFunc(result, func(a, b RepoStatus) int {
+		return cmp.Or(cmp.Compare(a.Profile, b.Profile), cmp.Compare(a.Path, b.Path))
+	})
+
+	return result
+}
@@ -0,0 +1,106 @@
+package launcher
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+)
+
+// diskGuard keeps the file system of the root folder of a profile from filling up.
+type diskGuard struct {
+	config.Disk
+	rootFolder string
+}
+
+func (g diskGuard) enabled() bool {
+	return g.MinFree > 0 || g.MinFreePercent > 0
+}
+
+// check returns an error if less than the required free space would be left after cloning a repository of
+// the expected size. The expected size is zero if it's unknown or nothing is going to be cloned.
+func (g diskGuard) check(expectedSize int64) error {
+	if !g.enabled() {
+		return nil
+	}
+
+	free, total, err := freeSpace(g.rootFolder)
+	if err != nil {
+		return fmt.Errorf("failed to check free space in %v: %w", g.rootFolder, err)
+	}
+
+	minFree := max(g.MinFree, int64(float64(total)*g.MinFreePercent/100))
+	if free-expectedSize >= minFree {
+		return nil
+	}
+
+	if expectedSize > 0 {
+		return fmt.Errorf("%w in %v: %v bytes are free, the repository takes about %v bytes and %v bytes must remain",
+			backup.ErrInsufficientSpace, g.rootFolder, free, expectedSize, minFree)
+	}
+
+	return fmt.Errorf("%w in %v: %v bytes are free and %v bytes must remain", backup.ErrInsufficientSpace, g.rootFolder, free, minFree)
+}
+
+// oversized returns the reason to skip a repository which is larger than its maximum size, according to
+// GitHub or to the size of its backup.
+func oversized(target Target) string {
+	if target.MaxRepoSize == 0 {
+		return ""
+	}
+
+	if target.Size > target.MaxRepoSize {
+		return fmt.Sprintf("size %v bytes exceeds the maximum repository size of %v bytes", target.Size, target.MaxRepoSize)
+	}
+
+	if size := backup.FolderSize(target.Path); size > target.MaxRepoSize {
+		return fmt.Sprintf("backup size %v bytes exceeds the maximum repository size of %v bytes", size, target.MaxRepoSize)
+	}
+
+	return ""
+}
+
+// withoutSkipped splits off the generic targets which exceed their maximum repository size or aren't due.
+func withoutSkipped(profile config.GenericProfile, targets []Target, now time.Time) (kept []Target, skipped []SkippedRepo) {
+	for _, target := range targets {
+		reason := cmp.Or(oversized(target), notDue(target, now))
+		if reason == "" {
+			kept = append(kept, target)
+			continue
+		}
+
+		repo, _ := filepath.Rel(profile.RootFolder, target.Path)
+		skipped = append(skipped, SkippedRepo{
+			Profile: target.Profile,
+			Repo:    repo,
+			URL:     target.URL,
+			Path:    target.Path,
+			Reason:  reason,
+		})
+	}
+
+	return kept, skipped
+}
+
+// freeSpace returns the space available to the user and the size of the file system of the folder. The
+// closest existing parent is checked if the folder doesn't exist yet.
+func freeSpace(folder string) (free, total int64, err error) {
+	for {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(folder, &stat)
+		if err == nil {
+			return int64(stat.Bavail) * int64(stat.Bsize), int64(stat.Blocks) * int64(stat.Bsize), nil
+		}
+
+		parent := filepath.Dir(folder)
+		if !errors.Is(err, syscall.ENOENT) || parent == folder {
+			return 0, 0, err
+		}
+		folder = parent
+	}
+}
@@ -0,0 +1,146 @@
+package launcher_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Disk guard tests", func() {
+	const huge = int64(1) << 60
+
+	var (
+		rootFolder        string
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		fakeObserver      *launcherfakes.FakeObserver
+		summary           launcher.Summary
+		err               error
+	)
+
+	BeforeEach(func() {
+		rootFolder = GinkgoT().TempDir()
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeBackupService.ActionReturns(backup.ActionClone, nil)
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+			for _, repo := range []github.Repo{
+				{Name: "huge", Owner: "owner", SSHURL: "git@github.com:owner/huge.git", Size: huge},
+				{Name: "small", Owner: "owner", SSHURL: "git@github.com:owner/small.git", Size: 1024},
+			} {
+				if !yield(repo, nil) {
+					return
+				}
+			}
+		})
+		fakeObserver = &launcherfakes.FakeObserver{}
+
+		conf = config.Config{Profiles: config.Profiles{
+			GitHubProfiles: []config.GitHubProfile{{
+				Name:       "github",
+				RootFolder: filepath.Join(rootFolder, "github"),
+				Disk:       config.Disk{MinFree: 1},
+			}},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, launcher.WithObservers(fakeObserver))
+		_, summary = fakeObserver.RunFinishedArgsForCall(0)
+	})
+
+	It("does not clone repositories which don't fit on the disk", func() {
+		Expect(err).To(MatchError(backup.ErrInsufficientSpace))
+		Expect(err).To(MatchError(ContainSubstring("failed to backup repository git@github.com:owner/huge.git from profile github: not enough free space")))
+		Expect(fakeBackupService.RunCallCount()).To(Equal(1))
+		_, url, _, _ := fakeBackupService.RunArgsForCall(0)
+		Expect(url).To(Equal("git@github.com:owner/small.git"))
+		Expect(summary.Failed()).To(HaveLen(1))
+		Expect(backup.ClassifyError(summary.Failed()[0].Err)).To(Equal(backup.ErrorClassDisk))
+	})
+
+	When("the repositories are already cloned", func() {
+		BeforeEach(func() {
+			fakeBackupService.ActionReturns(backup.ActionFetch, nil)
+		})
+
+		It("fetches them", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeBackupService.RunCallCount()).To(Equal(2))
+		})
+	})
+
+	When("the disk is already short of free space", func() {
+		BeforeEach(func() {
+			conf.Profiles.GitHubProfiles[0].Disk = config.Disk{MinFreePercent: 100}
+		})
+
+		It("refuses to back up the profile", func() {
+			Expect(err).To(MatchError(ContainSubstring("failed to backup profile github: not enough free space in " + rootFolder)))
+			Expect(fakeReaderService.AllReposCallCount()).To(BeZero())
+			Expect(fakeBackupService.RunCallCount()).To(BeZero())
+			Expect(summary.Profiles).To(HaveLen(1))
+			Expect(summary.Profiles[0].Err).To(MatchError(backup.ErrInsufficientSpace))
+		})
+	})
+
+	When("repositories exceed the maximum size", func() {
+		BeforeEach(func() {
+			conf.Profiles.GitHubProfiles[0].Disk = config.Disk{MaxRepoSize: 1 << 30}
+
+			genericRoot := filepath.Join(rootFolder, "generic")
+			Expect(os.MkdirAll(filepath.Join(genericRoot, "big"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(genericRoot, "big", "pack"), make([]byte, 2048), 0o644)).To(Succeed())
+			conf.Profiles.GenericProfiles = []config.GenericProfile{{
+				Name:       "generic",
+				RootFolder: genericRoot,
+				Targets: []config.GenericTarget{
+					{URL: "https://example.com/big.git", Folder: "big", MaxRepoSize: 1024},
+					{URL: "https://example.com/new.git", Folder: "new", MaxRepoSize: 1024},
+				},
+			}}
+		})
+
+		It("skips them with the reason", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeBackupService.RunCallCount()).To(Equal(2))
+			Expect(summary.Skipped).To(Equal([]launcher.SkippedRepo{
+				{
+					Profile: "generic",
+					Repo:    "big",
+					URL:     "https://example.com/big.git",
+					Path:    filepath.Join(rootFolder, "generic", "big"),
+					Reason:  "backup size 2048 bytes exceeds the maximum repository size of 1024 bytes",
+				},
+				{
+					Profile: "github",
+					Repo:    "owner/huge",
+					URL:     "git@github.com:owner/huge.git",
+					Path:    filepath.Join(rootFolder, "github", "owner", "huge"),
+					Reason:  "size 1152921504606846976 bytes exceeds the maximum repository size of 1073741824 bytes",
+				},
+			}))
+		})
+
+		When("the repositories override the maximum size", func() {
+			BeforeEach(func() {
+				conf.Profiles.GenericProfiles[0].Targets[0].MaxRepoSize = 0
+				conf.Profiles.GitHubProfiles[0].Overrides = []config.RepoOverride{{Repos: []string{"huge"}}}
+			})
+
+			It("backs them up", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeBackupService.RunCallCount()).To(Equal(4))
+				Expect(summary.Skipped).To(BeEmpty())
+			})
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/config"
+)
+
+//counterfeiter:generate . Exporter
+type Exporter interface {
+	Export(ctx context.Context, profile, targetFolder string) (bundle.Bundle, error)
+}
+
+type BundledRepo struct {
+	Profile string
+	Path    string
+	// Bundle is empty if nothing changed since the previous bundle.
+	Bundle bundle.Bundle
+	Err    error
+}
+
+// Export bundles the repositories found in the root folders of the selected profiles, including the
+// ones which no longer belong to any target.
+func Export(ctx context.Context, conf config.Config, exporter Exporter, opts ...Option) (bundled []BundledRepo, exportErrors error) {
+	options := newOptions(opts)
+	sel, err := newSelection(conf, options)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repo := range storedRepos(conf, sel) {
+		select {
+		case <-ctx.Done():
+			return bundled, errors.Join(exportErrors, context.Canceled)
+		default:
+		}
+
+		ctx := clog.Add(ctx, "profile", repo.profile)
+		result := BundledRepo{Profile: repo.profile, Path: repo.path}
+		if result.Bundle, err = exportLocked(ctx, exporter, options.locking, repo.profile, repo.path); err != nil {
+			slog.ErrorContext(ctx, "Failed to bundle", "target_folder", repo.path, "error", err)
+			result.Err = fmt.Errorf("failed to bundle repository %v from profile %v: %w", repo.path, repo.profile, err)
+			exportErrors = errors.Join(exportErrors, result.Err)
+		}
+		bundled = append(bundled, result)
+	}
+
+	return bundled, errors.Join(exportErrors, sel.unmatched())
+}
+
+func exportLocked(ctx context.Context, exporter Exporter, locking bool, profile, repo string) (bundle.Bundle, error) {
+	unlock, err := lockRepo(locking, repo)
+	if err != nil {
+		return bundle.Bundle{}, err
+	}
+	defer unlock()
+
+	return exporter.Export(ctx, profile, repo)
+}
@@ -0,0 +1,103 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Export tests", func() {
+	var (
+		conf         config.Config
+		fakeExporter *launcherfakes.FakeExporter
+		rootFolder   string
+		bundled      []launcher.BundledRepo
+		err          error
+	)
+
+	BeforeEach(func() {
+		fakeExporter = &launcherfakes.FakeExporter{}
+		rootFolder = GinkgoT().TempDir()
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{Name: "generic", RootFolder: rootFolder}},
+			},
+		}
+
+		for _, name := range []string{"repo_1", "repo_2"} {
+			Expect(os.MkdirAll(filepath.Join(rootFolder, name, "objects"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(rootFolder, name, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644)).To(Succeed())
+		}
+		fakeExporter.ExportStub = func(_ context.Context, _, path string) (bundle.Bundle, error) {
+			if filepath.Base(path) == "repo_2" {
+				return bundle.Bundle{}, errors.New("no space left on device")
+			}
+			return bundle.Bundle{File: "000001.bundle"}, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		bundled, err = launcher.Export(ctx, conf, fakeExporter)
+	})
+
+	It("bundles every repository in the root folders", func() {
+		Expect(fakeExporter.ExportCallCount()).To(Equal(2))
+		Expect(bundled).To(HaveLen(2))
+		Expect(bundled[0].Bundle.File).To(Equal("000001.bundle"))
+		Expect(bundled[1].Err).To(HaveOccurred())
+		Expect(err).To(MatchError(ContainSubstring("failed to bundle repository " + filepath.Join(rootFolder, "repo_2") + " from profile generic: no space left on device")))
+	})
+})
+
+var _ = Describe("Bundling after backup", func() {
+	var (
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		fakeExporter      *launcherfakes.FakeExporter
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeExporter = &launcherfakes.FakeExporter{}
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {})
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{
+					Name:       "generic",
+					RootFolder: "/backups/generic",
+					Targets: []config.GenericTarget{
+						{URL: "https://example.com/repo_1.git", Folder: "repo_1"},
+						{URL: "https://example.com/repo_2.git", Folder: "repo_2"},
+					},
+				}},
+			},
+		}
+		fakeBackupService.RunReturnsOnCall(0, backup.Result{}, errors.New("something went wrong"))
+		fakeExporter.ExportReturns(bundle.Bundle{}, errors.New("no space left on device"))
+	})
+
+	JustBeforeEach(func() {
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, launcher.WithExporter(fakeExporter))
+	})
+
+	It("bundles successfully backed up repositories and fails their backup if bundling fails", func() {
+		Expect(fakeExporter.ExportCallCount()).To(Equal(1))
+		_, profile, path := fakeExporter.ExportArgsForCall(0)
+		Expect(profile).To(Equal("generic"))
+		Expect(path).To(Equal("/backups/generic/repo_2"))
+		Expect(err).To(MatchError(ContainSubstring("failed to bundle repository https://example.com/repo_2.git from profile generic: no space left on device")))
+	})
+})
@@ -0,0 +1,107 @@
+package launcher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/pattern"
+)
+
+// repoFilter returns the reason why the repository must be skipped or an empty string to keep it.
+type repoFilter func(repo github.Repo) (skipReason string)
+
+func applyFilters(filters []repoFilter, repo github.Repo) string {
+	for _, filter := range filters {
+		if reason := filter(repo); reason != "" {
+			return reason
+		}
+	}
+
+	return ""
+}
+
+func profileFilters(profile config.GitHubProfile) ([]repoFilter, error) {
+	includeFilter, err := includeFilter(profile.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	excludeFilter, err := excludeFilter(profile.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return []repoFilter{excludeFilter, includeFilter, attributeFilter(profile.Filters)}, nil
+}
+
+func includeFilter(toInclude []string) (repoFilter, error) {
+	if toInclude == nil {
+		return func(github.Repo) string { return "" }, nil
+	}
+
+	patterns, err := pattern.CompileList(toInclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(repo github.Repo) string {
+		if !patterns.Match(repo.Owner, repo.Name) {
+			return "not in the include list"
+		}
+
+		return ""
+	}, nil
+}
+
+func excludeFilter(toExclude []string) (repoFilter, error) {
+	patterns, err := pattern.CompileList(toExclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(repo github.Repo) string {
+		if patterns.Match(repo.Owner, repo.Name) {
+			return "in the exclude list"
+		}
+
+		return ""
+	}, nil
+}
+
+func attributeFilter(filters config.RepoFilters) repoFilter {
+	return func(repo github.Repo) string {
+		switch {
+		case filters.Fork != nil && *filters.Fork != repo.Fork:
+			return describeFlag(repo.Fork, "a fork")
+		case filters.Archived != nil && *filters.Archived != repo.Archived:
+			return describeFlag(repo.Archived, "archived")
+		case filters.Private != nil && *filters.Private != repo.Private:
+			return describeFlag(repo.Private, "private")
+		case filters.MinSize != nil && repo.Size < *filters.MinSize:
+			return fmt.Sprintf("size %v bytes is below the minimum of %v bytes", repo.Size, *filters.MinSize)
+		case filters.MaxSize != nil && repo.Size > *filters.MaxSize:
+			return fmt.Sprintf("size %v bytes exceeds the maximum of %v bytes", repo.Size, *filters.MaxSize)
+		case len(filters.Languages) > 0 && !slices.ContainsFunc(filters.Languages, func(language string) bool {
+			return strings.EqualFold(language, repo.Language)
+		}):
+			return fmt.Sprintf("language %q is not in the languages list", repo.Language)
+		case len(filters.Topics) > 0 && !slices.ContainsFunc(filters.Topics, func(topic string) bool {
+			return slices.ContainsFunc(repo.Topics, func(repoTopic string) bool { return strings.EqualFold(topic, repoTopic) })
+		}):
+			return "has none of the topics"
+		default:
+			return ""
+		}
+	}
+}
+
+func describeFlag(value bool, description string) string {
+	if value {
+		return "is " + description
+	}
+
+	return "is not " + description
+}
@@ -0,0 +1,141 @@
+package launcher_test
+
+import (
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Repository filter tests", func() {
+	var (
+		profile           config.GitHubProfile
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		plan              launcher.Plan
+		err               error
+	)
+
+	yes, no := true, false
+	size := func(value int64) *int64 { return &value }
+
+	BeforeEach(func() {
+		profile = config.GitHubProfile{Name: "github", RootFolder: "/backup"}
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+			for _, repo := range []github.Repo{
+				{Name: "svc-users", Owner: "myorg", Private: true, Size: 1000, Language: "Go", Topics: []string{"backend"}},
+				{Name: "svc-archive", Owner: "myorg", Archived: true, Size: 500, Language: "Go"},
+				{Name: "website", Owner: "myorg", Size: 5000, Language: "TypeScript", Topics: []string{"frontend"}},
+				{Name: "svc-fork", Owner: "someone", Fork: true, Size: 100, Language: "Rust", Topics: []string{"Backend"}},
+			} {
+				if !yield(repo, nil) {
+					return
+				}
+			}
+		})
+	})
+
+	JustBeforeEach(func() {
+		conf := config.Config{Profiles: config.Profiles{GitHubProfiles: []config.GitHubProfile{profile}}}
+		plan, err = launcher.DryRun(ctx, conf, fakeBackupService, fakeReaderService)
+	})
+
+	kept := func() []string {
+		names := make([]string, len(plan.Steps))
+		for i, step := range plan.Steps {
+			names[i] = step.Path
+		}
+
+		return names
+	}
+
+	skipped := func() map[string]string {
+		reasons := map[string]string{}
+		for _, repo := range plan.Skipped {
+			reasons[repo.Repo] = repo.Reason
+		}
+
+		return reasons
+	}
+
+	When("include and exclude use patterns", func() {
+		BeforeEach(func() {
+			profile.Include = []string{"myorg/*", "regex:^svc-"}
+			profile.Exclude = []string{"*-archive"}
+		})
+
+		It("keeps matching repositories", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(kept()).To(Equal([]string{"/backup/myorg/svc-users", "/backup/myorg/website", "/backup/someone/svc-fork"}))
+			Expect(skipped()).To(Equal(map[string]string{"myorg/svc-archive": "in the exclude list"}))
+		})
+	})
+
+	When("include has a negated pattern only", func() {
+		BeforeEach(func() {
+			profile.Include = []string{"!svc-*"}
+		})
+
+		It("keeps repositories which don't match the pattern", func() {
+			Expect(kept()).To(Equal([]string{"/backup/myorg/website"}))
+		})
+	})
+
+	When("an include pattern is invalid", func() {
+		BeforeEach(func() {
+			profile.Include = []string{"regex:("}
+		})
+
+		It("returns an error", func() {
+			Expect(err).To(MatchError(ContainSubstring(`invalid pattern "regex:("`)))
+			Expect(plan.Steps).To(BeEmpty())
+		})
+	})
+
+	When("boolean attributes are filtered", func() {
+		BeforeEach(func() {
+			profile.Filters = config.RepoFilters{Fork: &no, Archived: &no, Private: &yes}
+		})
+
+		It("keeps matching repositories", func() {
+			Expect(kept()).To(Equal([]string{"/backup/myorg/svc-users"}))
+			Expect(skipped()).To(Equal(map[string]string{
+				"myorg/svc-archive": "is archived",
+				"myorg/website":     "is not private",
+				"someone/svc-fork":  "is a fork",
+			}))
+		})
+	})
+
+	When("size is filtered", func() {
+		BeforeEach(func() {
+			profile.Filters = config.RepoFilters{MinSize: size(200), MaxSize: size(1000)}
+		})
+
+		It("keeps repositories within the limits", func() {
+			Expect(kept()).To(Equal([]string{"/backup/myorg/svc-users", "/backup/myorg/svc-archive"}))
+			Expect(skipped()).To(Equal(map[string]string{
+				"myorg/website":    "size 5000 bytes exceeds the maximum of 1000 bytes",
+				"someone/svc-fork": "size 100 bytes is below the minimum of 200 bytes",
+			}))
+		})
+	})
+
+	When("languages and topics are filtered", func() {
+		BeforeEach(func() {
+			profile.Filters = config.RepoFilters{Languages: []string{"go", "rust"}, Topics: []string{"backend"}}
+		})
+
+		It("keeps matching repositories", func() {
+			Expect(kept()).To(Equal([]string{"/backup/myorg/svc-users", "/backup/someone/svc-fork"}))
+			Expect(skipped()).To(Equal(map[string]string{
+				"myorg/svc-archive": "has none of the topics",
+				"myorg/website":     `language "TypeScript" is not in the languages list`,
+			}))
+		})
+	})
+})
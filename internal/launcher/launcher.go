@@ -1,23 +1,26 @@
 package launcher
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"iter"
 	"log/slog"
 	"path"
-	"strings"
+	"time"
 
 	"github.com/AntonKosov/git-backups/internal/clog"
 	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
 	"github.com/AntonKosov/git-backups/internal/github"
-	"github.com/AntonKosov/git-backups/internal/slice"
 )
 
 //counterfeiter:generate . BackupService
 type BackupService interface {
-	Run(ctx context.Context, url, targetFolder string, privateSSHKey *string) error
+	Run(ctx context.Context, url, targetFolder string, settings backup.Settings) (backup.Result, error)
+	Action(targetFolder string) (backup.Action, error)
+	Verify(ctx context.Context, targetFolder string) error
 }
 
 //counterfeiter:generate . ReaderService
@@ -25,122 +28,371 @@ type ReaderService interface {
 	AllRepos(ctx context.Context, token, affiliation string) iter.Seq2[github.Repo, error]
 }
 
-func Run(ctx context.Context, conf config.Config, backupService BackupService, readerService ReaderService) error {
+type Target struct {
+	Profile       string
+	URL           string
+	Path          string
+	PrivateSSHKey *string
+	Maintenance   config.Maintenance
+	Partial       config.Partial
+	// MaxRepoSize skips the repository if it's larger. It's unlimited if zero.
+	MaxRepoSize int64
+	// Size is the size of the repository reported by GitHub in bytes. It's zero if unknown.
+	Size     int64
+	Timeout  time.Duration
+	LFS      bool
+	Schedule config.RepoSchedule
+}
+
+func Run(ctx context.Context, conf config.Config, backupService BackupService, readerService ReaderService, opts ...Option) error {
+	options := newOptions(opts)
+	r := runner{
+		backupService: backupService,
+		readerService: readerService,
+		observers:     options.observers,
+		exporter:      options.exporter,
+		gracefulStop:  options.gracefulStop,
+		locking:       options.locking,
+		verify:        conf.Verify,
+		summary:       Summary{Started: time.Now()},
+	}
+	for _, observer := range r.observers {
+		observer.RunStarted(ctx, r.summary.Started)
+	}
+
+	err := r.run(ctx, conf, options)
+
+	r.summary.Duration = time.Since(r.summary.Started)
+	r.summary.Err = err
+	for _, observer := range r.observers {
+		observer.RunFinished(ctx, r.summary)
+	}
+
+	return err
+}
+
+type runner struct {
+	backupService BackupService
+	readerService ReaderService
+	observers     []Observer
+	exporter      Exporter
+	gracefulStop  bool
+	locking       bool
+	verify        config.Verify
+	sel           *selection
+	summary       Summary
+}
+
+func (r *runner) run(ctx context.Context, conf config.Config, options Options) error {
+	sel, err := newSelection(conf, options)
+	if err != nil {
+		slog.ErrorContext(ctx, "Invalid filters", "error", err)
+		return err
+	}
+	r.sel = sel
+
+	if r.locking {
+		unlock, err := lockRootFolders(conf, sel)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to lock the root folders", "error", err)
+			return fmt.Errorf("failed to start the run: %w", err)
+		}
+		defer unlock()
+	}
+
 	slog.InfoContext(ctx, "Beginning to backup generic repositories...")
-	err := backupGenericProfiles(ctx, conf.Profiles.GenericProfiles, backupService)
+	err = r.backupGenericProfiles(ctx, conf.Profiles.GenericProfiles)
 	slog.InfoContext(ctx, "Backed up generic repositories")
 
 	slog.InfoContext(ctx, "Beginning to backup github repositories...")
-	err = errors.Join(err, backupGitHubProfiles(ctx, conf.Profiles.GitHubProfiles, backupService, readerService))
+	err = errors.Join(err, r.backupGitHubProfiles(ctx, conf.Profiles.GitHubProfiles))
 	slog.InfoContext(ctx, "Backed up github repositories")
 
-	return err
+	return errors.Join(err, sel.unmatched())
 }
 
-func backupGenericProfiles(ctx context.Context, genericProfiles []config.GenericProfile, backupService BackupService) (backupErrors error) {
+func (r *runner) backupGenericProfiles(ctx context.Context, genericProfiles []config.GenericProfile) (backupErrors error) {
 	for _, profile := range genericProfiles {
-		ctx := clog.Add(ctx, "profile", profile)
-		for _, target := range profile.Targets {
+		if !r.sel.profile(profile.Name) {
+			continue
+		}
+
+		ctx := clog.Add(ctx, "profile", profile.Name)
+		r.profileStarted(ctx, profile.Name)
+		targets, skipped := withoutSkipped(profile, genericTargets(profile, r.sel), r.summary.Started)
+		r.summary.Skipped = append(r.summary.Skipped, skipped...)
+		r.summary.Profiles = append(r.summary.Profiles, ProfileResult{
+			Name:       profile.Name,
+			Discovered: len(profile.Targets),
+			Selected:   len(targets),
+		})
+
+		guard := diskGuard{Disk: profile.Disk, rootFolder: profile.RootFolder}
+		if err := r.checkFreeSpace(ctx, guard); err != nil {
+			backupErrors = errors.Join(backupErrors, err)
+			continue
+		}
+
+		for _, target := range targets {
 			select {
 			case <-ctx.Done():
 				return errors.Join(backupErrors, context.Canceled)
 			default:
-				targetPath := path.Join(profile.RootFolder, target.Folder)
-				ctx := clog.Add(ctx, "Target folder", targetPath)
-				if err := backupService.Run(ctx, target.URL, targetPath, profile.PrivateSSHKey); err != nil {
-					slog.ErrorContext(ctx, "Failed to backup", "error", err)
-					backupErrors = errors.Join(backupErrors, fmt.Errorf("failed to backup repository %v from profile %v: %w", target.URL, profile.Name, err))
-				}
+				ctx := clog.Add(ctx, "target_folder", target.Path)
+				backupErrors = errors.Join(backupErrors, r.backupTarget(ctx, target, guard))
 			}
 		}
+
+		known := make(map[string]bool, len(profile.Targets))
+		for _, target := range profile.Targets {
+			known[path.Join(profile.RootFolder, target.Folder)] = true
+		}
+		r.addOrphans(profile.Name, profile.RootFolder, known)
 	}
 
 	return backupErrors
 }
 
-func backupGitHubProfiles(ctx context.Context, githubProfiles []config.GitHubProfile, backupService BackupService, readerService ReaderService) (backupErrors error) {
+func (r *runner) backupGitHubProfiles(ctx context.Context, githubProfiles []config.GitHubProfile) (backupErrors error) {
 	for _, profile := range githubProfiles {
+		if !r.sel.profile(profile.Name) {
+			continue
+		}
+
 		ctx := clog.Add(ctx, "profile", profile.Name)
-		repos := include(
-			profile.Include,
-			exclude(
-				profile.Exclude,
-				readerService.AllRepos(ctx, profile.Token, profile.Affiliation),
-			),
-		)
-		for repo, err := range repos {
+		r.profileStarted(ctx, profile.Name)
+		r.summary.Profiles = append(r.summary.Profiles, ProfileResult{Name: profile.Name})
+		profileResult := &r.summary.Profiles[len(r.summary.Profiles)-1]
+		known := map[string]bool{}
+
+		guard := diskGuard{Disk: profile.Disk, rootFolder: profile.RootFolder}
+		if err := r.checkFreeSpace(ctx, guard); err != nil {
+			backupErrors = errors.Join(backupErrors, err)
+			continue
+		}
+
+		for candidate, err := range gitHubCandidates(ctx, profile, r.readerService, r.sel) {
 			if err != nil {
 				slog.ErrorContext(ctx, "Failed to read repositories", "error", err)
-				backupErrors = errors.Join(backupErrors, errors.Join(backupErrors, fmt.Errorf("failed to read repositories: %w", err)))
+				profileResult.Err = err
+				backupErrors = errors.Join(backupErrors, err)
 				break
 			}
 
+			profileResult.Discovered++
+			known[candidate.target.Path] = true
+			candidate.skipReason = cmp.Or(candidate.skipReason, notDue(candidate.target, r.summary.Started))
+			if candidate.skipReason != "" {
+				r.summary.Skipped = append(r.summary.Skipped, candidate.skipped())
+				continue
+			}
+			profileResult.Selected++
+
 			select {
 			case <-ctx.Done():
 				return errors.Join(backupErrors, context.Canceled)
 			default:
-				ctx := clog.Add(ctx, "repo", repo.Name)
-
-				err := backupService.Run(
-					ctx,
-					repo.SSHURL,
-					path.Join(profile.RootFolder, repo.Owner, repo.Name),
-					profile.PrivateSSHKey,
-				)
-				if err != nil {
-					slog.ErrorContext(ctx, "Failed to backup", "error", err)
-					backupErrors = errors.Join(backupErrors, fmt.Errorf("failed to backup repository %v from profile %v: %w", repo.SSHURL, profile.Name, err))
-				}
+				ctx := clog.Add(ctx, "repo", path.Base(candidate.target.Path))
+				backupErrors = errors.Join(backupErrors, r.backupTarget(ctx, candidate.target, guard))
 			}
 		}
+
+		// An incomplete list of repositories would report everything else as orphaned.
+		if profileResult.Err == nil {
+			r.addOrphans(profile.Name, profile.RootFolder, known)
+		}
 	}
 
 	return backupErrors
 }
 
-func include(toInclude []string, repos iter.Seq2[github.Repo, error]) iter.Seq2[github.Repo, error] {
-	if toInclude == nil {
-		return repos
+func (r *runner) profileStarted(ctx context.Context, profile string) {
+	for _, observer := range r.observers {
+		observer.ProfileStarted(ctx, profile)
+	}
+}
+
+// checkFreeSpace refuses to back up a profile whose root folder is already short of free space.
+func (r *runner) checkFreeSpace(ctx context.Context, guard diskGuard) error {
+	err := guard.check(0)
+	if err == nil {
+		return nil
+	}
+
+	slog.ErrorContext(ctx, "Refused to backup the profile", "error", err)
+	profileResult := &r.summary.Profiles[len(r.summary.Profiles)-1]
+	profileResult.Err = err
+
+	return fmt.Errorf("failed to backup profile %v: %w", profileResult.Name, err)
+}
+
+func (r *runner) backupTarget(ctx context.Context, target Target, guard diskGuard) error {
+	result := TargetResult{Target: target, Started: time.Now()}
+	if r.gracefulStop {
+		ctx = context.WithoutCancel(ctx)
 	}
 
-	includeMap := slice.Lookup(toInclude, func(name string) (string, bool) { return strings.ToLower(name), true })
-	return func(yield func(github.Repo, error) bool) {
-		for repo, err := range repos {
+	unlock, err := lockRepo(r.locking, target.Path)
+	if err == nil {
+		defer unlock()
+		result.Backup, err = r.runBackup(ctx, target, guard)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to backup", "error", err)
+		result.Err = fmt.Errorf("failed to backup repository %v from profile %v: %w", target.URL, target.Profile, err)
+	} else if r.verify.AfterBackup && due(target.Path, r.verify.SamplePercent, r.summary.Started) {
+		result.Verified = true
+		if err := r.backupService.Verify(ctx, target.Path); err != nil {
+			slog.ErrorContext(ctx, "Failed to verify", "error", err)
+			result.Err = fmt.Errorf("failed to verify repository %v from profile %v: %w", target.URL, target.Profile, err)
+		}
+	}
+	if result.Err == nil && r.exporter != nil {
+		if _, err := r.exporter.Export(ctx, target.Profile, target.Path); err != nil {
+			slog.ErrorContext(ctx, "Failed to bundle", "error", err)
+			result.Err = fmt.Errorf("failed to bundle repository %v from profile %v: %w", target.URL, target.Profile, err)
+		}
+	}
+	result.Duration = time.Since(result.Started)
+
+	r.summary.Targets = append(r.summary.Targets, result)
+	for _, observer := range r.observers {
+		observer.TargetFinished(ctx, result)
+	}
+
+	return result.Err
+}
+
+// runBackup runs git unless cloning the repository would leave too little free space.
+func (r *runner) runBackup(ctx context.Context, target Target, guard diskGuard) (backup.Result, error) {
+	if guard.enabled() {
+		action, err := r.backupService.Action(target.Path)
+		if err != nil {
+			return backup.Result{}, err
+		}
+
+		if action == backup.ActionClone {
+			if err := guard.check(target.Size); err != nil {
+				return backup.Result{}, err
+			}
+		}
+	}
+
+	return r.backupService.Run(ctx, target.URL, target.Path, backup.Settings{
+		PrivateSSHKey: target.PrivateSSHKey,
+		Maintenance:   target.Maintenance,
+		Partial:       target.Partial,
+		Timeout:       target.Timeout,
+		LFS:           target.LFS,
+	})
+}
+
+func (r *runner) addOrphans(profile, rootFolder string, known map[string]bool) {
+	for _, orphan := range backup.FindRepos(rootFolder, known) {
+		r.summary.Orphaned = append(r.summary.Orphaned, OrphanedRepo{Profile: profile, Path: orphan})
+	}
+}
+
+func genericTargets(profile config.GenericProfile, sel *selection) []Target {
+	targets := make([]Target, 0, len(profile.Targets))
+	for _, target := range profile.Targets {
+		if !sel.folder(target.Folder) {
+			continue
+		}
+
+		targets = append(targets, Target{
+			Profile:       profile.Name,
+			URL:           target.URL,
+			Path:          path.Join(profile.RootFolder, target.Folder),
+			PrivateSSHKey: cmp.Or(target.PrivateSSHKey, profile.PrivateSSHKey),
+			Maintenance:   target.Maintenance,
+			Partial:       target.Partial,
+			MaxRepoSize:   target.MaxRepoSize,
+			Timeout:       target.Timeout,
+			LFS:           target.LFS,
+			Schedule:      target.Schedule,
+		})
+	}
+
+	return targets
+}
+
+func gitHubTargets(ctx context.Context, profile config.GitHubProfile, readerService ReaderService, sel *selection) iter.Seq2[Target, error] {
+	return func(yield func(Target, error) bool) {
+		for candidate, err := range gitHubCandidates(ctx, profile, readerService, sel) {
 			if err != nil {
-				yield(github.Repo{}, err)
+				yield(Target{}, err)
 				return
 			}
 
-			if !includeMap[strings.ToLower(repo.Name)] {
+			if candidate.skipReason != "" {
 				continue
 			}
 
-			if !yield(repo, nil) {
+			if !yield(candidate.target, nil) {
 				return
 			}
 		}
 	}
 }
 
-func exclude(toExclude []string, repos iter.Seq2[github.Repo, error]) iter.Seq2[github.Repo, error] {
-	if len(toExclude) == 0 {
-		return repos
+type candidate struct {
+	repo       github.Repo
+	target     Target
+	skipReason string
+}
+
+func (c candidate) skipped() SkippedRepo {
+	return SkippedRepo{
+		Profile: c.target.Profile,
+		Repo:    c.repo.Owner + "/" + c.repo.Name,
+		URL:     c.target.URL,
+		Path:    c.target.Path,
+		Reason:  c.skipReason,
 	}
+}
 
-	excludeMap := slice.Lookup(toExclude, func(name string) (string, bool) { return strings.ToLower(name), true })
+func gitHubCandidates(ctx context.Context, profile config.GitHubProfile, readerService ReaderService, sel *selection) iter.Seq2[candidate, error] {
+	return func(yield func(candidate, error) bool) {
+		filters, err := profileFilters(profile)
+		if err != nil {
+			yield(candidate{}, err)
+			return
+		}
+		filters = append(filters, sel.repoFilter())
+		overrides, err := compileOverrides(profile.Overrides)
+		if err != nil {
+			yield(candidate{}, err)
+			return
+		}
 
-	return func(yield func(github.Repo, error) bool) {
-		for repo, err := range repos {
+		for repo, err := range readerService.AllRepos(ctx, profile.Token, profile.Affiliation) {
 			if err != nil {
-				yield(github.Repo{}, err)
+				yield(candidate{}, fmt.Errorf("failed to read repositories: %w", err))
 				return
 			}
 
-			if excludeMap[strings.ToLower(repo.Name)] {
-				continue
+			c := candidate{
+				repo: repo,
+				target: overrides.apply(repo, Target{
+					Profile:       profile.Name,
+					URL:           repo.SSHURL,
+					Path:          path.Join(profile.RootFolder, repo.Owner, repo.Name),
+					PrivateSSHKey: profile.PrivateSSHKey,
+					Maintenance:   profile.Maintenance,
+					Partial:       profile.Partial,
+					MaxRepoSize:   profile.Disk.MaxRepoSize,
+					Size:          repo.Size,
+					Timeout:       profile.Timeout,
+					LFS:           profile.LFS,
+					Schedule:      profile.Schedule,
+				}),
+				skipReason: applyFilters(filters, repo),
 			}
-
-			if !yield(repo, nil) {
+			if c.skipReason == "" {
+				c.skipReason = oversized(c.target)
+			}
+			if !yield(c, nil) {
 				return
 			}
 		}
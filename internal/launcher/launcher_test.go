@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
 	"github.com/AntonKosov/git-backups/internal/github"
 	"github.com/AntonKosov/git-backups/internal/launcher"
 	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
@@ -18,19 +20,21 @@ var _ = Describe("Launcher tests", func() {
 		conf              config.Config
 		fakeBackupService *launcherfakes.FakeBackupService
 		fakeReaderService *launcherfakes.FakeReaderService
+		opts              []launcher.Option
 		err               error
 	)
 
 	var verifyCall = func(idx int, expectedURL, expectedPath string, expectedSSHKey *string) {
-		_, url, path, privateSSHKey := fakeBackupService.RunArgsForCall(idx)
+		_, url, path, settings := fakeBackupService.RunArgsForCall(idx)
 		Expect(url).To(Equal(expectedURL))
 		Expect(path).To(Equal(expectedPath))
-		Expect(privateSSHKey).To(Equal(expectedSSHKey))
+		Expect(settings.PrivateSSHKey).To(Equal(expectedSSHKey))
 	}
 
 	BeforeEach(func() {
 		fakeBackupService = &launcherfakes.FakeBackupService{}
 		fakeReaderService = &launcherfakes.FakeReaderService{}
+		opts = nil
 
 		conf = config.Config{
 			Profiles: config.Profiles{
@@ -152,7 +156,7 @@ var _ = Describe("Launcher tests", func() {
 	})
 
 	JustBeforeEach(func() {
-		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService)
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, opts...)
 	})
 
 	It("does not return an error", func() {
@@ -176,7 +180,7 @@ var _ = Describe("Launcher tests", func() {
 
 	When("generic backup service returns an error", func() {
 		BeforeEach(func() {
-			fakeBackupService.RunReturns(errors.New("something went wrong"))
+			fakeBackupService.RunReturns(backup.Result{}, errors.New("something went wrong"))
 		})
 
 		It("returns an error", func() {
@@ -207,7 +211,7 @@ var _ = Describe("Launcher tests", func() {
 
 	When("GitHub backup service returns an error", func() {
 		BeforeEach(func() {
-			fakeBackupService.RunReturnsOnCall(4, errors.New("something went wrong"))
+			fakeBackupService.RunReturnsOnCall(4, backup.Result{}, errors.New("something went wrong"))
 		})
 
 		It("returns an error", func() {
@@ -250,6 +254,72 @@ var _ = Describe("Launcher tests", func() {
 		})
 	})
 
+	When("targets override the settings of their profile", func() {
+		var (
+			profileKey  = "/generic/profiles/0/private_key"
+			targetKey   = "/generic/profiles/0/targets/1/private_key"
+			maintenance = config.Maintenance{Interval: 24 * time.Hour, Tasks: []string{"gc"}}
+			partial     = config.Partial{BlobLimit: 1024}
+		)
+
+		BeforeEach(func() {
+			conf.Profiles.GenericProfiles[0].PrivateSSHKey = &profileKey
+			conf.Profiles.GenericProfiles[0].Targets[1].PrivateSSHKey = &targetKey
+			conf.Profiles.GenericProfiles[0].Targets[1].Maintenance = maintenance
+			conf.Profiles.GenericProfiles[0].Targets[1].Partial = partial
+			conf.Profiles.GenericProfiles[0].Targets[1].Timeout = time.Hour
+			conf.Profiles.GenericProfiles[0].Targets[1].LFS = true
+		})
+
+		It("backs up the targets with their settings", func() {
+			_, _, _, settings := fakeBackupService.RunArgsForCall(0)
+			Expect(settings).To(Equal(backup.Settings{PrivateSSHKey: &profileKey}))
+			_, _, _, settings = fakeBackupService.RunArgsForCall(1)
+			Expect(settings).To(Equal(backup.Settings{
+				PrivateSSHKey: &targetKey,
+				Maintenance:   maintenance,
+				Partial:       partial,
+				Timeout:       time.Hour,
+				LFS:           true,
+			}))
+		})
+	})
+
+	When("GitHub repositories override the settings of their profile", func() {
+		var (
+			key1 = "/github/profiles/0/private_key"
+			key2 = "/github/profiles/0/overrides/0/private_key"
+		)
+
+		BeforeEach(func() {
+			profile := &conf.Profiles.GitHubProfiles[0]
+			profile.PrivateSSHKey = &key1
+			profile.Maintenance = config.Maintenance{Interval: 24 * time.Hour}
+			profile.Timeout = time.Minute
+			profile.Overrides = []config.RepoOverride{
+				{Repos: []string{"repo_name_*"}, PrivateSSHKey: &key2, Partial: config.Partial{BlobLimit: 1}, Timeout: time.Hour, LFS: true},
+				{Repos: []string{"GH_Username1/repo_name_2"}, Partial: config.Partial{BlobLimit: 2}},
+			}
+		})
+
+		It("backs up the matching repositories with the settings of the last matching override", func() {
+			_, _, _, settings := fakeBackupService.RunArgsForCall(4)
+			Expect(settings).To(Equal(backup.Settings{PrivateSSHKey: &key2, Partial: config.Partial{BlobLimit: 1}, Timeout: time.Hour, LFS: true}))
+			_, _, _, settings = fakeBackupService.RunArgsForCall(5)
+			Expect(settings).To(Equal(backup.Settings{PrivateSSHKey: &key1, Partial: config.Partial{BlobLimit: 2}}))
+		})
+
+		When("an override has an invalid pattern", func() {
+			BeforeEach(func() {
+				conf.Profiles.GitHubProfiles[0].Overrides[1].Repos = []string{"["}
+			})
+
+			It("returns an error", func() {
+				Expect(err).To(MatchError(ContainSubstring(`invalid override: invalid pattern "["`)))
+			})
+		})
+	})
+
 	When("include contains a repo name with different capitalization", func() {
 		BeforeEach(func() {
 			conf.Profiles.GitHubProfiles[0].Include[0] = "rEpO_NaMe_1"
@@ -260,10 +330,10 @@ var _ = Describe("Launcher tests", func() {
 		})
 
 		It("includes the repo", func() {
-			_, url, path, privateSSHKey := fakeBackupService.RunArgsForCall(4)
+			_, url, path, settings := fakeBackupService.RunArgsForCall(4)
 			Expect(url).To(Equal("git:github.com/GH_Username1/repo_name_1.git"))
 			Expect(path).To(Equal("/home/user/git_backup/folder_name_3/GH_Username1/repo_name_1"))
-			Expect(privateSSHKey).To(BeNil())
+			Expect(settings.PrivateSSHKey).To(BeNil())
 		})
 	})
 
@@ -277,10 +347,10 @@ var _ = Describe("Launcher tests", func() {
 		})
 
 		It("does not include the repo", func() {
-			_, url, path, privateSSHKey := fakeBackupService.RunArgsForCall(6)
+			_, url, path, settings := fakeBackupService.RunArgsForCall(6)
 			Expect(url).To(Equal("git:github.com/GH_Username2/repo_name_4.git"))
 			Expect(path).To(Equal("/home/user/git_backup/folder_name_4/GH_Username2/repo_name_4"))
-			Expect(privateSSHKey).To(BeNil())
+			Expect(settings.PrivateSSHKey).To(BeNil())
 		})
 	})
 
@@ -295,23 +365,23 @@ var _ = Describe("Launcher tests", func() {
 
 		It("does not clone repositories from the first GitHub profile", func() {
 			Expect(fakeBackupService.RunCallCount()).To(Equal(8))
-			_, url, path, privateSSHKey := fakeBackupService.RunArgsForCall(4)
+			_, url, path, settings := fakeBackupService.RunArgsForCall(4)
 			Expect(url).To(Equal("git:github.com/GH_Username2/repo_name_4.git"))
 			Expect(path).To(Equal("/home/user/git_backup/folder_name_4/GH_Username2/repo_name_4"))
-			Expect(privateSSHKey).To(BeNil())
+			Expect(settings.PrivateSSHKey).To(BeNil())
 		})
 	})
 
 	When("generic context is canceled", func() {
 		BeforeEach(func() {
 			numCalls := 0
-			fakeBackupService.RunStub = func(context.Context, string, string, *string) error {
+			fakeBackupService.RunStub = func(context.Context, string, string, backup.Settings) (backup.Result, error) {
 				numCalls++
 				if numCalls == 3 {
 					ctxCancel()
 				}
 
-				return nil
+				return backup.Result{}, nil
 			}
 		})
 
@@ -327,13 +397,13 @@ var _ = Describe("Launcher tests", func() {
 	When("GitHub context is canceled", func() {
 		BeforeEach(func() {
 			numCalls := 0
-			fakeBackupService.RunStub = func(context.Context, string, string, *string) error {
+			fakeBackupService.RunStub = func(context.Context, string, string, backup.Settings) (backup.Result, error) {
 				numCalls++
 				if numCalls == 6 {
 					ctxCancel()
 				}
 
-				return nil
+				return backup.Result{}, nil
 			}
 		})
 
@@ -345,4 +415,31 @@ var _ = Describe("Launcher tests", func() {
 			Expect(fakeBackupService.RunCallCount()).To(Equal(6))
 		})
 	})
+
+	When("the context is canceled with graceful stop enabled", func() {
+		var backupCtxErr error
+
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithGracefulStop()}
+			numCalls := 0
+			fakeBackupService.RunStub = func(ctx context.Context, _, _ string, _ backup.Settings) (backup.Result, error) {
+				numCalls++
+				if numCalls == 3 {
+					ctxCancel()
+					backupCtxErr = ctx.Err()
+				}
+
+				return backup.Result{}, nil
+			}
+		})
+
+		It("lets the current backup finish", func() {
+			Expect(backupCtxErr).NotTo(HaveOccurred())
+		})
+
+		It("skips the remaining targets", func() {
+			Expect(err).To(MatchError(context.Canceled))
+			Expect(fakeBackupService.RunCallCount()).To(Equal(3))
+		})
+	})
 })
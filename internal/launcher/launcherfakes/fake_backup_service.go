@@ -5,36 +5,128 @@ import (
 	"context"
 	"sync"
 
+	"github.com/AntonKosov/git-backups/internal/git/backup"
 	"github.com/AntonKosov/git-backups/internal/launcher"
 )
 
 type FakeBackupService struct {
-	RunStub        func(context.Context, string, string, *string) error
+	ActionStub        func(string) (backup.Action, error)
+	actionMutex       sync.RWMutex
+	actionArgsForCall []struct {
+		arg1 string
+	}
+	actionReturns struct {
+		result1 backup.Action
+		result2 error
+	}
+	actionReturnsOnCall map[int]struct {
+		result1 backup.Action
+		result2 error
+	}
+	RunStub        func(context.Context, string, string, backup.Settings) (backup.Result, error)
 	runMutex       sync.RWMutex
 	runArgsForCall []struct {
 		arg1 context.Context
 		arg2 string
 		arg3 string
-		arg4 *string
+		arg4 backup.Settings
 	}
 	runReturns struct {
-		result1 error
+		result1 backup.Result
+		result2 error
 	}
 	runReturnsOnCall map[int]struct {
+		result1 backup.Result
+		result2 error
+	}
+	VerifyStub        func(context.Context, string) error
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	verifyReturns struct {
+		result1 error
+	}
+	verifyReturnsOnCall map[int]struct {
 		result1 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeBackupService) Run(arg1 context.Context, arg2 string, arg3 string, arg4 *string) error {
+func (fake *FakeBackupService) Action(arg1 string) (backup.Action, error) {
+	fake.actionMutex.Lock()
+	ret, specificReturn := fake.actionReturnsOnCall[len(fake.actionArgsForCall)]
+	fake.actionArgsForCall = append(fake.actionArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ActionStub
+	fakeReturns := fake.actionReturns
+	fake.recordInvocation("Action", []interface{}{arg1})
+	fake.actionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeBackupService) ActionCallCount() int {
+	fake.actionMutex.RLock()
+	defer fake.actionMutex.RUnlock()
+	return len(fake.actionArgsForCall)
+}
+
+func (fake *FakeBackupService) ActionCalls(stub func(string) (backup.Action, error)) {
+	fake.actionMutex.Lock()
+	defer fake.actionMutex.Unlock()
+	fake.ActionStub = stub
+}
+
+func (fake *FakeBackupService) ActionArgsForCall(i int) string {
+	fake.actionMutex.RLock()
+	defer fake.actionMutex.RUnlock()
+	argsForCall := fake.actionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeBackupService) ActionReturns(result1 backup.Action, result2 error) {
+	fake.actionMutex.Lock()
+	defer fake.actionMutex.Unlock()
+	fake.ActionStub = nil
+	fake.actionReturns = struct {
+		result1 backup.Action
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackupService) ActionReturnsOnCall(i int, result1 backup.Action, result2 error) {
+	fake.actionMutex.Lock()
+	defer fake.actionMutex.Unlock()
+	fake.ActionStub = nil
+	if fake.actionReturnsOnCall == nil {
+		fake.actionReturnsOnCall = make(map[int]struct {
+			result1 backup.Action
+			result2 error
+		})
+	}
+	fake.actionReturnsOnCall[i] = struct {
+		result1 backup.Action
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackupService) Run(arg1 context.Context, arg2 string, arg3 string, arg4 backup.Settings) (backup.Result, error) {
 	fake.runMutex.Lock()
 	ret, specificReturn := fake.runReturnsOnCall[len(fake.runArgsForCall)]
 	fake.runArgsForCall = append(fake.runArgsForCall, struct {
 		arg1 context.Context
 		arg2 string
 		arg3 string
-		arg4 *string
+		arg4 backup.Settings
 	}{arg1, arg2, arg3, arg4})
 	stub := fake.RunStub
 	fakeReturns := fake.runReturns
@@ -44,9 +136,9 @@ func (fake *FakeBackupService) Run(arg1 context.Context, arg2 string, arg3 strin
 		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeBackupService) RunCallCount() int {
@@ -55,38 +147,103 @@ func (fake *FakeBackupService) RunCallCount() int {
 	return len(fake.runArgsForCall)
 }
 
-func (fake *FakeBackupService) RunCalls(stub func(context.Context, string, string, *string) error) {
+func (fake *FakeBackupService) RunCalls(stub func(context.Context, string, string, backup.Settings) (backup.Result, error)) {
 	fake.runMutex.Lock()
 	defer fake.runMutex.Unlock()
 	fake.RunStub = stub
 }
 
-func (fake *FakeBackupService) RunArgsForCall(i int) (context.Context, string, string, *string) {
+func (fake *FakeBackupService) RunArgsForCall(i int) (context.Context, string, string, backup.Settings) {
 	fake.runMutex.RLock()
 	defer fake.runMutex.RUnlock()
 	argsForCall := fake.runArgsForCall[i]
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
-func (fake *FakeBackupService) RunReturns(result1 error) {
+func (fake *FakeBackupService) RunReturns(result1 backup.Result, result2 error) {
 	fake.runMutex.Lock()
 	defer fake.runMutex.Unlock()
 	fake.RunStub = nil
 	fake.runReturns = struct {
-		result1 error
-	}{result1}
+		result1 backup.Result
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeBackupService) RunReturnsOnCall(i int, result1 error) {
+func (fake *FakeBackupService) RunReturnsOnCall(i int, result1 backup.Result, result2 error) {
 	fake.runMutex.Lock()
 	defer fake.runMutex.Unlock()
 	fake.RunStub = nil
 	if fake.runReturnsOnCall == nil {
 		fake.runReturnsOnCall = make(map[int]struct {
-			result1 error
+			result1 backup.Result
+			result2 error
 		})
 	}
 	fake.runReturnsOnCall[i] = struct {
+		result1 backup.Result
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBackupService) Verify(arg1 context.Context, arg2 string) error {
+	fake.verifyMutex.Lock()
+	ret, specificReturn := fake.verifyReturnsOnCall[len(fake.verifyArgsForCall)]
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.VerifyStub
+	fakeReturns := fake.verifyReturns
+	fake.recordInvocation("Verify", []interface{}{arg1, arg2})
+	fake.verifyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeBackupService) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *FakeBackupService) VerifyCalls(stub func(context.Context, string) error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = stub
+}
+
+func (fake *FakeBackupService) VerifyArgsForCall(i int) (context.Context, string) {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	argsForCall := fake.verifyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeBackupService) VerifyReturns(result1 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBackupService) VerifyReturnsOnCall(i int, result1 error) {
+	fake.verifyMutex.Lock()
+	defer fake.verifyMutex.Unlock()
+	fake.VerifyStub = nil
+	if fake.verifyReturnsOnCall == nil {
+		fake.verifyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.verifyReturnsOnCall[i] = struct {
 		result1 error
 	}{result1}
 }
@@ -94,8 +251,12 @@ func (fake *FakeBackupService) RunReturnsOnCall(i int, result1 error) {
 func (fake *FakeBackupService) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.actionMutex.RLock()
+	defer fake.actionMutex.RUnlock()
 	fake.runMutex.RLock()
 	defer fake.runMutex.RUnlock()
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
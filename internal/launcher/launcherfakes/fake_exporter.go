@@ -0,0 +1,122 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package launcherfakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+)
+
+type FakeExporter struct {
+	ExportStub        func(context.Context, string, string) (bundle.Bundle, error)
+	exportMutex       sync.RWMutex
+	exportArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	exportReturns struct {
+		result1 bundle.Bundle
+		result2 error
+	}
+	exportReturnsOnCall map[int]struct {
+		result1 bundle.Bundle
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeExporter) Export(arg1 context.Context, arg2 string, arg3 string) (bundle.Bundle, error) {
+	fake.exportMutex.Lock()
+	ret, specificReturn := fake.exportReturnsOnCall[len(fake.exportArgsForCall)]
+	fake.exportArgsForCall = append(fake.exportArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.ExportStub
+	fakeReturns := fake.exportReturns
+	fake.recordInvocation("Export", []interface{}{arg1, arg2, arg3})
+	fake.exportMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeExporter) ExportCallCount() int {
+	fake.exportMutex.RLock()
+	defer fake.exportMutex.RUnlock()
+	return len(fake.exportArgsForCall)
+}
+
+func (fake *FakeExporter) ExportCalls(stub func(context.Context, string, string) (bundle.Bundle, error)) {
+	fake.exportMutex.Lock()
+	defer fake.exportMutex.Unlock()
+	fake.ExportStub = stub
+}
+
+func (fake *FakeExporter) ExportArgsForCall(i int) (context.Context, string, string) {
+	fake.exportMutex.RLock()
+	defer fake.exportMutex.RUnlock()
+	argsForCall := fake.exportArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeExporter) ExportReturns(result1 bundle.Bundle, result2 error) {
+	fake.exportMutex.Lock()
+	defer fake.exportMutex.Unlock()
+	fake.ExportStub = nil
+	fake.exportReturns = struct {
+		result1 bundle.Bundle
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExporter) ExportReturnsOnCall(i int, result1 bundle.Bundle, result2 error) {
+	fake.exportMutex.Lock()
+	defer fake.exportMutex.Unlock()
+	fake.ExportStub = nil
+	if fake.exportReturnsOnCall == nil {
+		fake.exportReturnsOnCall = make(map[int]struct {
+			result1 bundle.Bundle
+			result2 error
+		})
+	}
+	fake.exportReturnsOnCall[i] = struct {
+		result1 bundle.Bundle
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExporter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.exportMutex.RLock()
+	defer fake.exportMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeExporter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ launcher.Exporter = new(FakeExporter)
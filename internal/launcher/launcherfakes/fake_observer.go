@@ -0,0 +1,203 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package launcherfakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/launcher"
+)
+
+type FakeObserver struct {
+	ProfileStartedStub        func(context.Context, string)
+	profileStartedMutex       sync.RWMutex
+	profileStartedArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	RunFinishedStub        func(context.Context, launcher.Summary)
+	runFinishedMutex       sync.RWMutex
+	runFinishedArgsForCall []struct {
+		arg1 context.Context
+		arg2 launcher.Summary
+	}
+	RunStartedStub        func(context.Context, time.Time)
+	runStartedMutex       sync.RWMutex
+	runStartedArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Time
+	}
+	TargetFinishedStub        func(context.Context, launcher.TargetResult)
+	targetFinishedMutex       sync.RWMutex
+	targetFinishedArgsForCall []struct {
+		arg1 context.Context
+		arg2 launcher.TargetResult
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeObserver) ProfileStarted(arg1 context.Context, arg2 string) {
+	fake.profileStartedMutex.Lock()
+	fake.profileStartedArgsForCall = append(fake.profileStartedArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ProfileStartedStub
+	fake.recordInvocation("ProfileStarted", []interface{}{arg1, arg2})
+	fake.profileStartedMutex.Unlock()
+	if stub != nil {
+		fake.ProfileStartedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeObserver) ProfileStartedCallCount() int {
+	fake.profileStartedMutex.RLock()
+	defer fake.profileStartedMutex.RUnlock()
+	return len(fake.profileStartedArgsForCall)
+}
+
+func (fake *FakeObserver) ProfileStartedCalls(stub func(context.Context, string)) {
+	fake.profileStartedMutex.Lock()
+	defer fake.profileStartedMutex.Unlock()
+	fake.ProfileStartedStub = stub
+}
+
+func (fake *FakeObserver) ProfileStartedArgsForCall(i int) (context.Context, string) {
+	fake.profileStartedMutex.RLock()
+	defer fake.profileStartedMutex.RUnlock()
+	argsForCall := fake.profileStartedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObserver) RunFinished(arg1 context.Context, arg2 launcher.Summary) {
+	fake.runFinishedMutex.Lock()
+	fake.runFinishedArgsForCall = append(fake.runFinishedArgsForCall, struct {
+		arg1 context.Context
+		arg2 launcher.Summary
+	}{arg1, arg2})
+	stub := fake.RunFinishedStub
+	fake.recordInvocation("RunFinished", []interface{}{arg1, arg2})
+	fake.runFinishedMutex.Unlock()
+	if stub != nil {
+		fake.RunFinishedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeObserver) RunFinishedCallCount() int {
+	fake.runFinishedMutex.RLock()
+	defer fake.runFinishedMutex.RUnlock()
+	return len(fake.runFinishedArgsForCall)
+}
+
+func (fake *FakeObserver) RunFinishedCalls(stub func(context.Context, launcher.Summary)) {
+	fake.runFinishedMutex.Lock()
+	defer fake.runFinishedMutex.Unlock()
+	fake.RunFinishedStub = stub
+}
+
+func (fake *FakeObserver) RunFinishedArgsForCall(i int) (context.Context, launcher.Summary) {
+	fake.runFinishedMutex.RLock()
+	defer fake.runFinishedMutex.RUnlock()
+	argsForCall := fake.runFinishedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObserver) RunStarted(arg1 context.Context, arg2 time.Time) {
+	fake.runStartedMutex.Lock()
+	fake.runStartedArgsForCall = append(fake.runStartedArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Time
+	}{arg1, arg2})
+	stub := fake.RunStartedStub
+	fake.recordInvocation("RunStarted", []interface{}{arg1, arg2})
+	fake.runStartedMutex.Unlock()
+	if stub != nil {
+		fake.RunStartedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeObserver) RunStartedCallCount() int {
+	fake.runStartedMutex.RLock()
+	defer fake.runStartedMutex.RUnlock()
+	return len(fake.runStartedArgsForCall)
+}
+
+func (fake *FakeObserver) RunStartedCalls(stub func(context.Context, time.Time)) {
+	fake.runStartedMutex.Lock()
+	defer fake.runStartedMutex.Unlock()
+	fake.RunStartedStub = stub
+}
+
+func (fake *FakeObserver) RunStartedArgsForCall(i int) (context.Context, time.Time) {
+	fake.runStartedMutex.RLock()
+	defer fake.runStartedMutex.RUnlock()
+	argsForCall := fake.runStartedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObserver) TargetFinished(arg1 context.Context, arg2 launcher.TargetResult) {
+	fake.targetFinishedMutex.Lock()
+	fake.targetFinishedArgsForCall = append(fake.targetFinishedArgsForCall, struct {
+		arg1 context.Context
+		arg2 launcher.TargetResult
+	}{arg1, arg2})
+	stub := fake.TargetFinishedStub
+	fake.recordInvocation("TargetFinished", []interface{}{arg1, arg2})
+	fake.targetFinishedMutex.Unlock()
+	if stub != nil {
+		fake.TargetFinishedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeObserver) TargetFinishedCallCount() int {
+	fake.targetFinishedMutex.RLock()
+	defer fake.targetFinishedMutex.RUnlock()
+	return len(fake.targetFinishedArgsForCall)
+}
+
+func (fake *FakeObserver) TargetFinishedCalls(stub func(context.Context, launcher.TargetResult)) {
+	fake.targetFinishedMutex.Lock()
+	defer fake.targetFinishedMutex.Unlock()
+	fake.TargetFinishedStub = stub
+}
+
+func (fake *FakeObserver) TargetFinishedArgsForCall(i int) (context.Context, launcher.TargetResult) {
+	fake.targetFinishedMutex.RLock()
+	defer fake.targetFinishedMutex.RUnlock()
+	argsForCall := fake.targetFinishedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeObserver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.profileStartedMutex.RLock()
+	defer fake.profileStartedMutex.RUnlock()
+	fake.runFinishedMutex.RLock()
+	defer fake.runFinishedMutex.RUnlock()
+	fake.runStartedMutex.RLock()
+	defer fake.runStartedMutex.RUnlock()
+	fake.targetFinishedMutex.RLock()
+	defer fake.targetFinishedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeObserver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ launcher.Observer = new(FakeObserver)
@@ -0,0 +1,39 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+)
+
+func List(ctx context.Context, conf config.Config, readerService ReaderService, opts ...Option) (targets []Target, listErrors error) {
+	sel, err := newSelection(conf, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, profile := range conf.Profiles.GenericProfiles {
+		if sel.profile(profile.Name) {
+			targets = append(targets, genericTargets(profile, sel)...)
+		}
+	}
+
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		if !sel.profile(profile.Name) {
+			continue
+		}
+
+		for target, err := range gitHubTargets(ctx, profile, readerService, sel) {
+			if err != nil {
+				listErrors = errors.Join(listErrors, fmt.Errorf("profile %v: %w", profile.Name, err))
+				break
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	return targets, errors.Join(listErrors, sel.unmatched())
+}
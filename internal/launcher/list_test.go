@@ -0,0 +1,75 @@
+package launcher_test
+
+import (
+	"errors"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("List tests", func() {
+	var (
+		sshKey            = "/path/to/key"
+		conf              config.Config
+		fakeReaderService *launcherfakes.FakeReaderService
+		targets           []launcher.Target
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+			for _, name := range []string{"repo_1", "repo_2", "repo_3"} {
+				if !yield(github.Repo{Name: name, Owner: "owner", SSHURL: "git@github.com:owner/" + name + ".git"}, nil) {
+					return
+				}
+			}
+		})
+
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{
+					Name:          "generic",
+					RootFolder:    "/backup/generic",
+					PrivateSSHKey: &sshKey,
+					Targets:       []config.GenericTarget{{URL: "https://example.com/repo.git", Folder: "repo"}},
+				}},
+				GitHubProfiles: []config.GitHubProfile{{
+					Name:       "github",
+					RootFolder: "/backup/github",
+					Exclude:    []string{"REPO_2"},
+				}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		targets, err = launcher.List(ctx, conf, fakeReaderService)
+	})
+
+	It("returns resolved targets after filtering", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets).To(Equal([]launcher.Target{
+			{Profile: "generic", URL: "https://example.com/repo.git", Path: "/backup/generic/repo", PrivateSSHKey: &sshKey},
+			{Profile: "github", URL: "git@github.com:owner/repo_1.git", Path: "/backup/github/owner/repo_1"},
+			{Profile: "github", URL: "git@github.com:owner/repo_3.git", Path: "/backup/github/owner/repo_3"},
+		}))
+	})
+
+	When("repositories cannot be read", func() {
+		BeforeEach(func() {
+			fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+				yield(github.Repo{}, errors.New("something went wrong"))
+			})
+		})
+
+		It("returns generic targets and an error", func() {
+			Expect(targets).To(HaveLen(1))
+			Expect(err).To(MatchError("profile github: failed to read repositories: something went wrong"))
+		})
+	})
+})
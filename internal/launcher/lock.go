@@ -0,0 +1,63 @@
+package launcher
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/lock"
+)
+
+// RunLockName is the lock file which a run keeps in the root folder of every selected profile.
+const RunLockName = ".git-backups.lock"
+
+// lockRootFolders takes the run lock of the root folders of the selected profiles, so that runs never
+// overlap. Either all locks are taken or none.
+func lockRootFolders(conf config.Config, sel *selection) (unlock func(), err error) {
+	var rootFolders []string
+	for _, profile := range conf.Profiles.GenericProfiles {
+		if sel.profile(profile.Name) {
+			rootFolders = append(rootFolders, filepath.Clean(profile.RootFolder))
+		}
+	}
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		if sel.profile(profile.Name) {
+			rootFolders = append(rootFolders, filepath.Clean(profile.RootFolder))
+		}
+	}
+	slices.Sort(rootFolders)
+
+	var locks []*lock.Lock
+	unlock = func() {
+		for _, l := range locks {
+			// A lock which failed to be removed is stale and taken over by the next run.
+			_ = l.Release()
+		}
+	}
+
+	for _, rootFolder := range slices.Compact(rootFolders) {
+		l, err := lock.Acquire(filepath.Join(rootFolder, RunLockName))
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		locks = append(locks, l)
+	}
+
+	return unlock, nil
+}
+
+// lockRepo keeps other processes from using the repository until it's unlocked. The lock is next to the
+// repository since the repository folder doesn't exist before it's cloned.
+func lockRepo(enabled bool, repo string) (unlock func(), err error) {
+	if !enabled {
+		return func() {}, nil
+	}
+
+	l, err := lock.Acquire(filepath.Clean(repo) + ".lock")
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { _ = l.Release() }, nil
+}
@@ -0,0 +1,125 @@
+package launcher_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	"github.com/AntonKosov/git-backups/internal/lock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lock tests", func() {
+	var (
+		rootFolder        string
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		fakeObserver      *launcherfakes.FakeObserver
+		locksDuringRun    []string
+		summary           launcher.Summary
+		err               error
+	)
+
+	lockFiles := func() []string {
+		var files []string
+		Expect(filepath.WalkDir(rootFolder, func(fileName string, entry os.DirEntry, err error) error {
+			if err == nil && filepath.Ext(fileName) == ".lock" {
+				files = append(files, fileName)
+			}
+			return err
+		})).To(Succeed())
+
+		return files
+	}
+
+	lockByOtherHost := func(fileName string) {
+		data, err := json.Marshal(lock.Owner{PID: 42, Host: "other-host", Acquired: time.Now()})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Dir(fileName), 0o755)).To(Succeed())
+		Expect(os.WriteFile(fileName, data, 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		rootFolder = GinkgoT().TempDir()
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeObserver = &launcherfakes.FakeObserver{}
+		locksDuringRun = nil
+		fakeBackupService.RunStub = func(context.Context, string, string, backup.Settings) (backup.Result, error) {
+			if locksDuringRun == nil {
+				locksDuringRun = lockFiles()
+			}
+			return backup.Result{}, nil
+		}
+
+		conf = config.Config{Profiles: config.Profiles{
+			GenericProfiles: []config.GenericProfile{
+				{
+					Name:       "generic",
+					RootFolder: rootFolder,
+					Targets: []config.GenericTarget{
+						{URL: "https://example.com/repo_1.git", Folder: "repo_1"},
+						{URL: "https://example.com/repo_2.git", Folder: "repo_2"},
+					},
+				},
+				{
+					Name:       "generic 2",
+					RootFolder: rootFolder,
+					Targets:    []config.GenericTarget{{URL: "https://example.com/repo_3.git", Folder: "repo_3"}},
+				},
+			},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, launcher.WithLocking(), launcher.WithObservers(fakeObserver))
+		_, summary = fakeObserver.RunFinishedArgsForCall(0)
+	})
+
+	It("locks the root folder and the repository while it's backed up", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeBackupService.RunCallCount()).To(Equal(3))
+		Expect(locksDuringRun).To(ConsistOf(
+			filepath.Join(rootFolder, launcher.RunLockName),
+			filepath.Join(rootFolder, "repo_1.lock"),
+		))
+		Expect(lockFiles()).To(BeEmpty())
+	})
+
+	When("another run holds the root folder", func() {
+		BeforeEach(func() {
+			lockByOtherHost(filepath.Join(rootFolder, launcher.RunLockName))
+		})
+
+		It("doesn't start", func() {
+			Expect(err).To(MatchError(lock.ErrLocked))
+			Expect(err).To(MatchError(ContainSubstring("failed to start the run: locked by another process: " +
+				filepath.Join(rootFolder, launcher.RunLockName) + " is held by process 42 on other-host since")))
+			Expect(fakeBackupService.RunCallCount()).To(BeZero())
+			Expect(lockFiles()).To(HaveLen(1))
+		})
+	})
+
+	When("another process holds a repository", func() {
+		BeforeEach(func() {
+			lockByOtherHost(filepath.Join(rootFolder, "repo_2.lock"))
+		})
+
+		It("backs up the other repositories", func() {
+			Expect(err).To(MatchError(lock.ErrLocked))
+			Expect(fakeBackupService.RunCallCount()).To(Equal(2))
+			Expect(summary.Failed()).To(HaveLen(1))
+			Expect(summary.Failed()[0].Target.Path).To(Equal(filepath.Join(rootFolder, "repo_2")))
+			Expect(backup.ClassifyError(summary.Failed()[0].Err)).To(Equal(backup.ErrorClassLocked))
+			Expect(lockFiles()).To(Equal([]string{filepath.Join(rootFolder, "repo_2.lock")}))
+		})
+	})
+})
@@ -0,0 +1,177 @@
+package launcher
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/pattern"
+)
+
+type Options struct {
+	profiles      []string
+	repos         []string
+	observers     []Observer
+	exporter      Exporter
+	gracefulStop  bool
+	locking       bool
+	samplePercent int
+	sampleDay     time.Time
+}
+
+type Option func(*Options)
+
+func newOptions(opts []Option) Options {
+	var options Options
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return options
+}
+
+func WithObservers(observers ...Observer) Option {
+	return func(o *Options) {
+		o.observers = append(o.observers, observers...)
+	}
+}
+
+// WithExporter bundles every successfully backed up repository.
+func WithExporter(exporter Exporter) Option {
+	return func(o *Options) {
+		o.exporter = exporter
+	}
+}
+
+// WithGracefulStop lets the repository being backed up finish when the context is canceled. Remaining
+// repositories are skipped as usual.
+func WithGracefulStop() Option {
+	return func(o *Options) {
+		o.gracefulStop = true
+	}
+}
+
+// WithLocking takes a lock in the root folder of every selected profile for the whole run, so that runs
+// never overlap, and a lock next to every repository while it's used. Locks of processes which no longer
+// run on this host are taken over.
+func WithLocking() Option {
+	return func(o *Options) {
+		o.locking = true
+	}
+}
+
+// WithSample limits verification to the share of repositories due on the day, so that every repository
+// is verified once in 100/percent days.
+func WithSample(percent int, day time.Time) Option {
+	return func(o *Options) {
+		o.samplePercent = percent
+		o.sampleDay = day
+	}
+}
+
+func WithProfiles(names ...string) Option {
+	return func(o *Options) {
+		o.profiles = append(o.profiles, names...)
+	}
+}
+
+// WithRepos limits the run to repositories matching the patterns, which have the syntax of include and
+// exclude patterns. The folder of a generic target is matched like "owner/name" of a GitHub repository.
+func WithRepos(patterns ...string) Option {
+	return func(o *Options) {
+		o.repos = append(o.repos, patterns...)
+	}
+}
+
+type selection struct {
+	profiles     map[string]bool
+	repos        pattern.List
+	matchedRepos []bool
+}
+
+func newSelection(conf config.Config, options Options) (*selection, error) {
+	s := &selection{repos: make(pattern.List, 0, len(options.repos))}
+
+	var errs error
+	for _, text := range options.repos {
+		p, err := pattern.Compile(text)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("invalid repository filter: %w", err))
+			continue
+		}
+		s.repos = append(s.repos, p)
+	}
+	s.matchedRepos = make([]bool, len(s.repos))
+
+	if len(options.profiles) > 0 {
+		s.profiles = make(map[string]bool, len(options.profiles))
+		for _, name := range options.profiles {
+			s.profiles[name] = true
+		}
+
+		known := map[string]bool{}
+		for _, profile := range conf.Profiles.GenericProfiles {
+			known[profile.Name] = true
+		}
+		for _, profile := range conf.Profiles.GitHubProfiles {
+			known[profile.Name] = true
+		}
+
+		for _, name := range options.profiles {
+			if !known[name] {
+				errs = errors.Join(errs, fmt.Errorf("profile filter %q matches no profile", name))
+			}
+		}
+	}
+
+	return s, errs
+}
+
+func (s *selection) profile(name string) bool {
+	return s.profiles == nil || s.profiles[name]
+}
+
+func (s *selection) repo(owner, name string) bool {
+	if len(s.repos) == 0 {
+		return true
+	}
+
+	for i, p := range s.repos {
+		if p.Match(owner, name) {
+			s.matchedRepos[i] = true
+		}
+	}
+
+	return s.repos.Match(owner, name)
+}
+
+// folder selects a repository by its folder relative to the root folder of the profile.
+func (s *selection) folder(folder string) bool {
+	owner, name := path.Split(path.Clean(folder))
+
+	return s.repo(strings.TrimSuffix(owner, "/"), name)
+}
+
+func (s *selection) repoFilter() repoFilter {
+	return func(repo github.Repo) string {
+		if !s.repo(repo.Owner, repo.Name) {
+			return "does not match the repository filter"
+		}
+
+		return ""
+	}
+}
+
+func (s *selection) unmatched() (errs error) {
+	for i, matched := range s.matchedRepos {
+		if !matched {
+			errs = errors.Join(errs, fmt.Errorf("repository filter %q matches no repository", s.repos[i]))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,161 @@
+package launcher_test
+
+import (
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filter options tests", func() {
+	var (
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		opts              []launcher.Option
+		err               error
+	)
+
+	calledURLs := func() []string {
+		urls := make([]string, fakeBackupService.RunCallCount())
+		for i := range urls {
+			_, urls[i], _, _ = fakeBackupService.RunArgsForCall(i)
+		}
+
+		return urls
+	}
+
+	BeforeEach(func() {
+		opts = nil
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+			for _, repo := range []github.Repo{
+				{Name: "service-a", Owner: "org", SSHURL: "git@github.com:org/service-a.git"},
+				{Name: "Service-B", Owner: "org", SSHURL: "git@github.com:org/Service-B.git"},
+				{Name: "tool", Owner: "user", SSHURL: "git@github.com:user/tool.git"},
+			} {
+				if !yield(repo, nil) {
+					return
+				}
+			}
+		})
+
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{
+					Name:       "generic",
+					RootFolder: "/backup/generic",
+					Targets: []config.GenericTarget{
+						{URL: "https://example.com/service-c.git", Folder: "service-c"},
+						{URL: "https://example.com/other.git", Folder: "other"},
+					},
+				}},
+				GitHubProfiles: []config.GitHubProfile{{Name: "github", RootFolder: "/backup/github"}},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, opts...)
+	})
+
+	When("no filters are given", func() {
+		It("backs up everything", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeBackupService.RunCallCount()).To(Equal(5))
+		})
+	})
+
+	When("a profile is selected", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithProfiles("github")}
+		})
+
+		It("backs up the profile only", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calledURLs()).To(Equal([]string{
+				"git@github.com:org/service-a.git",
+				"git@github.com:org/Service-B.git",
+				"git@github.com:user/tool.git",
+			}))
+			Expect(fakeReaderService.AllReposCallCount()).To(Equal(1))
+		})
+	})
+
+	When("repositories are selected", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithRepos("service-*", "user/tool")}
+		})
+
+		It("backs up matching generic targets and GitHub repositories", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calledURLs()).To(Equal([]string{
+				"https://example.com/service-c.git",
+				"git@github.com:org/service-a.git",
+				"git@github.com:org/Service-B.git",
+				"git@github.com:user/tool.git",
+			}))
+		})
+	})
+
+	When("repositories are selected with regular expressions and negations", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithRepos("regex:^service-", "!org/*-b")}
+		})
+
+		It("backs up matching repositories except the negated ones", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calledURLs()).To(Equal([]string{
+				"https://example.com/service-c.git",
+				"git@github.com:org/service-a.git",
+			}))
+		})
+	})
+
+	When("profile and repository filters are combined", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithProfiles("github"), launcher.WithRepos("org/*-b")}
+		})
+
+		It("backs up the matching repository", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calledURLs()).To(Equal([]string{"git@github.com:org/Service-B.git"}))
+		})
+	})
+
+	When("a profile filter matches nothing", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithProfiles("github", "missing")}
+		})
+
+		It("returns an error without running backups", func() {
+			Expect(err).To(MatchError(`profile filter "missing" matches no profile`))
+			Expect(fakeBackupService.RunCallCount()).To(BeZero())
+		})
+	})
+
+	When("a repository filter matches nothing", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithRepos("tool", "missing-*")}
+		})
+
+		It("backs up matching repositories and returns an error", func() {
+			Expect(err).To(MatchError(`repository filter "missing-*" matches no repository`))
+			Expect(calledURLs()).To(Equal([]string{"git@github.com:user/tool.git"}))
+		})
+	})
+
+	When("a repository filter is invalid", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithRepos("[")}
+		})
+
+		It("returns an error without running backups", func() {
+			Expect(err).To(MatchError(ContainSubstring(`invalid repository filter: invalid pattern "["`)))
+			Expect(fakeBackupService.RunCallCount()).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package launcher
+
+import (
+	"fmt"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/pattern"
+)
+
+type repoOverride struct {
+	config.RepoOverride
+	repos pattern.List
+}
+
+type repoOverrides []repoOverride
+
+func compileOverrides(overrides []config.RepoOverride) (repoOverrides, error) {
+	compiled := make(repoOverrides, len(overrides))
+	for i, override := range overrides {
+		repos, err := pattern.CompileList(override.Repos)
+		if err != nil {
+			return nil, fmt.Errorf("invalid override: %w", err)
+		}
+		compiled[i] = repoOverride{RepoOverride: override, repos: repos}
+	}
+
+	return compiled, nil
+}
+
+// apply returns the target with the settings of the last override which matches the repository. The
+// settings of the target are kept if none matches.
+func (o repoOverrides) apply(repo github.Repo, target Target) Target {
+	for i := len(o) - 1; i >= 0; i-- {
+		if !o[i].repos.Match(repo.Owner, repo.Name) {
+			continue
+		}
+
+		if o[i].PrivateSSHKey != nil {
+			target.PrivateSSHKey = o[i].PrivateSSHKey
+		}
+		target.Maintenance = o[i].Maintenance
+		target.Partial = o[i].Partial
+		target.MaxRepoSize = o[i].MaxRepoSize
+		target.Timeout = o[i].Timeout
+		target.LFS = o[i].LFS
+		target.Schedule = o[i].Schedule
+
+		return target
+	}
+
+	return target
+}
@@ -0,0 +1,141 @@
+package launcher
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/redact"
+)
+
+type Plan struct {
+	Steps   []PlanStep    `json:"steps"`
+	Skipped []SkippedRepo `json:"skipped"`
+}
+
+type PlanStep struct {
+	Profile       string        `json:"profile"`
+	URL           string        `json:"url"`
+	Path          string        `json:"path"`
+	Action        backup.Action `json:"action"`
+	PrivateSSHKey *string       `json:"private_ssh_key"`
+}
+
+type SkippedRepo struct {
+	Profile string `json:"profile"`
+	Repo    string `json:"repo"`
+	URL     string `json:"url"`
+	Path    string `json:"path"`
+	Reason  string `json:"reason"`
+}
+
+// DryRun resolves what Run would do without running git.
+func DryRun(ctx context.Context, conf config.Config, backupService BackupService, readerService ReaderService, opts ...Option) (plan Plan, planErrors error) {
+	plan = Plan{Steps: []PlanStep{}, Skipped: []SkippedRepo{}}
+	now := time.Now()
+	sel, err := newSelection(conf, newOptions(opts))
+	if err != nil {
+		return plan, err
+	}
+
+	addStep := func(target Target) {
+		action, err := backupService.Action(target.Path)
+		if err != nil {
+			planErrors = errors.Join(planErrors, fmt.Errorf("failed to check folder %v: %w", target.Path, err))
+			return
+		}
+
+		plan.Steps = append(plan.Steps, PlanStep{
+			Profile:       target.Profile,
+			URL:           target.URL,
+			Path:          target.Path,
+			Action:        action,
+			PrivateSSHKey: target.PrivateSSHKey,
+		})
+	}
+
+	for _, profile := range conf.Profiles.GenericProfiles {
+		if !sel.profile(profile.Name) {
+			continue
+		}
+
+		targets, skipped := withoutSkipped(profile, genericTargets(profile, sel), now)
+		plan.Skipped = append(plan.Skipped, skipped...)
+		for _, target := range targets {
+			addStep(target)
+		}
+	}
+
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		if !sel.profile(profile.Name) {
+			continue
+		}
+
+		for candidate, err := range gitHubCandidates(ctx, profile, readerService, sel) {
+			if err != nil {
+				planErrors = errors.Join(planErrors, fmt.Errorf("profile %v: %w", profile.Name, err))
+				break
+			}
+
+			candidate.skipReason = cmp.Or(candidate.skipReason, notDue(candidate.target, now))
+			if candidate.skipReason != "" {
+				plan.Skipped = append(plan.Skipped, candidate.skipped())
+				continue
+			}
+
+			addStep(candidate.target)
+		}
+	}
+
+	return plan, errors.Join(planErrors, sel.unmatched())
+}
+
+// redacted returns a copy of the plan without credentials in the URLs, which are written to stdout.
+func (p Plan) redacted() Plan {
+	redacted := Plan{Steps: slices.Clone(p.Steps), Skipped: slices.Clone(p.Skipped)}
+	for i := range redacted.Steps {
+		redacted.Steps[i].URL = redact.String(redacted.Steps[i].URL)
+	}
+	for i := range redacted.Skipped {
+		redacted.Skipped[i].URL = redact.String(redacted.Skipped[i].URL)
+	}
+
+	return redacted
+}
+
+func (p Plan) WriteText(w io.Writer) error {
+	p = p.redacted()
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PROFILE\tACTION\tURL\tPATH\tSSH KEY")
+	for _, step := range p.Steps {
+		sshKey := "-"
+		if step.PrivateSSHKey != nil {
+			sshKey = *step.PrivateSSHKey
+		}
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\n", step.Profile, step.Action, step.URL, step.Path, sshKey)
+	}
+
+	if len(p.Skipped) > 0 {
+		fmt.Fprintln(writer, "\nSKIPPED\tREPOSITORY\tREASON")
+		for _, skipped := range p.Skipped {
+			fmt.Fprintf(writer, "%v\t%v\t%v\n", skipped.Profile, skipped.Repo, skipped.Reason)
+		}
+	}
+
+	return writer.Flush()
+}
+
+func (p Plan) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(p.redacted())
+}
@@ -0,0 +1,42 @@
+package launcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/schedule"
+)
+
+// notDue returns the reason to skip a repository whose schedule doesn't allow another backup yet.
+// Repositories without a schedule or a previous backup are always due. An interval may end a tenth
+// early, so that a repository which was backed up late in the previous run isn't skipped by a run of a
+// regular schedule.
+func notDue(target Target, now time.Time) string {
+	if target.Schedule.IsZero() {
+		return ""
+	}
+
+	metadata, err := backup.ReadMetadata(target.Path)
+	if err != nil || metadata.LastBackup.IsZero() {
+		return ""
+	}
+
+	next := metadata.LastBackup.Add(target.Schedule.Interval - target.Schedule.Interval/10)
+	if target.Schedule.Cron != "" {
+		cron, err := schedule.ParseCron(target.Schedule.Cron)
+		if err != nil {
+			return ""
+		}
+		next = cron.Next(metadata.LastBackup.Local())
+		if next.IsZero() {
+			return fmt.Sprintf("schedule %q has no further backups", target.Schedule.Cron)
+		}
+	}
+
+	if next.After(now) {
+		return fmt.Sprintf("not due until %v", next.Local().Format(time.RFC3339))
+	}
+
+	return ""
+}
@@ -0,0 +1,132 @@
+package launcher_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schedule tests", func() {
+	var (
+		rootFolder        string
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		fakeObserver      *launcherfakes.FakeObserver
+		summary           launcher.Summary
+		err               error
+	)
+
+	backedUp := func(path string, ago time.Duration) {
+		Expect(backup.Metadata{LastBackup: time.Now().Add(-ago)}.WriteFile(path)).To(Succeed())
+	}
+
+	backedUpPaths := func() []string {
+		var paths []string
+		for i := range fakeBackupService.RunCallCount() {
+			_, _, path, _ := fakeBackupService.RunArgsForCall(i)
+			paths = append(paths, path)
+		}
+
+		return paths
+	}
+
+	BeforeEach(func() {
+		rootFolder = GinkgoT().TempDir()
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+			for _, name := range []string{"daily", "weekly"} {
+				if !yield(github.Repo{Name: name, Owner: "owner", SSHURL: "git@github.com:owner/" + name + ".git"}, nil) {
+					return
+				}
+			}
+		})
+		fakeObserver = &launcherfakes.FakeObserver{}
+
+		conf = config.Config{Profiles: config.Profiles{
+			GenericProfiles: []config.GenericProfile{{
+				Name:       "generic",
+				RootFolder: filepath.Join(rootFolder, "generic"),
+				Schedule:   config.RepoSchedule{Interval: 24 * time.Hour},
+				Targets: []config.GenericTarget{
+					{URL: "https://example.com/daily.git", Folder: "daily", Schedule: config.RepoSchedule{Interval: 24 * time.Hour}},
+					{URL: "https://example.com/weekly.git", Folder: "weekly", Schedule: config.RepoSchedule{Cron: "0 3 * * 0"}},
+				},
+			}},
+			GitHubProfiles: []config.GitHubProfile{{
+				Name:       "github",
+				RootFolder: filepath.Join(rootFolder, "github"),
+				Schedule:   config.RepoSchedule{Interval: 24 * time.Hour},
+				Overrides: []config.RepoOverride{
+					{Repos: []string{"weekly"}, Schedule: config.RepoSchedule{Interval: 7 * 24 * time.Hour}},
+				},
+			}},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, launcher.WithObservers(fakeObserver))
+		_, summary = fakeObserver.RunFinishedArgsForCall(0)
+	})
+
+	It("backs up repositories which were never backed up", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeBackupService.RunCallCount()).To(Equal(4))
+		Expect(summary.Skipped).To(BeEmpty())
+	})
+
+	When("the repositories were just backed up", func() {
+		BeforeEach(func() {
+			for _, path := range []string{"generic/daily", "generic/weekly", "github/owner/daily", "github/owner/weekly"} {
+				backedUp(filepath.Join(rootFolder, path), 0)
+			}
+		})
+
+		It("skips them until they are due", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeBackupService.RunCallCount()).To(BeZero())
+			Expect(summary.Skipped).To(HaveLen(4))
+			Expect(summary.Skipped[0].Reason).To(HavePrefix("not due until "))
+		})
+	})
+
+	When("the repositories were backed up two days ago", func() {
+		BeforeEach(func() {
+			for _, path := range []string{"generic/daily", "github/owner/daily", "github/owner/weekly"} {
+				backedUp(filepath.Join(rootFolder, path), 48*time.Hour)
+			}
+		})
+
+		It("backs up only the repositories which are due", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backedUpPaths()).To(ConsistOf(
+				filepath.Join(rootFolder, "generic/daily"),
+				filepath.Join(rootFolder, "generic/weekly"),
+				filepath.Join(rootFolder, "github/owner/daily"),
+			))
+			Expect(summary.Skipped).To(HaveLen(1))
+			Expect(summary.Skipped[0].Path).To(Equal(filepath.Join(rootFolder, "github/owner/weekly")))
+		})
+	})
+
+	When("the repositories were backed up a little less than a day ago", func() {
+		BeforeEach(func() {
+			backedUp(filepath.Join(rootFolder, "generic/daily"), 23*time.Hour)
+			backedUp(filepath.Join(rootFolder, "github/owner/daily"), time.Hour)
+		})
+
+		It("tolerates runs which start a little earlier than the previous one", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backedUpPaths()).NotTo(ContainElement(filepath.Join(rootFolder, "github/owner/daily")))
+			Expect(backedUpPaths()).To(ContainElement(filepath.Join(rootFolder, "generic/daily")))
+		})
+	})
+})
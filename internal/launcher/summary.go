@@ -0,0 +1,66 @@
+package launcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+)
+
+//counterfeiter:generate . Observer
+type Observer interface {
+	RunStarted(ctx context.Context, started time.Time)
+	// ProfileStarted is called before the targets of a selected profile are listed and backed up.
+	ProfileStarted(ctx context.Context, profile string)
+	TargetFinished(ctx context.Context, result TargetResult)
+	RunFinished(ctx context.Context, summary Summary)
+}
+
+type TargetResult struct {
+	Target   Target
+	Started  time.Time
+	Duration time.Duration
+	// Backup describes the git operation. Its action is empty if the backup failed before git was run.
+	Backup backup.Result
+	// Verified is set if the integrity of the repository was checked after the backup.
+	Verified bool
+	Err      error
+}
+
+type ProfileResult struct {
+	Name string
+	// Discovered is the number of targets defined in the config or found by the GitHub API.
+	Discovered int
+	// Selected is the number of targets left after filtering.
+	Selected int
+	Err      error
+}
+
+// OrphanedRepo is a backup which no longer belongs to any target of its profile, e.g. because the
+// repository was deleted or renamed on GitHub or removed from the config.
+type OrphanedRepo struct {
+	Profile string
+	Path    string
+}
+
+type Summary struct {
+	Started  time.Time
+	Duration time.Duration
+	Profiles []ProfileResult
+	Targets  []TargetResult
+	Skipped  []SkippedRepo
+	Orphaned []OrphanedRepo
+	// Err is the error returned by Run.
+	Err error
+}
+
+func (s Summary) Failed() []TargetResult {
+	var failed []TargetResult
+	for _, result := range s.Targets {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
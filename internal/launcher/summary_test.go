@@ -0,0 +1,155 @@
+package launcher_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Observer tests", func() {
+	var (
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		fakeObserver      *launcherfakes.FakeObserver
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeObserver = &launcherfakes.FakeObserver{}
+
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{
+					{
+						Name:       "generic",
+						RootFolder: "/backups/generic",
+						Targets: []config.GenericTarget{
+							{URL: "https://example.com/repo_1.git", Folder: "repo_1"},
+							{URL: "https://example.com/repo_2.git", Folder: "repo_2"},
+						},
+					},
+				},
+				GitHubProfiles: []config.GitHubProfile{
+					{
+						Name:       "github",
+						RootFolder: "/backups/github",
+						Exclude:    []string{"excluded"},
+					},
+				},
+			},
+		}
+
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+			for _, name := range []string{"included", "excluded"} {
+				if !yield(github.Repo{Owner: "owner", Name: name, SSHURL: "git:" + name}, nil) {
+					return
+				}
+			}
+		})
+		fakeBackupService.RunReturnsOnCall(0, backup.Result{Action: backup.ActionFetch, RefsBefore: 2, RefsAfter: 3}, nil)
+		fakeBackupService.RunReturnsOnCall(1, backup.Result{}, errors.New("something went wrong"))
+	})
+
+	JustBeforeEach(func() {
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, launcher.WithObservers(fakeObserver))
+	})
+
+	It("returns an error", func() {
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("notifies about the start of the run", func() {
+		Expect(fakeObserver.RunStartedCallCount()).To(Equal(1))
+	})
+
+	It("notifies about every target", func() {
+		Expect(fakeObserver.TargetFinishedCallCount()).To(Equal(3))
+
+		_, result := fakeObserver.TargetFinishedArgsForCall(0)
+		Expect(result.Target).To(Equal(launcher.Target{
+			Profile: "generic", URL: "https://example.com/repo_1.git", Path: "/backups/generic/repo_1",
+		}))
+		Expect(result.Backup).To(Equal(backup.Result{Action: backup.ActionFetch, RefsBefore: 2, RefsAfter: 3}))
+		Expect(result.Err).NotTo(HaveOccurred())
+
+		_, result = fakeObserver.TargetFinishedArgsForCall(1)
+		Expect(result.Target.Path).To(Equal("/backups/generic/repo_2"))
+		Expect(result.Err).To(MatchError(ContainSubstring("something went wrong")))
+
+		_, result = fakeObserver.TargetFinishedArgsForCall(2)
+		Expect(result.Target.Path).To(Equal("/backups/github/owner/included"))
+		Expect(result.Err).NotTo(HaveOccurred())
+	})
+
+	It("reports the summary", func() {
+		Expect(fakeObserver.RunFinishedCallCount()).To(Equal(1))
+		_, summary := fakeObserver.RunFinishedArgsForCall(0)
+		Expect(summary.Err).To(Equal(err))
+		Expect(summary.Targets).To(HaveLen(3))
+		Expect(summary.Failed()).To(HaveLen(1))
+		Expect(summary.Profiles).To(Equal([]launcher.ProfileResult{
+			{Name: "generic", Discovered: 2, Selected: 2},
+			{Name: "github", Discovered: 2, Selected: 1},
+		}))
+		Expect(summary.Skipped).To(Equal([]launcher.SkippedRepo{
+			{Profile: "github", Repo: "owner/excluded", URL: "git:excluded", Path: "/backups/github/owner/excluded", Reason: "in the exclude list"},
+		}))
+	})
+
+	When("root folders contain repositories which are no longer targets", func() {
+		var genericRoot, gitHubRoot string
+
+		var createRepo = func(folder string) {
+			Expect(os.MkdirAll(filepath.Join(folder, "objects"), 0o755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(folder, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			genericRoot = GinkgoT().TempDir()
+			gitHubRoot = GinkgoT().TempDir()
+			conf.Profiles.GenericProfiles[0].RootFolder = genericRoot
+			conf.Profiles.GitHubProfiles[0].RootFolder = gitHubRoot
+
+			createRepo(filepath.Join(genericRoot, "repo_1"))
+			createRepo(filepath.Join(genericRoot, "removed"))
+			createRepo(filepath.Join(genericRoot, "nested", "removed"))
+			Expect(os.MkdirAll(filepath.Join(genericRoot, "not_a_repo"), 0o755)).To(Succeed())
+			createRepo(filepath.Join(gitHubRoot, "owner", "included"))
+			createRepo(filepath.Join(gitHubRoot, "owner", "excluded"))
+			createRepo(filepath.Join(gitHubRoot, "owner", "deleted"))
+		})
+
+		It("reports orphaned repositories", func() {
+			_, summary := fakeObserver.RunFinishedArgsForCall(0)
+			Expect(summary.Orphaned).To(ConsistOf(
+				launcher.OrphanedRepo{Profile: "generic", Path: filepath.Join(genericRoot, "removed")},
+				launcher.OrphanedRepo{Profile: "generic", Path: filepath.Join(genericRoot, "nested", "removed")},
+				launcher.OrphanedRepo{Profile: "github", Path: filepath.Join(gitHubRoot, "owner", "deleted")},
+			))
+		})
+
+		When("repositories cannot be listed", func() {
+			BeforeEach(func() {
+				fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {
+					yield(github.Repo{}, errors.New("something went wrong"))
+				})
+			})
+
+			It("does not report repositories of the profile", func() {
+				_, summary := fakeObserver.RunFinishedArgsForCall(0)
+				Expect(summary.Orphaned).To(HaveLen(2))
+			})
+		})
+	})
+})
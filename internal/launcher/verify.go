@@ -0,0 +1,136 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+)
+
+type VerifiedRepo struct {
+	Profile string
+	Path    string
+	Err     error
+}
+
+type Verification struct {
+	// Found is the number of repositories in the root folders before sampling.
+	Found int
+	Repos []VerifiedRepo
+}
+
+func (v Verification) Failed() []VerifiedRepo {
+	var failed []VerifiedRepo
+	for _, repo := range v.Repos {
+		if repo.Err != nil {
+			failed = append(failed, repo)
+		}
+	}
+
+	return failed
+}
+
+// Verify checks the integrity of the repositories found in the root folders of the selected profiles,
+// including the ones which no longer belong to any target.
+func Verify(ctx context.Context, conf config.Config, backupService BackupService, opts ...Option) (verification Verification, verifyErrors error) {
+	options := newOptions(opts)
+	sel, err := newSelection(conf, options)
+	if err != nil {
+		return verification, err
+	}
+
+	for _, repo := range storedRepos(conf, sel) {
+		verification.Found++
+		if !due(repo.path, options.samplePercent, options.sampleDay) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return verification, errors.Join(verifyErrors, context.Canceled)
+		default:
+		}
+
+		ctx := clog.Add(ctx, "profile", repo.profile)
+		result := VerifiedRepo{Profile: repo.profile, Path: repo.path}
+		if err := verifyLocked(ctx, backupService, options.locking, repo.path); err != nil {
+			slog.ErrorContext(ctx, "Failed to verify", "target_folder", repo.path, "error", err)
+			result.Err = fmt.Errorf("failed to verify repository %v from profile %v: %w", repo.path, repo.profile, err)
+			verifyErrors = errors.Join(verifyErrors, result.Err)
+		}
+		verification.Repos = append(verification.Repos, result)
+	}
+
+	return verification, errors.Join(verifyErrors, sel.unmatched())
+}
+
+func verifyLocked(ctx context.Context, backupService BackupService, locking bool, repo string) error {
+	unlock, err := lockRepo(locking, repo)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return backupService.Verify(ctx, repo)
+}
+
+type storedRepo struct {
+	profile string
+	path    string
+}
+
+// storedRepos returns the selected repositories found in the root folders of the selected profiles,
+// including the ones which no longer belong to any target. Profiles may share a root folder, so every
+// repository is returned once.
+func storedRepos(conf config.Config, sel *selection) []storedRepo {
+	type profileRoot struct{ name, rootFolder string }
+	var profiles []profileRoot
+	for _, profile := range conf.Profiles.GenericProfiles {
+		profiles = append(profiles, profileRoot{name: profile.Name, rootFolder: profile.RootFolder})
+	}
+	for _, profile := range conf.Profiles.GitHubProfiles {
+		profiles = append(profiles, profileRoot{name: profile.Name, rootFolder: profile.RootFolder})
+	}
+
+	var repos []storedRepo
+	seen := map[string]bool{}
+	for _, profile := range profiles {
+		if !sel.profile(profile.name) {
+			continue
+		}
+
+		for _, repo := range backup.FindRepos(profile.rootFolder, seen) {
+			seen[path.Clean(filepath.ToSlash(repo))] = true
+			relative, _ := filepath.Rel(profile.rootFolder, repo)
+			if sel.folder(filepath.ToSlash(relative)) {
+				repos = append(repos, storedRepo{profile: profile.name, path: repo})
+			}
+		}
+	}
+
+	return repos
+}
+
+// due reports whether the repository is verified on the day when only a share of repositories is
+// verified at a time. Every repository is due once in 100/percent days.
+func due(repo string, percent int, day time.Time) bool {
+	if percent <= 0 || percent >= 100 {
+		return true
+	}
+
+	period := uint64((100 + percent - 1) / percent)
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(path.Clean(filepath.ToSlash(repo))))
+	year, month, date := day.Date()
+	days := uint64(time.Date(year, month, date, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60))
+
+	return hash.Sum64()%period == days%period
+}
@@ -0,0 +1,185 @@
+package launcher_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/launcher/launcherfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verify tests", func() {
+	var (
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		genericRoot       string
+		gitHubRoot        string
+		opts              []launcher.Option
+		verification      launcher.Verification
+		err               error
+	)
+
+	var createRepo = func(folder string) {
+		Expect(os.MkdirAll(filepath.Join(folder, "objects"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(folder, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644)).To(Succeed())
+	}
+
+	var verifiedPaths = func(verification launcher.Verification) []string {
+		var paths []string
+		for _, repo := range verification.Repos {
+			paths = append(paths, repo.Path)
+		}
+		return paths
+	}
+
+	BeforeEach(func() {
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		genericRoot = GinkgoT().TempDir()
+		gitHubRoot = GinkgoT().TempDir()
+		opts = nil
+
+		conf = config.Config{
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{Name: "generic", RootFolder: genericRoot}},
+				GitHubProfiles: []config.GitHubProfile{
+					{Name: "github", RootFolder: gitHubRoot},
+					{Name: "github 2", RootFolder: gitHubRoot},
+				},
+			},
+		}
+
+		createRepo(filepath.Join(genericRoot, "repo_1"))
+		createRepo(filepath.Join(genericRoot, "nested", "repo_2"))
+		createRepo(filepath.Join(gitHubRoot, "owner", "repo_3"))
+		fakeBackupService.VerifyStub = func(_ context.Context, path string) error {
+			if filepath.Base(path) == "repo_2" {
+				return errors.New("the repository is corrupted")
+			}
+			return nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		verification, err = launcher.Verify(ctx, conf, fakeBackupService, opts...)
+	})
+
+	It("verifies every repository in the root folders once", func() {
+		Expect(verification.Found).To(Equal(3))
+		Expect(verifiedPaths(verification)).To(ConsistOf(
+			filepath.Join(genericRoot, "repo_1"),
+			filepath.Join(genericRoot, "nested", "repo_2"),
+			filepath.Join(gitHubRoot, "owner", "repo_3"),
+		))
+	})
+
+	It("reports corrupted repositories", func() {
+		Expect(err).To(MatchError(ContainSubstring("failed to verify repository " + filepath.Join(genericRoot, "nested", "repo_2") + " from profile generic: the repository is corrupted")))
+		Expect(verification.Failed()).To(HaveLen(1))
+		Expect(verification.Failed()[0].Profile).To(Equal("generic"))
+	})
+
+	When("repositories are filtered", func() {
+		BeforeEach(func() {
+			opts = []launcher.Option{launcher.WithProfiles("github"), launcher.WithRepos("owner/*")}
+		})
+
+		It("verifies the selected repositories", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(verifiedPaths(verification)).To(Equal([]string{filepath.Join(gitHubRoot, "owner", "repo_3")}))
+		})
+	})
+
+	When("a sample is given", func() {
+		It("verifies every repository once within the period", func() {
+			day := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local)
+			counts := map[string]int{}
+			for i := range 4 {
+				verification, _ := launcher.Verify(ctx, conf, fakeBackupService, launcher.WithSample(25, day.AddDate(0, 0, i)))
+				Expect(verification.Found).To(Equal(3))
+				for _, path := range verifiedPaths(verification) {
+					counts[path]++
+				}
+			}
+
+			Expect(counts).To(HaveLen(3))
+			for _, count := range counts {
+				Expect(count).To(Equal(1))
+			}
+		})
+	})
+})
+
+var _ = Describe("Verification after backup", func() {
+	var (
+		conf              config.Config
+		fakeBackupService *launcherfakes.FakeBackupService
+		fakeReaderService *launcherfakes.FakeReaderService
+		fakeObserver      *launcherfakes.FakeObserver
+		err               error
+	)
+
+	BeforeEach(func() {
+		fakeBackupService = &launcherfakes.FakeBackupService{}
+		fakeReaderService = &launcherfakes.FakeReaderService{}
+		fakeObserver = &launcherfakes.FakeObserver{}
+		fakeReaderService.AllReposReturns(func(yield func(github.Repo, error) bool) {})
+		conf = config.Config{
+			Verify: config.Verify{AfterBackup: true},
+			Profiles: config.Profiles{
+				GenericProfiles: []config.GenericProfile{{
+					Name:       "generic",
+					RootFolder: "/backups/generic",
+					Targets: []config.GenericTarget{
+						{URL: "https://example.com/repo_1.git", Folder: "repo_1"},
+						{URL: "https://example.com/repo_2.git", Folder: "repo_2"},
+					},
+				}},
+			},
+		}
+		fakeBackupService.VerifyReturnsOnCall(1, errors.New("the repository is corrupted"))
+	})
+
+	JustBeforeEach(func() {
+		err = launcher.Run(ctx, conf, fakeBackupService, fakeReaderService, launcher.WithObservers(fakeObserver))
+	})
+
+	It("verifies backed up repositories", func() {
+		Expect(fakeBackupService.VerifyCallCount()).To(Equal(2))
+		Expect(err).To(MatchError(ContainSubstring("failed to verify repository https://example.com/repo_2.git from profile generic: the repository is corrupted")))
+
+		_, summary := fakeObserver.RunFinishedArgsForCall(0)
+		Expect(summary.Targets[0].Verified).To(BeTrue())
+		Expect(summary.Targets[0].Err).NotTo(HaveOccurred())
+		Expect(summary.Targets[1].Verified).To(BeTrue())
+		Expect(summary.Targets[1].Err).To(HaveOccurred())
+	})
+
+	When("the backup fails", func() {
+		BeforeEach(func() {
+			fakeBackupService.RunReturns(backup.Result{}, errors.New("something went wrong"))
+		})
+
+		It("does not verify", func() {
+			Expect(fakeBackupService.VerifyCallCount()).To(BeZero())
+		})
+	})
+
+	When("verification is disabled", func() {
+		BeforeEach(func() {
+			conf.Verify.AfterBackup = false
+		})
+
+		It("does not verify", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeBackupService.VerifyCallCount()).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,219 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrLocked is returned when the lock is held by another process.
+var ErrLocked = errors.New("locked by another process")
+
+// Owner identifies the process which holds a lock.
+type Owner struct {
+	PID      int       `json:"pid"`
+	Host     string    `json:"host"`
+	Acquired time.Time `json:"acquired"`
+}
+
+func (o Owner) same(other Owner) bool {
+	return o.PID == other.PID && o.Host == other.Host && o.Acquired.Equal(other.Acquired)
+}
+
+// stale reports whether the owner is a process of this host which no longer runs. Processes of other
+// hosts can't be checked. A lock with the PID of this process which this process doesn't hold is left by
+// a previous process with the same PID, e.g. PID 1 of a restarted container.
+func (o Owner) stale(fileName, host string) bool {
+	if o.Host != host {
+		return false
+	}
+
+	if o.PID <= 0 {
+		return true
+	}
+
+	if o.PID == os.Getpid() {
+		return !holds(fileName, o)
+	}
+
+	return errors.Is(syscall.Kill(o.PID, 0), syscall.ESRCH)
+}
+
+var (
+	heldMutex sync.Mutex
+	// held are the owners of the locks which this process holds by the absolute file names of the locks.
+	held = map[string]Owner{}
+)
+
+func holds(fileName string, owner Owner) bool {
+	heldMutex.Lock()
+	defer heldMutex.Unlock()
+
+	current, ok := held[fileName]
+	return ok && current.same(owner)
+}
+
+func setHeld(fileName string, owner *Owner) {
+	heldMutex.Lock()
+	defer heldMutex.Unlock()
+
+	if owner == nil {
+		delete(held, fileName)
+	} else {
+		held[fileName] = *owner
+	}
+}
+
+// LockedError describes the owner of a lock which couldn't be acquired.
+type LockedError struct {
+	FileName string
+	Owner    Owner
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%v: %v is held by process %v on %v since %v",
+		ErrLocked, e.FileName, e.Owner.PID, e.Owner.Host, e.Owner.Acquired.Local().Format(time.DateTime))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// Lock is a file which keeps the PID and the host name of its owner.
+type Lock struct {
+	fileName string
+	owner    Owner
+}
+
+const attempts = 3
+
+// Acquire creates the lock file or returns a LockedError if another process holds it. A lock left by a
+// process which no longer runs on this host is taken over.
+func Acquire(fileName string) (*Lock, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	fileName, err = filepath.Abs(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := Owner{PID: os.Getpid(), Host: host, Acquired: time.Now().UTC().Truncate(time.Second)}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+		return nil, err
+	}
+
+	for range attempts {
+		err := create(fileName, owner)
+		if err == nil {
+			setHeld(fileName, &owner)
+			return &Lock{fileName: fileName, owner: owner}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, err
+		}
+
+		current, err := read(fileName)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read lock %v: %w", fileName, err)
+		}
+
+		if !current.stale(fileName, host) {
+			return nil, &LockedError{FileName: fileName, Owner: current}
+		}
+
+		if err := removeStale(fileName, current); err != nil {
+			return nil, fmt.Errorf("failed to remove stale lock %v: %w", fileName, err)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to acquire lock %v after %v attempts", fileName, attempts)
+}
+
+// Release removes the lock file unless it was taken over by another process.
+func (l *Lock) Release() error {
+	if holds(l.fileName, l.owner) {
+		setHeld(l.fileName, nil)
+	}
+
+	current, err := read(l.fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if !current.same(l.owner) {
+		return nil
+	}
+
+	return os.Remove(l.fileName)
+}
+
+// create writes the owner into a temporary file and links it as the lock, so that the lock never
+// exists without its owner.
+func create(fileName string, owner Owner) error {
+	data, err := json.Marshal(owner)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return errors.Join(err, file.Close())
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Link(file.Name(), fileName)
+}
+
+func read(fileName string) (Owner, error) {
+	var owner Owner
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return owner, err
+	}
+
+	return owner, json.Unmarshal(data, &owner)
+}
+
+// removeStale moves the lock aside before removing it. If another process has replaced the stale lock
+// in the meantime, its lock is put back.
+func removeStale(fileName string, stale Owner) error {
+	aside := fileName + ".stale." + strconv.Itoa(os.Getpid())
+	if err := os.Rename(fileName, aside); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+	defer func() { _ = os.Remove(aside) }()
+
+	if current, err := read(aside); err == nil && !current.same(stale) {
+		return os.Link(aside, fileName)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package lock_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/lock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lock tests", func() {
+	var (
+		fileName string
+		host     string
+	)
+
+	writeLock := func(owner lock.Owner) {
+		data, err := json.Marshal(owner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(fileName, data, 0o644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fileName = filepath.Join(GinkgoT().TempDir(), "root", ".git-backups.lock")
+		var err error
+		host, err = os.Hostname()
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("creates the lock file with the owner", func() {
+		l, err := lock.Acquire(fileName)
+		Expect(err).NotTo(HaveOccurred())
+
+		data, err := os.ReadFile(fileName)
+		Expect(err).NotTo(HaveOccurred())
+		var owner lock.Owner
+		Expect(json.Unmarshal(data, &owner)).To(Succeed())
+		Expect(owner.PID).To(Equal(os.Getpid()))
+		Expect(owner.Host).To(Equal(host))
+
+		Expect(l.Release()).To(Succeed())
+		Expect(fileName).NotTo(BeAnExistingFile())
+	})
+
+	It("is exclusive until released", func() {
+		l, err := lock.Acquire(fileName)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = lock.Acquire(fileName)
+		Expect(err).To(MatchError(lock.ErrLocked))
+		var lockedErr *lock.LockedError
+		Expect(err).To(BeAssignableToTypeOf(lockedErr))
+		Expect(err).To(MatchError(ContainSubstring(fileName + " is held by process")))
+
+		Expect(l.Release()).To(Succeed())
+		l, err = lock.Acquire(fileName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(l.Release()).To(Succeed())
+	})
+
+	When("the owner no longer runs", func() {
+		BeforeEach(func() {
+			command := exec.Command("true")
+			Expect(command.Run()).To(Succeed())
+			Expect(os.MkdirAll(filepath.Dir(fileName), 0o755)).To(Succeed())
+			writeLock(lock.Owner{PID: command.Process.Pid, Host: host, Acquired: time.Now()})
+		})
+
+		It("takes the lock over", func() {
+			l, err := lock.Acquire(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(l.Release()).To(Succeed())
+			Expect(filepath.Dir(fileName)).To(BeADirectory())
+			entries, err := os.ReadDir(filepath.Dir(fileName))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+	})
+
+	When("a previous process had the same PID", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Dir(fileName), 0o755)).To(Succeed())
+			writeLock(lock.Owner{PID: os.Getpid(), Host: host, Acquired: time.Now().Add(-time.Hour)})
+		})
+
+		It("takes the lock over", func() {
+			l, err := lock.Acquire(fileName)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = lock.Acquire(fileName)
+			Expect(err).To(MatchError(lock.ErrLocked))
+			Expect(l.Release()).To(Succeed())
+			Expect(fileName).NotTo(BeAnExistingFile())
+		})
+	})
+
+	When("the owner runs on another host", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(filepath.Dir(fileName), 0o755)).To(Succeed())
+			writeLock(lock.Owner{PID: 1, Host: "other-host", Acquired: time.Now()})
+		})
+
+		It("reports the owner", func() {
+			_, err := lock.Acquire(fileName)
+			Expect(err).To(MatchError(ContainSubstring("is held by process 1 on other-host since")))
+		})
+	})
+
+	When("the lock was taken over", func() {
+		It("doesn't remove the lock of the new owner", func() {
+			l, err := lock.Acquire(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			writeLock(lock.Owner{PID: 1, Host: "other-host", Acquired: time.Now()})
+
+			Expect(l.Release()).To(Succeed())
+			Expect(fileName).To(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package logfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Writer appends to a file and rotates it once it grows over the maximum size. Rotated files get
+// numeric suffixes (app.log.1 is the most recent one) and only the configured number of them is kept.
+type Writer struct {
+	mutex      sync.Mutex
+	fileName   string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	retryAt    int64
+}
+
+func New(fileName string, maxSize int64, maxBackups int) (*Writer, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid maximum log file size %v", maxSize)
+	}
+
+	w := &Writer{fileName: fileName, maxSize: maxSize, maxBackups: max(maxBackups, 0)}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.size > 0 && w.size+int64(len(p)) > max(w.maxSize, w.retryAt) {
+		if err := w.rotate(); err != nil {
+			if w.file == nil {
+				return 0, err
+			}
+
+			// Keep appending to the current file and retry once it has grown by the maximum size again,
+			// so the error is reported once instead of on every write.
+			w.retryAt = w.size + w.maxSize
+			n, writeErr := w.file.Write(p)
+			w.size += int64(n)
+
+			return n, errors.Join(err, writeErr)
+		}
+		w.retryAt = 0
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+
+	err := w.file.Close()
+	w.file = nil
+
+	return err
+}
+
+func (w *Writer) open() error {
+	file, err := os.OpenFile(w.fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return errors.Join(err, file.Close())
+	}
+
+	w.file, w.size = file, info.Size()
+
+	return nil
+}
+
+func (w *Writer) rotate() error {
+	err := w.file.Close()
+	w.file = nil
+	if err == nil {
+		err = w.shift()
+	}
+
+	// The current file is reopened even if it couldn't be rotated, so logging goes on.
+	return errors.Join(err, w.open())
+}
+
+func (w *Writer) shift() error {
+	if w.maxBackups == 0 {
+		if err := os.Remove(w.fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	for i := w.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(w.backupName(i), w.backupName(i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return os.Rename(w.fileName, w.backupName(1))
+}
+
+func (w *Writer) backupName(index int) string {
+	return fmt.Sprintf("%v.%v", w.fileName, index)
+}
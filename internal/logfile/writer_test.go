@@ -0,0 +1,107 @@
+package logfile_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/AntonKosov/git-backups/internal/logfile"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Writer tests", func() {
+	var (
+		fileName   string
+		maxBackups int
+		writer     *logfile.Writer
+	)
+
+	readFile := func(name string) string {
+		content, err := os.ReadFile(name)
+		Expect(err).NotTo(HaveOccurred())
+
+		return string(content)
+	}
+
+	write := func(lines ...string) {
+		for _, line := range lines {
+			_, err := writer.Write([]byte(line))
+			Expect(err).NotTo(HaveOccurred())
+		}
+	}
+
+	BeforeEach(func() {
+		fileName = filepath.Join(GinkgoT().TempDir(), "app.log")
+		maxBackups = 2
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		writer, err = logfile.New(fileName, 10, maxBackups)
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(writer.Close)
+	})
+
+	It("appends to the file", func() {
+		write("1234\n", "5678\n")
+		Expect(readFile(fileName)).To(Equal("1234\n5678\n"))
+	})
+
+	It("rotates the file when it exceeds the maximum size", func() {
+		write("1234\n", "5678\n", "abcd\n", "efgh\n", "ijkl\n", "mnop\n", "qrst\n")
+		Expect(readFile(fileName)).To(Equal("qrst\n"))
+		Expect(readFile(fileName + ".1")).To(Equal("ijkl\nmnop\n"))
+		Expect(readFile(fileName + ".2")).To(Equal("abcd\nefgh\n"))
+		Expect(fileName + ".3").NotTo(BeAnExistingFile())
+	})
+
+	It("writes a line larger than the maximum size", func() {
+		write("a line which is too long\n")
+		Expect(readFile(fileName)).To(Equal("a line which is too long\n"))
+	})
+
+	When("the file already exists", func() {
+		BeforeEach(func() {
+			Expect(os.WriteFile(fileName, []byte("old data\n"), 0o600)).To(Succeed())
+		})
+
+		It("takes the existing size into account", func() {
+			write("1234\n")
+			Expect(readFile(fileName)).To(Equal("1234\n"))
+			Expect(readFile(fileName + ".1")).To(Equal("old data\n"))
+		})
+	})
+
+	When("backups are disabled", func() {
+		BeforeEach(func() {
+			maxBackups = 0
+		})
+
+		It("truncates the file", func() {
+			write("1234\n", "5678\n", "abcd\n")
+			Expect(readFile(fileName)).To(Equal("abcd\n"))
+			Expect(fileName + ".1").NotTo(BeAnExistingFile())
+		})
+	})
+
+	When("the file cannot be rotated", func() {
+		BeforeEach(func() {
+			maxBackups = 1
+			Expect(os.MkdirAll(filepath.Join(fileName+".1", "blocker"), 0o700)).To(Succeed())
+		})
+
+		It("keeps appending to the file and reports the error once", func() {
+			write("1234\n", "5678\n")
+			_, err := writer.Write([]byte("abcd\n"))
+			Expect(err).To(HaveOccurred())
+			write("efgh\n")
+			Expect(readFile(fileName)).To(Equal("1234\n5678\nabcd\nefgh\n"))
+		})
+	})
+
+	It("fails after being closed", func() {
+		Expect(writer.Close()).To(Succeed())
+		_, err := writer.Write([]byte("data"))
+		Expect(err).To(MatchError(os.ErrClosed))
+	})
+})
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+)
+
+type repoKey struct {
+	profile string
+	path    string
+}
+
+type repoMetrics struct {
+	lastSuccess         float64
+	lastAttempt         float64
+	lastAttemptDuration float64
+	lastAttemptSuccess  bool
+	fetchedBytes        float64
+	sizeBytes           float64
+	failures            map[backup.ErrorClass]float64
+}
+
+type profileMetrics struct {
+	discovered int
+	selected   int
+}
+
+// Collector gathers metrics of backup runs observed from the launcher. It is safe for concurrent use.
+type Collector struct {
+	mutex    sync.Mutex
+	repos    map[repoKey]*repoMetrics
+	profiles map[string]profileMetrics
+
+	runFinished   bool
+	runSuccess    bool
+	runTimestamp  float64
+	runDuration   float64
+	runInProgress bool
+}
+
+func NewCollector() *Collector {
+	return &Collector{
+		repos:    map[repoKey]*repoMetrics{},
+		profiles: map[string]profileMetrics{},
+	}
+}
+
+func (c *Collector) RunStarted(ctx context.Context, started time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.runInProgress = true
+}
+
+func (c *Collector) ProfileStarted(ctx context.Context, profile string) {}
+
+func (c *Collector) TargetFinished(ctx context.Context, result launcher.TargetResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	metrics := c.repo(repoKey{profile: result.Target.Profile, path: result.Target.Path})
+	metrics.lastAttempt = timestamp(result.Started)
+	metrics.lastAttemptDuration = result.Duration.Seconds()
+	metrics.lastAttemptSuccess = result.Err == nil
+
+	if backupResult := result.Backup; backupResult.Action != "" {
+		metrics.lastAttemptDuration = backupResult.Duration.Seconds()
+		metrics.sizeBytes = float64(backupResult.Size)
+		metrics.fetchedBytes += float64(backupResult.Fetched())
+	}
+
+	if result.Err != nil {
+		metrics.failures[backup.ClassifyError(result.Err)]++
+		return
+	}
+
+	metrics.lastSuccess = timestamp(result.Started.Add(result.Duration))
+}
+
+func (c *Collector) RunFinished(ctx context.Context, summary launcher.Summary) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, profile := range summary.Profiles {
+		c.profiles[profile.Name] = profileMetrics{discovered: profile.Discovered, selected: profile.Selected}
+	}
+
+	c.runInProgress = false
+	c.runFinished = true
+	c.runSuccess = summary.Err == nil
+	c.runTimestamp = timestamp(summary.Started.Add(summary.Duration))
+	c.runDuration = summary.Duration.Seconds()
+}
+
+func (c *Collector) repo(key repoKey) *repoMetrics {
+	metrics, ok := c.repos[key]
+	if !ok {
+		metrics = &repoMetrics{failures: map[backup.ErrorClass]float64{}}
+		c.repos[key] = metrics
+	}
+
+	return metrics
+}
+
+func timestamp(t time.Time) float64 {
+	return float64(t.UnixMilli()) / 1000
+}
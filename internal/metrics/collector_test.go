@@ -0,0 +1,142 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collector tests", func() {
+	var (
+		collector *metrics.Collector
+		started   time.Time
+	)
+
+	var output = func() string {
+		var sb strings.Builder
+		Expect(collector.Write(&sb)).To(Succeed())
+		return sb.String()
+	}
+
+	var backupTarget = func(profile, path string, size, fetched int64, err error) {
+		collector.TargetFinished(ctx, launcher.TargetResult{
+			Target:   launcher.Target{Profile: profile, Path: path},
+			Started:  started,
+			Duration: 3 * time.Second,
+			Backup: backup.Result{
+				Path: path, Action: backup.ActionFetch, Started: started, Duration: 2 * time.Second,
+				Size: size, ObjectsAfter: fetched,
+			},
+			Err: err,
+		})
+	}
+
+	BeforeEach(func() {
+		collector = metrics.NewCollector()
+		started = time.Unix(1700000000, 0)
+
+		collector.RunStarted(ctx, started)
+		backupTarget("profile", "/backups/repo_1", 150, 50, nil)
+		backupTarget("profile", "/backups/repo_2", 100, 0, errors.New("remote: Repository not found."))
+		collector.RunFinished(ctx, launcher.Summary{
+			Started:  started,
+			Duration: 10 * time.Second,
+			Profiles: []launcher.ProfileResult{{Name: "profile", Discovered: 3, Selected: 2}},
+			Err:      errors.New("failed"),
+		})
+	})
+
+	It("writes repository metrics", func() {
+		Expect(output()).To(ContainSubstring(strings.Join([]string{
+			`# HELP git_backups_repository_last_success_timestamp_seconds Time of the last successful backup of the repository.`,
+			`# TYPE git_backups_repository_last_success_timestamp_seconds gauge`,
+			`git_backups_repository_last_success_timestamp_seconds{profile="profile",path="/backups/repo_1"} 1700000003`,
+			`# HELP`,
+		}, "\n")))
+		Expect(output()).To(ContainSubstring(`git_backups_repository_last_attempt_duration_seconds{profile="profile",path="/backups/repo_2"} 2` + "\n"))
+		Expect(output()).To(ContainSubstring(`git_backups_repository_last_attempt_success{profile="profile",path="/backups/repo_2"} 0` + "\n"))
+		Expect(output()).To(ContainSubstring(`git_backups_repository_fetched_bytes_total{profile="profile",path="/backups/repo_1"} 50` + "\n"))
+		Expect(output()).To(ContainSubstring(`git_backups_repository_size_bytes{profile="profile",path="/backups/repo_1"} 150` + "\n"))
+		Expect(output()).To(ContainSubstring(`git_backups_repository_failures_total{profile="profile",path="/backups/repo_2",reason="not_found"} 1` + "\n"))
+		Expect(output()).NotTo(ContainSubstring(`git_backups_repository_failures_total{profile="profile",path="/backups/repo_1"`))
+	})
+
+	It("writes profile metrics", func() {
+		Expect(output()).To(ContainSubstring(`git_backups_profile_discovered_repositories{profile="profile"} 3` + "\n"))
+		Expect(output()).To(ContainSubstring(`git_backups_profile_selected_repositories{profile="profile"} 2` + "\n"))
+	})
+
+	It("writes run metrics", func() {
+		Expect(output()).To(ContainSubstring("git_backups_run_in_progress 0\n"))
+		Expect(output()).To(ContainSubstring("git_backups_run_success 0\n"))
+		Expect(output()).To(ContainSubstring("git_backups_run_last_timestamp_seconds 1700000010\n"))
+		Expect(output()).To(ContainSubstring("git_backups_run_duration_seconds 10\n"))
+	})
+
+	It("serves metrics over HTTP", func() {
+		recorder := httptest.NewRecorder()
+		collector.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(recorder.Header().Get("Content-Type")).To(HavePrefix("text/plain"))
+		Expect(recorder.Body.String()).To(Equal(output()))
+	})
+
+	It("escapes label values", func() {
+		backupTarget(`pro"file`, `C:\backups`, 0, 0, nil)
+		Expect(output()).To(ContainSubstring(`{profile="pro\"file",path="C:\\backups"}`))
+	})
+
+	When("metrics are restored from a textfile", func() {
+		var (
+			fileName string
+			restored *metrics.Collector
+		)
+
+		BeforeEach(func() {
+			fileName = filepath.Join(GinkgoT().TempDir(), "git_backups.prom")
+			Expect(collector.WriteTextfile(fileName)).To(Succeed())
+
+			restored = metrics.NewCollector()
+			Expect(restored.LoadTextfile(fileName)).To(Succeed())
+		})
+
+		It("writes the textfile atomically", func() {
+			entries, err := os.ReadDir(filepath.Dir(fileName))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+
+		It("keeps repository metrics of the previous run", func() {
+			var sb strings.Builder
+			Expect(restored.Write(&sb)).To(Succeed())
+			Expect(sb.String()).To(ContainSubstring(`git_backups_repository_last_success_timestamp_seconds{profile="profile",path="/backups/repo_1"} 1700000003` + "\n"))
+			Expect(sb.String()).To(ContainSubstring(`git_backups_repository_failures_total{profile="profile",path="/backups/repo_2",reason="not_found"} 1` + "\n"))
+			Expect(sb.String()).NotTo(ContainSubstring("git_backups_run_success"))
+		})
+
+		It("keeps counting from the previous values", func() {
+			collector = restored
+			backupTarget("profile", "/backups/repo_1", 170, 20, nil)
+			backupTarget("profile", "/backups/repo_2", 100, 0, errors.New("remote: Repository not found."))
+			Expect(output()).To(ContainSubstring(`git_backups_repository_fetched_bytes_total{profile="profile",path="/backups/repo_1"} 70` + "\n"))
+			Expect(output()).To(ContainSubstring(`git_backups_repository_size_bytes{profile="profile",path="/backups/repo_1"} 170` + "\n"))
+			Expect(output()).To(ContainSubstring(`git_backups_repository_failures_total{profile="profile",path="/backups/repo_2",reason="not_found"} 2` + "\n"))
+		})
+	})
+
+	It("ignores a missing textfile", func() {
+		Expect(metrics.NewCollector().LoadTextfile(filepath.Join(GinkgoT().TempDir(), "missing.prom"))).To(Succeed())
+	})
+
+	It("fails on a malformed textfile", func() {
+		Expect(metrics.NewCollector().Load(strings.NewReader(`metric{label="value} 1`))).To(MatchError(ContainSubstring("line 1")))
+	})
+})
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const namespace = "git_backups_"
+
+const (
+	repoLastSuccess         = namespace + "repository_last_success_timestamp_seconds"
+	repoLastAttempt         = namespace + "repository_last_attempt_timestamp_seconds"
+	repoLastAttemptDuration = namespace + "repository_last_attempt_duration_seconds"
+	repoLastAttemptSuccess  = namespace + "repository_last_attempt_success"
+	repoFetchedBytes        = namespace + "repository_fetched_bytes_total"
+	repoSizeBytes           = namespace + "repository_size_bytes"
+	repoFailures            = namespace + "repository_failures_total"
+	profileDiscovered       = namespace + "profile_discovered_repositories"
+	profileSelected         = namespace + "profile_selected_repositories"
+	runInProgress           = namespace + "run_in_progress"
+	runSuccess              = namespace + "run_success"
+	runLastTimestamp        = namespace + "run_last_timestamp_seconds"
+	runDuration             = namespace + "run_duration_seconds"
+)
+
+type label struct {
+	name  string
+	value string
+}
+
+type sample struct {
+	labels []label
+	value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []sample
+}
+
+// Write writes all metrics in the Prometheus text exposition format.
+func (c *Collector) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range c.families() {
+		if len(f.samples) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "# HELP %v %v\n", f.name, f.help)
+		fmt.Fprintf(bw, "# TYPE %v %v\n", f.name, f.kind)
+		for _, s := range f.samples {
+			bw.WriteString(f.name)
+			writeLabels(bw, s.labels)
+			bw.WriteString(" ")
+			bw.WriteString(strconv.FormatFloat(s.value, 'f', -1, 64))
+			bw.WriteString("\n")
+		}
+	}
+
+	return bw.Flush()
+}
+
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = c.Write(w)
+}
+
+func (c *Collector) families() []family {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	families := []family{
+		{name: repoLastSuccess, kind: "gauge", help: "Time of the last successful backup of the repository."},
+		{name: repoLastAttempt, kind: "gauge", help: "Time of the last backup attempt of the repository."},
+		{name: repoLastAttemptDuration, kind: "gauge", help: "Duration of the last backup attempt of the repository."},
+		{name: repoLastAttemptSuccess, kind: "gauge", help: "Whether the last backup attempt of the repository succeeded."},
+		{name: repoFetchedBytes, kind: "counter", help: "Bytes of objects added to the backup of the repository."},
+		{name: repoSizeBytes, kind: "gauge", help: "Size of the backup of the repository on disk."},
+		{name: repoFailures, kind: "counter", help: "Failed backups of the repository by reason."},
+	}
+
+	keys := slices.SortedFunc(maps.Keys(c.repos), func(a, b repoKey) int {
+		return cmp.Or(cmp.Compare(a.profile, b.profile), cmp.Compare(a.path, b.path))
+	})
+	for _, key := range keys {
+		metrics := c.repos[key]
+		labels := []label{{"profile", key.profile}, {"path", key.path}}
+		if metrics.lastSuccess > 0 {
+			families[0].samples = append(families[0].samples, sample{labels, metrics.lastSuccess})
+		}
+		if metrics.lastAttempt > 0 {
+			families[1].samples = append(families[1].samples, sample{labels, metrics.lastAttempt})
+			families[2].samples = append(families[2].samples, sample{labels, metrics.lastAttemptDuration})
+			families[3].samples = append(families[3].samples, sample{labels, boolValue(metrics.lastAttemptSuccess)})
+			families[5].samples = append(families[5].samples, sample{labels, metrics.sizeBytes})
+		}
+		families[4].samples = append(families[4].samples, sample{labels, metrics.fetchedBytes})
+		for _, reason := range slices.Sorted(maps.Keys(metrics.failures)) {
+			reasonLabels := append(slices.Clone(labels), label{"reason", string(reason)})
+			families[6].samples = append(families[6].samples, sample{reasonLabels, metrics.failures[reason]})
+		}
+	}
+
+	discovered := family{name: profileDiscovered, kind: "gauge", help: "Repositories found in the profile during the last run."}
+	selected := family{name: profileSelected, kind: "gauge", help: "Repositories selected for backup in the profile during the last run."}
+	for _, name := range slices.Sorted(maps.Keys(c.profiles)) {
+		labels := []label{{"profile", name}}
+		discovered.samples = append(discovered.samples, sample{labels, float64(c.profiles[name].discovered)})
+		selected.samples = append(selected.samples, sample{labels, float64(c.profiles[name].selected)})
+	}
+	families = append(families, discovered, selected,
+		family{
+			name: runInProgress, kind: "gauge", help: "Whether a backup run is in progress.",
+			samples: []sample{{value: boolValue(c.runInProgress)}},
+		},
+	)
+
+	if c.runFinished {
+		families = append(families,
+			family{
+				name: runSuccess, kind: "gauge", help: "Whether the last run succeeded.",
+				samples: []sample{{value: boolValue(c.runSuccess)}},
+			},
+			family{
+				name: runLastTimestamp, kind: "gauge", help: "Time the last run finished.",
+				samples: []sample{{value: c.runTimestamp}},
+			},
+			family{
+				name: runDuration, kind: "gauge", help: "Duration of the last run.",
+				samples: []sample{{value: c.runDuration}},
+			},
+		)
+	}
+
+	return families
+}
+
+func writeLabels(w *bufio.Writer, labels []label) {
+	if len(labels) == 0 {
+		return
+	}
+
+	w.WriteString("{")
+	for i, l := range labels {
+		if i > 0 {
+			w.WriteString(",")
+		}
+		w.WriteString(l.name)
+		w.WriteString(`="`)
+		w.WriteString(labelEscaper.Replace(l.value))
+		w.WriteString(`"`)
+	}
+	w.WriteString("}")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
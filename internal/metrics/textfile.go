@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AntonKosov/git-backups/internal/fsutil"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+)
+
+// LoadTextfile restores repository metrics from a textfile written by a previous run, so that one-shot
+// runs keep the last success time and counters of repositories. A missing file is not an error.
+func (c *Collector) LoadTextfile(fileName string) error {
+	file, err := os.Open(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c.Load(file)
+}
+
+// Load restores repository metrics from the text exposition format. Unknown metrics are ignored.
+func (c *Collector) Load(r io.Reader) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, labels, value, err := parseSample(line)
+		if err != nil {
+			return fmt.Errorf("line %v: %w", lineNumber, err)
+		}
+
+		c.restore(name, labels, value)
+	}
+
+	return scanner.Err()
+}
+
+func (c *Collector) restore(name string, labels map[string]string, value float64) {
+	profile, hasProfile := labels["profile"]
+	path, hasPath := labels["path"]
+	if !hasProfile || !hasPath {
+		return
+	}
+
+	switch name {
+	case repoLastSuccess:
+		c.repo(repoKey{profile, path}).lastSuccess = value
+	case repoLastAttempt:
+		c.repo(repoKey{profile, path}).lastAttempt = value
+	case repoLastAttemptDuration:
+		c.repo(repoKey{profile, path}).lastAttemptDuration = value
+	case repoLastAttemptSuccess:
+		c.repo(repoKey{profile, path}).lastAttemptSuccess = value == 1
+	case repoFetchedBytes:
+		c.repo(repoKey{profile, path}).fetchedBytes = value
+	case repoSizeBytes:
+		c.repo(repoKey{profile, path}).sizeBytes = value
+	case repoFailures:
+		c.repo(repoKey{profile, path}).failures[backup.ErrorClass(labels["reason"])] = value
+	}
+}
+
+func parseSample(line string) (name string, labels map[string]string, value float64, err error) {
+	labels = map[string]string{}
+	end := strings.IndexAny(line, "{ ")
+	if end <= 0 {
+		return "", nil, 0, fmt.Errorf("invalid sample %q", line)
+	}
+	name, rest := line[:end], line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest, err = parseLabels(rest[1:], labels)
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("invalid labels of %v: %w", name, err)
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, fmt.Errorf("missing value of %v", name)
+	}
+
+	value, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("invalid value of %v: %w", name, err)
+	}
+
+	return name, labels, value, nil
+}
+
+// parseLabels parses `name="value",...}` and returns the rest of the line after the closing brace.
+func parseLabels(text string, labels map[string]string) (string, error) {
+	for {
+		text = strings.TrimLeft(text, " ,")
+		if rest, ok := strings.CutPrefix(text, "}"); ok {
+			return rest, nil
+		}
+
+		name, rest, ok := strings.Cut(text, `="`)
+		if !ok {
+			return "", errors.New("missing label value")
+		}
+
+		var value strings.Builder
+		escaped, closed := false, false
+		for i, r := range rest {
+			switch {
+			case escaped:
+				if r == 'n' {
+					r = '\n'
+				}
+				value.WriteRune(r)
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				text, closed = rest[i+1:], true
+			default:
+				value.WriteRune(r)
+			}
+			if closed {
+				break
+			}
+		}
+		if !closed {
+			return "", errors.New("unterminated label value")
+		}
+
+		labels[strings.TrimSpace(name)] = value.String()
+	}
+}
+
+// WriteTextfile writes the metrics for the node_exporter textfile collector. The file is replaced
+// atomically so the exporter never reads a partially written file.
+func (c *Collector) WriteTextfile(fileName string) error {
+	return fsutil.WriteAtomically(fileName, c.Write)
+}
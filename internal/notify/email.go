@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+)
+
+type email struct {
+	conf config.SMTP
+}
+
+func newEmail(conf config.SMTP) email {
+	return email{conf: conf}
+}
+
+func (e email) Send(ctx context.Context, report Report) error {
+	conn, err := e.dial(ctx)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, e.conf.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if !e.conf.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: e.conf.Host}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if e.conf.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", e.conf.Username, e.conf.Password, e.conf.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(e.conf.From); err != nil {
+		return err
+	}
+	for _, to := range e.conf.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(e.message(report)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (e email) dial(ctx context.Context) (net.Conn, error) {
+	address := net.JoinHostPort(e.conf.Host, strconv.Itoa(e.conf.Port))
+	if e.conf.TLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: e.conf.Host}}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+
+	var dialer net.Dialer
+
+	return dialer.DialContext(ctx, "tcp", address)
+}
+
+func (e email) message(report Report) []byte {
+	var sb strings.Builder
+	headers := [][2]string{
+		{"From", e.conf.From},
+		{"To", strings.Join(e.conf.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", report.Title())},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "8bit"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&sb, "%v: %v\r\n", header[0], header[1])
+	}
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(report.Text(), "\n", "\r\n"))
+
+	return []byte(sb.String())
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+)
+
+const (
+	EventAlways   = "always"
+	EventFailure  = "failure"
+	EventRecovery = "recovery"
+
+	sendTimeout = 30 * time.Second
+)
+
+type sender interface {
+	Send(ctx context.Context, report Report) error
+}
+
+type channel struct {
+	name     string
+	events   []string
+	profiles []string
+	sender   sender
+}
+
+// Notifier sends the run summary to the configured channels when the run finishes. Failures to notify
+// are logged and don't affect the run.
+type Notifier struct {
+	channels []channel
+	store    Store
+}
+
+func New(conf config.Notifications, store Store) (*Notifier, error) {
+	n := &Notifier{store: store}
+	for _, c := range conf.Channels {
+		s, err := newSender(c)
+		if err != nil {
+			return nil, fmt.Errorf("notification channel %q: %w", c.Name, err)
+		}
+		n.channels = append(n.channels, channel{name: c.Name, events: c.Events, profiles: c.Profiles, sender: s})
+	}
+
+	return n, nil
+}
+
+func newSender(c config.NotificationChannel) (sender, error) {
+	switch c.Type {
+	case "webhook":
+		return newWebhook(c.URL, formatJSON), nil
+	case "slack":
+		return newWebhook(c.URL, formatSlack), nil
+	case "mattermost":
+		return newWebhook(c.URL, formatMattermost), nil
+	case "email":
+		return newEmail(c.SMTP), nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", c.Type)
+	}
+}
+
+func (n *Notifier) RunStarted(ctx context.Context, started time.Time) {}
+
+func (n *Notifier) ProfileStarted(ctx context.Context, profile string) {}
+
+func (n *Notifier) TargetFinished(ctx context.Context, result launcher.TargetResult) {}
+
+func (n *Notifier) RunFinished(ctx context.Context, summary launcher.Summary) {
+	if Interrupted(summary) {
+		slog.InfoContext(ctx, "Skipping notifications of the interrupted run")
+		return
+	}
+
+	// Notifications are sent even if the run was canceled right at the end.
+	ctx = context.WithoutCancel(ctx)
+	for _, c := range n.channels {
+		n.notify(clog.Add(ctx, "notification_channel", c.name), c, summary)
+	}
+}
+
+func (n *Notifier) notify(ctx context.Context, c channel, summary launcher.Summary) {
+	report := NewReport(summary, c.profiles)
+	if report.Empty() {
+		// The run was filtered to other profiles or repositories, so it says nothing about the channel.
+		slog.DebugContext(ctx, "Skipping the notification since none of its profiles were backed up")
+		return
+	}
+
+	previouslyFailed, err := n.store.Failed(c.name)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read the notification state", "error", err)
+	}
+	report.Recovered = previouslyFailed && !report.Failed()
+
+	if err := n.store.SetFailed(c.name, report.Failed()); err != nil {
+		slog.WarnContext(ctx, "Failed to save the notification state", "error", err)
+	}
+
+	if !c.shouldSend(report) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	if err := c.sender.Send(ctx, report); err != nil {
+		slog.ErrorContext(ctx, "Failed to send the notification", "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Notification sent", "status", report.Status)
+}
+
+func (c channel) shouldSend(report Report) bool {
+	return slices.Contains(c.events, EventAlways) ||
+		(report.Failed() && slices.Contains(c.events, EventFailure)) ||
+		(report.Recovered && slices.Contains(c.events, EventRecovery))
+}
@@ -0,0 +1,245 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/notify"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Notifier tests", func() {
+	var (
+		server   *httptest.Server
+		mutex    sync.Mutex
+		requests []string
+		status   int
+		channels []config.NotificationChannel
+		store    notify.Store
+		summary  launcher.Summary
+	)
+
+	var received = func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return append([]string(nil), requests...)
+	}
+
+	var notifyRun = func() {
+		notifier, err := notify.New(config.Notifications{Channels: channels}, store)
+		Expect(err).NotTo(HaveOccurred())
+		notifier.RunFinished(ctx, summary)
+	}
+
+	var failedSummary = func() launcher.Summary {
+		started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		return launcher.Summary{
+			Started:  started,
+			Duration: 90 * time.Second,
+			Profiles: []launcher.ProfileResult{
+				{Name: "generic", Discovered: 2, Selected: 2},
+				{Name: "github", Discovered: 2, Selected: 1},
+			},
+			Targets: []launcher.TargetResult{
+				{Target: launcher.Target{Profile: "generic", URL: "https://example.com/repo_1.git", Path: "/backups/repo_1"}},
+				{
+					Target: launcher.Target{Profile: "generic", URL: "https://example.com/repo_2.git", Path: "/backups/repo_2"},
+					Err:    errors.New("remote: Repository not found."),
+				},
+				{Target: launcher.Target{Profile: "github", URL: "git@github.com:owner/repo_3.git", Path: "/backups/owner/repo_3"}},
+			},
+			Skipped:  []launcher.SkippedRepo{{Profile: "github", Repo: "owner/fork", Reason: "is a fork"}},
+			Orphaned: []launcher.OrphanedRepo{{Profile: "github", Path: "/backups/owner/deleted"}},
+			Err:      errors.New("failed"),
+		}
+	}
+
+	var successfulSummary = func() launcher.Summary {
+		s := failedSummary()
+		s.Targets[1].Err = nil
+		s.Err = nil
+		return s
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			mutex.Lock()
+			requests = append(requests, string(body))
+			mutex.Unlock()
+			w.WriteHeader(status)
+		}))
+		DeferCleanup(server.Close)
+
+		channels = []config.NotificationChannel{{Name: "hook", Type: "webhook", URL: server.URL, Events: []string{"always"}}}
+		store = notify.NewMemoryStore()
+		summary = failedSummary()
+	})
+
+	It("posts the report as JSON", func() {
+		notifyRun()
+		Expect(received()).To(HaveLen(1))
+		Expect(received()[0]).To(MatchJSON(`{
+			"status": "failure",
+			"recovered": false,
+			"started": "2025-01-02T03:04:05Z",
+			"duration_seconds": 90.000,
+			"profiles": null,
+			"totals": {"targets": 3, "succeeded": 2, "failed": 1, "skipped": 1, "orphaned": 1},
+			"failures": [{
+				"profile": "generic",
+				"url": "https://example.com/repo_2.git",
+				"path": "/backups/repo_2",
+				"reason": "not_found",
+				"error": "remote: Repository not found."
+			}],
+			"orphaned": [{"profile": "github", "path": "/backups/owner/deleted"}],
+			"errors": []
+		}`))
+	})
+
+	It("limits the report to the profiles of the channel", func() {
+		channels[0].Profiles = []string{"github"}
+		notifyRun()
+		var report map[string]any
+		Expect(json.Unmarshal([]byte(received()[0]), &report)).To(Succeed())
+		Expect(report["status"]).To(Equal("success"))
+		Expect(report["totals"]).To(Equal(map[string]any{
+			"targets": 1.0, "succeeded": 1.0, "failed": 0.0, "skipped": 1.0, "orphaned": 1.0,
+		}))
+	})
+
+	DescribeTable("chat formats", func(channelType, expectedTitle string) {
+		channels[0].Type = channelType
+		notifyRun()
+		var message map[string]string
+		Expect(json.Unmarshal([]byte(received()[0]), &message)).To(Succeed())
+		Expect(message).To(HaveKey("text"))
+		Expect(message["text"]).To(HavePrefix(expectedTitle + "\nStarted: 2025-01-02T03:04:05Z\nDuration: 1m30s\n"))
+		Expect(message["text"]).To(ContainSubstring("Backed up: 2, failed: 1, skipped: 1, orphaned: 1\n"))
+		Expect(message["text"]).To(ContainSubstring("Failures:\n- generic: https://example.com/repo_2.git (not_found)\n"))
+		Expect(message["text"]).To(ContainSubstring("Orphaned repositories:\n- github: /backups/owner/deleted\n"))
+	},
+		Entry("slack", "slack", "*Git backups failed: 1 of 3 repositories*"),
+		Entry("mattermost", "mattermost", "**Git backups failed: 1 of 3 repositories**"),
+	)
+
+	Describe("events", func() {
+		BeforeEach(func() {
+			channels[0].Events = []string{"failure", "recovery"}
+		})
+
+		It("notifies about failures and recoveries only", func() {
+			notifyRun()
+			Expect(received()).To(HaveLen(1))
+
+			summary = successfulSummary()
+			notifyRun()
+			Expect(received()).To(HaveLen(2))
+			Expect(received()[1]).To(ContainSubstring(`"recovered":true`))
+
+			notifyRun()
+			Expect(received()).To(HaveLen(2))
+		})
+
+		It("keeps the state in the file", func() {
+			stateFile := filepath.Join(GinkgoT().TempDir(), "state.json")
+			store = notify.NewFileStore(stateFile)
+			notifyRun()
+
+			store = notify.NewFileStore(stateFile)
+			summary = successfulSummary()
+			notifyRun()
+			Expect(received()).To(HaveLen(2))
+			Expect(received()[1]).To(ContainSubstring(`"recovered":true`))
+		})
+
+		It("keeps the failure of a run which didn't back up the profiles of the channel", func() {
+			channels[0].Profiles = []string{"generic"}
+			notifyRun()
+			Expect(received()).To(HaveLen(1))
+
+			summary = successfulSummary()
+			summary.Profiles = summary.Profiles[1:]
+			summary.Targets = summary.Targets[2:]
+			notifyRun()
+			Expect(received()).To(HaveLen(1))
+
+			failed, err := store.Failed("hook")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed).To(BeTrue())
+		})
+
+		It("does not notify about a successful run", func() {
+			summary = successfulSummary()
+			notifyRun()
+			Expect(received()).To(BeEmpty())
+		})
+	})
+
+	It("does not notify about interrupted runs", func() {
+		summary.Err = errors.Join(summary.Err, context.Canceled)
+		notifyRun()
+		Expect(received()).To(BeEmpty())
+	})
+
+	It("tolerates failing channels", func() {
+		status = http.StatusInternalServerError
+		unreachable := config.NotificationChannel{Name: "unreachable", Type: "webhook", URL: "http://127.0.0.1:1", Events: []string{"always"}}
+		channels = append([]config.NotificationChannel{unreachable}, channels...)
+		notifyRun()
+		Expect(received()).To(HaveLen(1))
+	})
+
+	Describe("email", func() {
+		var stub *smtpStub
+
+		BeforeEach(func() {
+			var err error
+			stub, err = newSMTPStub()
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(stub.close)
+
+			channels = []config.NotificationChannel{{
+				Name:   "mail",
+				Type:   "email",
+				Events: []string{"always"},
+				SMTP: config.SMTP{
+					Host:     "127.0.0.1",
+					Port:     stub.port(),
+					Username: "user",
+					Password: "secret",
+					From:     "backups@example.com",
+					To:       []string{"ops@example.com", "dev@example.com"},
+				},
+			}}
+		})
+
+		It("sends the report", func() {
+			notifyRun()
+			Expect(stub.received()).To(HaveLen(1))
+			mail := stub.received()[0]
+			Expect(base64.StdEncoding.DecodeString(mail.auth)).To(Equal([]byte("\x00user\x00secret")))
+			Expect(mail.from).To(Equal("backups@example.com"))
+			Expect(mail.to).To(Equal([]string{"ops@example.com", "dev@example.com"}))
+			Expect(mail.data).To(ContainSubstring("Subject: Git backups failed: 1 of 3 repositories\r\n"))
+			Expect(mail.data).To(ContainSubstring("To: ops@example.com, dev@example.com\r\n"))
+			Expect(mail.data).To(ContainSubstring("\r\n\r\nStarted: 2025-01-02T03:04:05Z\r\n"))
+			Expect(mail.data).To(ContainSubstring("- generic: https://example.com/repo_2.git (not_found)\r\n"))
+		})
+	})
+})
@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/redact"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Report is the run summary sent to notification channels. Error messages are redacted.
+type Report struct {
+	Status    string         `json:"status"`
+	Recovered bool           `json:"recovered"`
+	Started   time.Time      `json:"started"`
+	Duration  Duration       `json:"duration_seconds"`
+	Profiles  []string       `json:"profiles"`
+	Totals    Totals         `json:"totals"`
+	Failures  []Failure      `json:"failures"`
+	Orphaned  []OrphanedRepo `json:"orphaned"`
+	Errors    []string       `json:"errors"`
+}
+
+type Totals struct {
+	Targets   int `json:"targets"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"`
+	Orphaned  int `json:"orphaned"`
+}
+
+type Failure struct {
+	Profile string `json:"profile"`
+	URL     string `json:"url"`
+	Path    string `json:"path"`
+	Reason  string `json:"reason"`
+	Error   string `json:"error"`
+}
+
+type OrphanedRepo struct {
+	Profile string `json:"profile"`
+	Path    string `json:"path"`
+}
+
+// Duration is encoded in JSON as a number of seconds.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return fmt.Appendf(nil, "%.3f", time.Duration(d).Seconds()), nil
+}
+
+// NewReport builds the report of the given profiles, or of all profiles if none are given.
+func NewReport(summary launcher.Summary, profiles []string) Report {
+	inScope := func(profile string) bool {
+		return len(profiles) == 0 || slices.Contains(profiles, profile)
+	}
+
+	report := Report{
+		Started:  summary.Started,
+		Duration: Duration(summary.Duration),
+		Profiles: profiles,
+		Failures: []Failure{},
+		Orphaned: []OrphanedRepo{},
+		Errors:   []string{},
+	}
+
+	for _, profile := range summary.Profiles {
+		if inScope(profile.Name) && profile.Err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%v: %v", profile.Name, redact.String(profile.Err.Error())))
+		}
+	}
+
+	for _, result := range summary.Targets {
+		if !inScope(result.Target.Profile) {
+			continue
+		}
+
+		report.Totals.Targets++
+		if result.Err == nil {
+			report.Totals.Succeeded++
+			continue
+		}
+
+		report.Totals.Failed++
+		report.Failures = append(report.Failures, Failure{
+			Profile: result.Target.Profile,
+			URL:     redact.String(result.Target.URL),
+			Path:    result.Target.Path,
+			Reason:  string(backup.ClassifyError(result.Err)),
+			Error:   redact.String(result.Err.Error()),
+		})
+	}
+
+	for _, skipped := range summary.Skipped {
+		if inScope(skipped.Profile) {
+			report.Totals.Skipped++
+		}
+	}
+
+	for _, orphan := range summary.Orphaned {
+		if inScope(orphan.Profile) {
+			report.Totals.Orphaned++
+			report.Orphaned = append(report.Orphaned, OrphanedRepo(orphan))
+		}
+	}
+
+	// Errors which are not tied to a target or a profile (e.g. invalid filters) concern every profile.
+	if len(profiles) == 0 && summary.Err != nil && report.Totals.Failed == 0 && len(report.Errors) == 0 {
+		report.Errors = append(report.Errors, redact.String(summary.Err.Error()))
+	}
+
+	report.Status = StatusSuccess
+	if report.Totals.Failed > 0 || len(report.Errors) > 0 {
+		report.Status = StatusFailure
+	}
+
+	return report
+}
+
+// Interrupted reports whether the run was stopped before it finished, e.g. on shutdown.
+func Interrupted(summary launcher.Summary) bool {
+	return errors.Is(summary.Err, context.Canceled)
+}
+
+// Empty reports whether no repository of the report was backed up and none of its profiles failed.
+func (r Report) Empty() bool {
+	return r.Totals.Targets == 0 && len(r.Errors) == 0
+}
+
+func (r Report) Failed() bool {
+	return r.Status == StatusFailure
+}
+
+func (r Report) Title() string {
+	switch {
+	case r.Failed():
+		return fmt.Sprintf("Git backups failed: %v of %v repositories", r.Totals.Failed, r.Totals.Targets)
+	case r.Recovered:
+		return fmt.Sprintf("Git backups recovered: %v repositories backed up", r.Totals.Succeeded)
+	default:
+		return fmt.Sprintf("Git backups succeeded: %v repositories backed up", r.Totals.Succeeded)
+	}
+}
+
+// Text is a plain text description of the report for chats and emails.
+func (r Report) Text() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Started: %v\n", r.Started.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Duration: %v\n", time.Duration(r.Duration).Round(time.Second))
+	if len(r.Profiles) > 0 {
+		fmt.Fprintf(&sb, "Profiles: %v\n", strings.Join(r.Profiles, ", "))
+	}
+	fmt.Fprintf(&sb, "Backed up: %v, failed: %v, skipped: %v, orphaned: %v\n",
+		r.Totals.Succeeded, r.Totals.Failed, r.Totals.Skipped, r.Totals.Orphaned)
+
+	if len(r.Errors) > 0 {
+		sb.WriteString("\nErrors:\n")
+		for _, err := range r.Errors {
+			fmt.Fprintf(&sb, "- %v\n", err)
+		}
+	}
+
+	if len(r.Failures) > 0 {
+		sb.WriteString("\nFailures:\n")
+		for _, failure := range r.Failures {
+			fmt.Fprintf(&sb, "- %v: %v (%v)\n", failure.Profile, failure.URL, failure.Reason)
+		}
+	}
+
+	if len(r.Orphaned) > 0 {
+		sb.WriteString("\nOrphaned repositories:\n")
+		for _, orphan := range r.Orphaned {
+			fmt.Fprintf(&sb, "- %v: %v\n", orphan.Profile, orphan.Path)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package notify_test
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+)
+
+// smtpStub is a minimal in-process SMTP server which accepts every message.
+type smtpStub struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	mails    []stubMail
+}
+
+type stubMail struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStub() (*smtpStub, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	stub := &smtpStub{listener: listener}
+	go stub.serve()
+
+	return stub, nil
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStub) close() {
+	s.listener.Close()
+}
+
+func (s *smtpStub) received() []stubMail {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]stubMail(nil), s.mails...)
+}
+
+func (s *smtpStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStub) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	var mail stubMail
+	reply("220 localhost ESMTP stub")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"):
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(command, "AUTH PLAIN"):
+			mail.auth = strings.TrimSpace(line[len("AUTH PLAIN"):])
+			reply("235 2.7.0 Authentication successful")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			mail.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			mail.to = append(mail.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			mail.data = data.String()
+			s.mutex.Lock()
+			s.mails = append(s.mails, mail)
+			s.mutex.Unlock()
+			mail = stubMail{}
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store keeps whether the previous run failed for every channel, which is needed to detect recoveries.
+type Store interface {
+	Failed(channel string) (bool, error)
+	SetFailed(channel string, failed bool) error
+}
+
+type MemoryStore struct {
+	mutex  sync.Mutex
+	failed map[string]bool
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{failed: map[string]bool{}}
+}
+
+func (s *MemoryStore) Failed(channel string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.failed[channel], nil
+}
+
+func (s *MemoryStore) SetFailed(channel string, failed bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failed[channel] = failed
+
+	return nil
+}
+
+// FileStore keeps the state in a JSON file so that recoveries are detected across one-shot runs.
+type FileStore struct {
+	mutex    sync.Mutex
+	fileName string
+}
+
+type channelState struct {
+	Failed bool `json:"failed"`
+}
+
+func NewFileStore(fileName string) *FileStore {
+	return &FileStore{fileName: fileName}
+}
+
+func (s *FileStore) Failed(channel string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	states, err := s.read()
+
+	return states[channel].Failed, err
+}
+
+func (s *FileStore) SetFailed(channel string, failed bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return err
+	}
+	states[channel] = channelState{Failed: failed}
+
+	content, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile := filepath.Join(filepath.Dir(s.fileName), "."+filepath.Base(s.fileName)+".tmp")
+	if err := os.WriteFile(tmpFile, content, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, s.fileName)
+}
+
+func (s *FileStore) read() (map[string]channelState, error) {
+	states := map[string]channelState{}
+	content, err := os.ReadFile(s.fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return states, nil
+	}
+	if err != nil {
+		return states, err
+	}
+
+	if err := json.Unmarshal(content, &states); err != nil {
+		return map[string]channelState{}, err
+	}
+
+	return states, nil
+}
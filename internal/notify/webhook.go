@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type format int
+
+const (
+	formatJSON format = iota
+	formatSlack
+	formatMattermost
+)
+
+// webhook posts the report as JSON. Slack and Mattermost incoming webhooks get a message with text only.
+type webhook struct {
+	url    string
+	format format
+	client *http.Client
+}
+
+func newWebhook(url string, format format) webhook {
+	return webhook{url: url, format: format, client: http.DefaultClient}
+}
+
+func (w webhook) Send(ctx context.Context, report Report) error {
+	var payload any = report
+	switch w.format {
+	case formatSlack:
+		payload = chatMessage{Text: fmt.Sprintf("*%v*\n%v", report.Title(), report.Text())}
+	case formatMattermost:
+		payload = chatMessage{Text: fmt.Sprintf("**%v**\n%v", report.Title(), report.Text())}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %v", resp.Status)
+	}
+
+	return nil
+}
+
+type chatMessage struct {
+	Text string `json:"text"`
+}
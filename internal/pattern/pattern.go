@@ -0,0 +1,107 @@
+package pattern
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	negationPrefix = "!"
+	regexPrefix    = "regex:"
+)
+
+// Pattern matches repositories by name. Patterns containing "/" are matched against "owner/name",
+// others against the name only. Supported forms are exact names, globs ("svc-*") and regular
+// expressions ("regex:^svc-.*"). A leading "!" negates the pattern. Matching is case-insensitive.
+type Pattern struct {
+	text      string
+	negated   bool
+	qualified bool
+	match     func(string) bool
+}
+
+func Compile(text string) (Pattern, error) {
+	p := Pattern{text: text}
+	expr := text
+	if rest, ok := strings.CutPrefix(expr, negationPrefix); ok {
+		p.negated = true
+		expr = rest
+	}
+
+	if rest, ok := strings.CutPrefix(expr, regexPrefix); ok {
+		re, err := regexp.Compile("(?i)" + rest)
+		if err != nil {
+			return Pattern{}, fmt.Errorf("invalid pattern %q: %w", text, err)
+		}
+		p.qualified = strings.Contains(rest, "/")
+		p.match = re.MatchString
+
+		return p, nil
+	}
+
+	expr = strings.ToLower(expr)
+	if expr == "" {
+		return Pattern{}, fmt.Errorf("invalid pattern %q: empty pattern", text)
+	}
+
+	if _, err := path.Match(expr, ""); err != nil {
+		return Pattern{}, fmt.Errorf("invalid pattern %q: %w", text, err)
+	}
+
+	p.qualified = strings.Contains(expr, "/")
+	p.match = func(name string) bool {
+		matched, _ := path.Match(expr, strings.ToLower(name))
+		return matched
+	}
+
+	return p, nil
+}
+
+func (p Pattern) String() string {
+	return p.text
+}
+
+func (p Pattern) Match(owner, name string) bool {
+	if p.qualified {
+		return p.match(owner + "/" + name)
+	}
+
+	return p.match(name)
+}
+
+type List []Pattern
+
+func CompileList(texts []string) (List, error) {
+	list := make(List, len(texts))
+	for i, text := range texts {
+		p, err := Compile(text)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = p
+	}
+
+	return list, nil
+}
+
+// Match evaluates patterns in order and the last matching one wins, so negated patterns can carve out
+// exceptions. A list consisting of negated patterns only matches everything these patterns don't.
+func (l List) Match(owner, name string) bool {
+	matched := len(l) > 0
+	for _, p := range l {
+		if !p.negated {
+			matched = false
+			break
+		}
+	}
+
+	for _, p := range l {
+		if p.Match(owner, name) {
+			matched = !p.negated
+		}
+	}
+
+	return matched
+}
@@ -0,0 +1,52 @@
+package pattern_test
+
+import (
+	"github.com/AntonKosov/git-backups/internal/pattern"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("Pattern", func(text, owner, name string, expected bool) {
+	p, err := pattern.Compile(text)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(p.Match(owner, name)).To(Equal(expected))
+	Expect(p.String()).To(Equal(text))
+},
+	Entry("exact name", "repo", "owner", "repo", true),
+	Entry("exact name with different capitalization", "RePo", "owner", "rEpO", true),
+	Entry("different name", "repo", "owner", "repo2", false),
+	Entry("exact name ignores the owner", "repo", "other", "repo", true),
+	Entry("owner-qualified name", "owner/repo", "owner", "repo", true),
+	Entry("owner-qualified name of another owner", "owner/repo", "other", "repo", false),
+	Entry("glob", "*-archive", "owner", "old-archive", true),
+	Entry("glob which does not match", "*-archive", "owner", "archive-old", false),
+	Entry("owner glob", "MyOrg/*", "myorg", "repo", true),
+	Entry("owner glob of another owner", "myorg/*", "other", "repo", false),
+	Entry("regex", "regex:^svc-.*", "owner", "SVC-users", true),
+	Entry("regex which does not match", "regex:^svc-.*", "owner", "users-svc", false),
+	Entry("owner-qualified regex", "regex:^myorg/(a|b)$", "myorg", "b", true),
+	Entry("negated pattern matches as the base pattern", "!*-archive", "owner", "old-archive", true),
+)
+
+var _ = DescribeTable("Invalid patterns", func(text, expectedError string) {
+	_, err := pattern.Compile(text)
+	Expect(err).To(MatchError(ContainSubstring(expectedError)))
+},
+	Entry("empty", "", `invalid pattern "": empty pattern`),
+	Entry("bad glob", "repo[", `invalid pattern "repo[": syntax error in pattern`),
+	Entry("bad regex", "regex:(", `invalid pattern "regex:(": error parsing regexp`),
+)
+
+var _ = DescribeTable("List", func(texts []string, name string, expected bool) {
+	list, err := pattern.CompileList(texts)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(list.Match("owner", name)).To(Equal(expected))
+},
+	Entry("empty list", []string{}, "repo", false),
+	Entry("positive pattern", []string{"svc-*"}, "svc-a", true),
+	Entry("negated pattern only", []string{"!*-archive"}, "svc-a", true),
+	Entry("negated pattern only matching", []string{"!*-archive"}, "svc-archive", false),
+	Entry("exception after a positive pattern", []string{"svc-*", "!*-archive"}, "svc-archive", false),
+	Entry("positive pattern after an exception", []string{"svc-*", "!*-archive", "svc-archive"}, "svc-archive", true),
+	Entry("no pattern matches", []string{"svc-*"}, "tool", false),
+)
@@ -0,0 +1,73 @@
+package redact
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const Mask = "***"
+
+var (
+	mutex   sync.RWMutex
+	secrets []string
+
+	userInfoPattern      = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)([^/\s@:]*)(:[^/\s@]*)?@`)
+	authorizationPattern = regexp.MustCompile(`(?i)(authorization\s*[:=]\s*["\[]?(?:bearer|basic|token)?\s*)[^\s",\]]+`)
+	tokenPattern         = regexp.MustCompile(
+		`\b(?:gh[pousr]_[A-Za-z0-9]{16,}|github_pat_[A-Za-z0-9_]{22,}|glpat-[A-Za-z0-9_-]{20,}|xox[abposr]-[A-Za-z0-9-]{10,}|AKIA[0-9A-Z]{16})\b`,
+	)
+)
+
+func AddSecrets(values ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, value := range values {
+		if value == "" || slices.Contains(secrets, value) {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+
+	// Longer secrets go first so a secret containing another one is masked as a whole.
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+func String(s string) string {
+	mutex.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	mutex.RUnlock()
+
+	s = userInfoPattern.ReplaceAllStringFunc(s, maskUserInfo)
+	s = authorizationPattern.ReplaceAllString(s, "${1}"+Mask)
+
+	return tokenPattern.ReplaceAllString(s, Mask)
+}
+
+func Strings(values []string) []string {
+	redacted := make([]string, len(values))
+	for i, value := range values {
+		redacted[i] = String(value)
+	}
+
+	return redacted
+}
+
+func maskUserInfo(match string) string {
+	groups := userInfoPattern.FindStringSubmatch(match)
+	scheme, user, password := groups[1], groups[2], groups[3]
+	if password != "" {
+		return scheme + user + ":" + Mask + "@"
+	}
+
+	// Tokens are often passed as a user name over HTTP(S), e.g. https://<token>@github.com.
+	if strings.HasPrefix(strings.ToLower(scheme), "http") {
+		return scheme + Mask + "@"
+	}
+
+	return match
+}
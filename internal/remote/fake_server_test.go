@@ -0,0 +1,171 @@
+package remote_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// fakeServer is an in-process SSH server which serves the SFTP subsystem from the local file system and
+// only accepts the client key it was created with.
+type fakeServer struct {
+	mu         sync.Mutex
+	conns      []net.Conn
+	listener   net.Listener
+	config     *ssh.ServerConfig
+	hostKey    ssh.Signer
+	privateKey string
+	knownHosts string
+}
+
+func newFakeServer(folder string) (*fakeServer, error) {
+	hostKey, err := newSigner()
+	if err != nil {
+		return nil, err
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		return nil, err
+	}
+	clientKey, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &fakeServer{
+		listener:   listener,
+		hostKey:    hostKey,
+		privateKey: filepath.Join(folder, "id_ed25519"),
+		knownHosts: filepath.Join(folder, "known_hosts"),
+	}
+
+	s.config = &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if !bytes.Equal(key.Marshal(), clientKey.PublicKey().Marshal()) {
+				return nil, errors.New("unknown public key")
+			}
+			return nil, nil
+		},
+	}
+	s.config.AddHostKey(hostKey)
+
+	if err := os.WriteFile(s.privateKey, pem.EncodeToMemory(block), 0o600); err != nil {
+		return nil, err
+	}
+	if err := s.writeKnownHosts(hostKey.PublicKey()); err != nil {
+		return nil, err
+	}
+
+	go s.serve()
+
+	return s, nil
+}
+
+func (s *fakeServer) Address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeServer) Close() error {
+	return s.listener.Close()
+}
+
+// Disconnect closes the connections of all clients, like a restart of the server.
+func (s *fakeServer) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *fakeServer) writeKnownHosts(key ssh.PublicKey) error {
+	line := knownhosts.Line([]string{knownhosts.Normalize(s.Address())}, key)
+
+	return os.WriteFile(s.knownHosts, []byte(line+"\n"), 0o600)
+}
+
+func (s *fakeServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	_, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		_ = conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			for req := range requests {
+				isSFTP := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(isSFTP, nil)
+				if !isSFTP {
+					continue
+				}
+
+				server, err := sftp.NewServer(channel)
+				if err != nil {
+					_ = channel.Close()
+					return
+				}
+				_ = server.Serve()
+				_ = channel.Close()
+			}
+		}()
+	}
+}
+
+func newSigner() (ssh.Signer, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a key: %w", err)
+	}
+
+	return ssh.NewSignerFromKey(private)
+}
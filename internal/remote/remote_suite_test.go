@@ -0,0 +1,20 @@
+package remote_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+func TestRemote(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Remote Suite")
+}
+
+var _ = BeforeEach(func() {
+	ctx = context.Background()
+})
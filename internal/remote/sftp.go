@@ -0,0 +1,363 @@
+package remote
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const dialTimeout = 30 * time.Second
+
+// SFTP keeps repositories in a folder on an SSH server. Files are compared by size and modification time,
+// so that only new pack files and changed refs are transferred. It connects on first use.
+type SFTP struct {
+	conf   config.SFTP
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func NewSFTP(conf config.SFTP) *SFTP {
+	return &SFTP{conf: conf}
+}
+
+func (s *SFTP) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+
+	err := errors.Join(s.client.Close(), s.conn.Close())
+	s.client, s.conn = nil, nil
+
+	return err
+}
+
+// Pull downloads the repository into a temporary folder next to the local folder, which is renamed once
+// the download is complete.
+func (s *SFTP) Pull(ctx context.Context, relativePath, localFolder string) error {
+	return s.withClient(func(client *sftp.Client) error {
+		return s.pull(ctx, client, relativePath, localFolder)
+	})
+}
+
+func (s *SFTP) pull(ctx context.Context, client *sftp.Client, relativePath, localFolder string) (err error) {
+	remoteFolder := path.Join(s.conf.Folder, relativePath)
+	if _, err := client.Stat(remoteFolder); err != nil {
+		return err
+	}
+
+	files, err := listRemote(client, remoteFolder)
+	if err != nil {
+		return err
+	}
+	maps.DeleteFunc(files, func(name string, _ fs.FileInfo) bool { return isTempFile(name) })
+
+	if err := os.MkdirAll(filepath.Dir(localFolder), 0o755); err != nil {
+		return err
+	}
+
+	tempFolder, err := os.MkdirTemp(filepath.Dir(localFolder), "."+filepath.Base(localFolder)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.RemoveAll(tempFolder)
+		}
+	}()
+
+	var size int64
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := download(client, path.Join(remoteFolder, name), filepath.Join(tempFolder, filepath.FromSlash(name)), files[name]); err != nil {
+			return fmt.Errorf("failed to download %v: %w", name, err)
+		}
+		size += files[name].Size()
+	}
+
+	if err := os.Rename(tempFolder, localFolder); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Pulled repository from the remote storage", "files", len(files), "bytes", size)
+	return nil
+}
+
+// Push uploads the files of the local folder which are missing from the remote folder or differ from it,
+// and then deletes the remote files which no longer exist locally, including temporary files left by
+// interrupted uploads. Objects are uploaded before refs, and pack files before their indexes, so that
+// the remote refs never point to missing objects.
+func (s *SFTP) Push(ctx context.Context, relativePath, localFolder string) error {
+	return s.withClient(func(client *sftp.Client) error {
+		return s.push(ctx, client, relativePath, localFolder)
+	})
+}
+
+func (s *SFTP) push(ctx context.Context, client *sftp.Client, relativePath, localFolder string) error {
+	remoteFolder := path.Join(s.conf.Folder, relativePath)
+	remoteFiles, err := listRemote(client, remoteFolder)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	localFiles, err := listLocal(localFolder)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for name, local := range localFiles {
+		if remote, ok := remoteFiles[name]; !ok || remote.Size() != local.Size() || remote.ModTime().Unix() != local.ModTime().Unix() {
+			changed = append(changed, name)
+		}
+	}
+	slices.SortFunc(changed, func(a, b string) int {
+		return cmp.Or(cmp.Compare(uploadOrder(a), uploadOrder(b)), strings.Compare(a, b))
+	})
+
+	var size int64
+	for _, name := range changed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := upload(client, filepath.Join(localFolder, filepath.FromSlash(name)), path.Join(remoteFolder, name), localFiles[name]); err != nil {
+			return fmt.Errorf("failed to upload %v: %w", name, err)
+		}
+		size += localFiles[name].Size()
+	}
+
+	deleted := 0
+	for name := range remoteFiles {
+		if _, ok := localFiles[name]; !ok {
+			if err := client.Remove(path.Join(remoteFolder, name)); err != nil {
+				return fmt.Errorf("failed to delete %v: %w", name, err)
+			}
+			deleted++
+		}
+	}
+
+	slog.InfoContext(ctx, "Pushed repository to the remote storage", "uploaded", len(changed), "bytes", size, "deleted", deleted)
+	return nil
+}
+
+// withClient runs the operation on the cached connection. If the connection was lost, e.g. because the
+// server restarted between backups, it's dropped and the operation is run once more on a new connection.
+func (s *SFTP) withClient(operation func(*sftp.Client) error) error {
+	client, err := s.connect()
+	if err != nil {
+		return err
+	}
+
+	err = operation(client)
+	if !connectionLost(err) {
+		return err
+	}
+
+	s.drop(client)
+	client, connectErr := s.connect()
+	if connectErr != nil {
+		return errors.Join(err, connectErr)
+	}
+
+	return operation(client)
+}
+
+func connectionLost(err error) bool {
+	return errors.Is(err, sftp.ErrSSHFxConnectionLost) || errors.Is(err, io.EOF)
+}
+
+// drop closes the connection of the client unless another operation has already replaced it.
+func (s *SFTP) drop(client *sftp.Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != client {
+		return
+	}
+
+	_ = s.client.Close()
+	_ = s.conn.Close()
+	s.client, s.conn = nil, nil
+}
+
+func (s *SFTP) connect() (*sftp.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	key, err := os.ReadFile(s.conf.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key %v: %w", s.conf.PrivateKey, err)
+	}
+
+	hostKeyCallback, err := knownhosts.New(s.conf.KnownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+
+	conn, err := ssh.Dial("tcp", s.conf.Address, &ssh.ClientConfig{
+		User:            s.conf.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         dialTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %v: %w", s.conf.Address, err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to start SFTP on %v: %w", s.conf.Address, err), conn.Close())
+	}
+
+	s.conn, s.client = conn, client
+
+	return client, nil
+}
+
+// uploadOrder puts pack files and loose objects first, then pack indexes, and then refs and the rest.
+func uploadOrder(name string) int {
+	switch {
+	case strings.HasPrefix(name, "objects/") && !strings.HasSuffix(name, ".idx"):
+		return 0
+	case strings.HasPrefix(name, "objects/"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// upload writes the file under a temporary name and renames it, so that a partial file is never visible.
+func upload(client *sftp.Client, localFile, remoteFile string, info fs.FileInfo) error {
+	source, err := os.Open(localFile)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	if err := client.MkdirAll(path.Dir(remoteFile)); err != nil {
+		return err
+	}
+
+	tempFile := path.Join(path.Dir(remoteFile), "."+path.Base(remoteFile)+".tmp")
+	destination, err := client.Create(tempFile)
+	if err != nil {
+		return err
+	}
+
+	if _, err := destination.ReadFrom(source); err != nil {
+		return errors.Join(err, destination.Close(), client.Remove(tempFile))
+	}
+
+	if err := destination.Close(); err != nil {
+		return errors.Join(err, client.Remove(tempFile))
+	}
+
+	if err := client.PosixRename(tempFile, remoteFile); err != nil {
+		return errors.Join(err, client.Remove(tempFile))
+	}
+
+	return client.Chtimes(remoteFile, info.ModTime(), info.ModTime())
+}
+
+func download(client *sftp.Client, remoteFile, localFile string, info fs.FileInfo) error {
+	source, err := client.Open(remoteFile)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	if err := os.MkdirAll(filepath.Dir(localFile), 0o755); err != nil {
+		return err
+	}
+
+	destination, err := os.OpenFile(localFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := source.WriteTo(destination); err != nil {
+		return errors.Join(err, destination.Close())
+	}
+
+	if err := destination.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(localFile, info.ModTime(), info.ModTime())
+}
+
+// listRemote returns the files in the folder by their slash-separated paths within it.
+func listRemote(client *sftp.Client, folder string) (map[string]fs.FileInfo, error) {
+	files := map[string]fs.FileInfo{}
+	walker := client.Walk(folder)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, err
+		}
+
+		if walker.Stat().Mode().IsRegular() {
+			files[strings.TrimPrefix(walker.Path(), folder+"/")] = walker.Stat()
+		}
+	}
+
+	return files, nil
+}
+
+// isTempFile reports whether the remote file is a partial upload, see upload.
+func isTempFile(name string) bool {
+	return strings.HasPrefix(path.Base(name), ".") && strings.HasSuffix(name, ".tmp")
+}
+
+func listLocal(folder string) (map[string]fs.FileInfo, error) {
+	files := map[string]fs.FileInfo{}
+	err := filepath.WalkDir(folder, func(fileName string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(folder, fileName)
+		files[filepath.ToSlash(name)] = info
+
+		return err
+	})
+
+	return files, err
+}
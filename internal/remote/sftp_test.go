@@ -0,0 +1,198 @@
+package remote_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+	"github.com/AntonKosov/git-backups/internal/remote"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SFTP tests", func() {
+	const relativePath = "owner/repo.git"
+
+	var (
+		server       *fakeServer
+		storage      *remote.SFTP
+		localFolder  string
+		remoteFolder string
+	)
+
+	writeFile := func(folder, name, content string) {
+		fileName := filepath.Join(folder, name)
+		Expect(os.MkdirAll(filepath.Dir(fileName), 0o755)).To(Succeed())
+		Expect(os.WriteFile(fileName, []byte(content), 0o644)).To(Succeed())
+	}
+
+	readFile := func(folder, name string) string {
+		content, err := os.ReadFile(filepath.Join(folder, name))
+		Expect(err).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		var err error
+		server, err = newFakeServer(GinkgoT().TempDir())
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(server.Close)
+
+		localFolder = filepath.Join(GinkgoT().TempDir(), relativePath)
+		remoteFolder = GinkgoT().TempDir()
+
+		storage = remote.NewSFTP(config.SFTP{
+			Address:    server.Address(),
+			User:       "backup",
+			PrivateKey: server.privateKey,
+			KnownHosts: server.knownHosts,
+			Folder:     remoteFolder,
+		})
+		DeferCleanup(storage.Close)
+
+		writeFile(localFolder, "HEAD", "ref: refs/heads/main")
+		writeFile(localFolder, "packed-refs", "1234 refs/heads/main")
+		writeFile(localFolder, "objects/pack/pack-1.pack", "pack")
+		writeFile(localFolder, "objects/pack/pack-1.idx", "idx")
+	})
+
+	Describe("Push", func() {
+		It("uploads the repository", func() {
+			Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+
+			uploaded := filepath.Join(remoteFolder, relativePath)
+			Expect(readFile(uploaded, "HEAD")).To(Equal("ref: refs/heads/main"))
+			Expect(readFile(uploaded, "packed-refs")).To(Equal("1234 refs/heads/main"))
+			Expect(readFile(uploaded, "objects/pack/pack-1.pack")).To(Equal("pack"))
+			Expect(readFile(uploaded, "objects/pack/pack-1.idx")).To(Equal("idx"))
+		})
+
+		It("keeps the modification times", func() {
+			modTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+			Expect(os.Chtimes(filepath.Join(localFolder, "HEAD"), modTime, modTime)).To(Succeed())
+
+			Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(remoteFolder, relativePath, "HEAD"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.ModTime().Equal(modTime)).To(BeTrue())
+		})
+
+		When("the repository was pushed before", func() {
+			var uploaded string
+
+			BeforeEach(func() {
+				Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+				uploaded = filepath.Join(remoteFolder, relativePath)
+			})
+
+			It("skips unchanged files", func() {
+				// The same size and modification time mean the file wasn't changed, so the remote copy stays.
+				info, err := os.Stat(filepath.Join(localFolder, "HEAD"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(os.WriteFile(filepath.Join(uploaded, "HEAD"), []byte("ref: refs/heads/xxxx"), 0o644)).To(Succeed())
+				Expect(os.Chtimes(filepath.Join(uploaded, "HEAD"), info.ModTime(), info.ModTime())).To(Succeed())
+
+				Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+
+				Expect(readFile(uploaded, "HEAD")).To(Equal("ref: refs/heads/xxxx"))
+			})
+
+			It("uploads changed files", func() {
+				writeFile(localFolder, "packed-refs", "5678 refs/heads/main\n1234 refs/heads/old")
+
+				Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+
+				Expect(readFile(uploaded, "packed-refs")).To(Equal("5678 refs/heads/main\n1234 refs/heads/old"))
+			})
+
+			It("deletes files which no longer exist locally", func() {
+				Expect(os.Remove(filepath.Join(localFolder, "objects/pack/pack-1.pack"))).To(Succeed())
+				Expect(os.Remove(filepath.Join(localFolder, "objects/pack/pack-1.idx"))).To(Succeed())
+				writeFile(localFolder, "objects/pack/pack-2.pack", "new pack")
+				writeFile(localFolder, "objects/pack/pack-2.idx", "new idx")
+
+				Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+
+				Expect(filepath.Join(uploaded, "objects/pack/pack-1.pack")).NotTo(BeAnExistingFile())
+				Expect(filepath.Join(uploaded, "objects/pack/pack-1.idx")).NotTo(BeAnExistingFile())
+				Expect(readFile(uploaded, "objects/pack/pack-2.pack")).To(Equal("new pack"))
+			})
+
+			It("deletes files left by interrupted uploads", func() {
+				writeFile(uploaded, "objects/pack/.pack-3.pack.tmp", "partial pack")
+
+				Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+
+				Expect(filepath.Join(uploaded, "objects/pack/.pack-3.pack.tmp")).NotTo(BeAnExistingFile())
+				Expect(readFile(uploaded, "objects/pack/pack-1.pack")).To(Equal("pack"))
+			})
+		})
+
+		When("the server closed the connection since the last push", func() {
+			BeforeEach(func() {
+				Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+				server.Disconnect()
+				writeFile(localFolder, "packed-refs", "5678 refs/heads/develop")
+			})
+
+			It("connects again", func() {
+				Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+				Expect(readFile(filepath.Join(remoteFolder, relativePath), "packed-refs")).To(Equal("5678 refs/heads/develop"))
+			})
+		})
+
+		When("the host key is unknown", func() {
+			BeforeEach(func() {
+				other, err := newSigner()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(server.writeKnownHosts(other.PublicKey())).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := storage.Push(ctx, relativePath, localFolder)
+
+				Expect(err).To(MatchError(ContainSubstring("failed to connect to " + server.Address())))
+				Expect(filepath.Join(remoteFolder, relativePath)).NotTo(BeADirectory())
+			})
+		})
+	})
+
+	Describe("Pull", func() {
+		var pulledFolder string
+
+		BeforeEach(func() {
+			pulledFolder = filepath.Join(GinkgoT().TempDir(), "restored", relativePath)
+		})
+
+		It("downloads the pushed repository", func() {
+			Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+
+			Expect(storage.Pull(ctx, relativePath, pulledFolder)).To(Succeed())
+
+			Expect(readFile(pulledFolder, "HEAD")).To(Equal("ref: refs/heads/main"))
+			Expect(readFile(pulledFolder, "objects/pack/pack-1.pack")).To(Equal("pack"))
+			entries, err := os.ReadDir(filepath.Dir(pulledFolder))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+
+		It("skips files left by interrupted uploads", func() {
+			Expect(storage.Push(ctx, relativePath, localFolder)).To(Succeed())
+			writeFile(filepath.Join(remoteFolder, relativePath), "objects/pack/.pack-3.pack.tmp", "partial pack")
+
+			Expect(storage.Pull(ctx, relativePath, pulledFolder)).To(Succeed())
+
+			Expect(filepath.Join(pulledFolder, "objects/pack/.pack-3.pack.tmp")).NotTo(BeAnExistingFile())
+		})
+
+		It("returns ErrNotExist if the repository is not in the storage", func() {
+			err := storage.Pull(ctx, relativePath, pulledFolder)
+
+			Expect(err).To(MatchError(fs.ErrNotExist))
+			Expect(pulledFolder).NotTo(BeADirectory())
+		})
+	})
+})
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/fsutil"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/redact"
+)
+
+const (
+	StatusSuccess     = "success"
+	StatusFailure     = "failure"
+	StatusSkipped     = "skipped"
+	StatusInterrupted = "interrupted"
+
+	ActionSkip = "skip"
+)
+
+// Report describes a finished run. URLs and error messages are redacted.
+type Report struct {
+	Summary  Summary  `json:"summary"`
+	Targets  []Target `json:"targets"`
+	Orphaned []Orphan `json:"orphaned"`
+}
+
+// Summary is a flat description of the run which can be kept as a line of a history file.
+type Summary struct {
+	Started         time.Time `json:"started"`
+	Finished        time.Time `json:"finished"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Status          string    `json:"status"`
+	Targets         int       `json:"targets"`
+	Succeeded       int       `json:"succeeded"`
+	Failed          int       `json:"failed"`
+	Skipped         int       `json:"skipped"`
+	Orphaned        int       `json:"orphaned"`
+	FetchedBytes    int64     `json:"fetched_bytes"`
+	SizeBytes       int64     `json:"size_bytes"`
+	Errors          []string  `json:"errors"`
+}
+
+type Target struct {
+	Profile string `json:"profile"`
+	URL     string `json:"url"`
+	Path    string `json:"path"`
+	// Action is clone, fetch or skip. It is empty if the backup failed before git was run.
+	Action          string    `json:"action"`
+	Status          string    `json:"status"`
+	Started         time.Time `json:"started,omitzero"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	ErrorClass      string    `json:"error_class,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	SkipReason      string    `json:"skip_reason,omitempty"`
+	Verified        bool      `json:"verified"`
+	RefsBefore      int       `json:"refs_before"`
+	RefsAfter       int       `json:"refs_after"`
+	SizeBytes       int64     `json:"size_bytes"`
+	FetchedBytes    int64     `json:"fetched_bytes"`
+}
+
+type Orphan struct {
+	Profile string `json:"profile"`
+	Path    string `json:"path"`
+}
+
+func New(summary launcher.Summary) Report {
+	report := Report{
+		Summary: Summary{
+			Started:         summary.Started,
+			Finished:        summary.Started.Add(summary.Duration),
+			DurationSeconds: summary.Duration.Seconds(),
+			Status:          runStatus(summary.Err),
+			Errors:          []string{},
+		},
+		Targets:  []Target{},
+		Orphaned: []Orphan{},
+	}
+
+	for _, result := range summary.Targets {
+		target := NewTarget(result)
+		report.Targets = append(report.Targets, target)
+		report.Summary.Targets++
+		report.Summary.FetchedBytes += target.FetchedBytes
+		report.Summary.SizeBytes += target.SizeBytes
+		if result.Err != nil {
+			report.Summary.Failed++
+		} else {
+			report.Summary.Succeeded++
+		}
+	}
+
+	for _, skipped := range summary.Skipped {
+		report.Summary.Skipped++
+		report.Targets = append(report.Targets, Target{
+			Profile:    skipped.Profile,
+			URL:        redact.String(skipped.URL),
+			Path:       skipped.Path,
+			Action:     ActionSkip,
+			Status:     StatusSkipped,
+			SkipReason: skipped.Reason,
+		})
+	}
+
+	for _, orphan := range summary.Orphaned {
+		report.Summary.Orphaned++
+		report.Orphaned = append(report.Orphaned, Orphan(orphan))
+	}
+
+	for _, profile := range summary.Profiles {
+		if profile.Err != nil {
+			report.Summary.Errors = append(report.Summary.Errors, fmt.Sprintf("%v: %v", profile.Name, redact.String(profile.Err.Error())))
+		}
+	}
+
+	// Errors which are not tied to a target or a profile, e.g. invalid filters.
+	if summary.Err != nil && report.Summary.Failed == 0 && len(report.Summary.Errors) == 0 {
+		report.Summary.Errors = append(report.Summary.Errors, redact.String(summary.Err.Error()))
+	}
+
+	return report
+}
+
+// NewTarget describes the backup of a target.
+func NewTarget(result launcher.TargetResult) Target {
+	target := Target{
+		Profile:         result.Target.Profile,
+		URL:             redact.String(result.Target.URL),
+		Path:            result.Target.Path,
+		Action:          string(result.Backup.Action),
+		Status:          StatusSuccess,
+		Started:         result.Started,
+		DurationSeconds: result.Duration.Seconds(),
+		Verified:        result.Verified,
+		RefsBefore:      result.Backup.RefsBefore,
+		RefsAfter:       result.Backup.RefsAfter,
+		SizeBytes:       result.Backup.Size,
+		FetchedBytes:    result.Backup.Fetched(),
+	}
+
+	if result.Err != nil {
+		target.Status = StatusFailure
+		target.ErrorClass = string(backup.ClassifyError(result.Err))
+		target.Error = redact.String(result.Err.Error())
+	}
+
+	return target
+}
+
+func runStatus(err error) string {
+	switch {
+	case err == nil:
+		return StatusSuccess
+	case errors.Is(err, context.Canceled):
+		return StatusInterrupted
+	default:
+		return StatusFailure
+	}
+}
+
+func (r Report) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(r)
+}
+
+// WriteFile replaces the file atomically so that readers never see a partial report.
+func (r Report) WriteFile(fileName string) error {
+	return fsutil.WriteAtomically(fileName, r.Write)
+}
@@ -0,0 +1,23 @@
+package report_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var ctx context.Context
+
+func TestReport(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Report Suite")
+}
+
+var _ = BeforeEach(func() {
+	ctx = context.Background()
+})
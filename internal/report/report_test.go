@@ -0,0 +1,139 @@
+package report_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/launcher"
+	"github.com/AntonKosov/git-backups/internal/report"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report tests", func() {
+	var summary launcher.Summary
+
+	BeforeEach(func() {
+		started := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		backupErr := errors.New("remote: Repository not found.")
+		summary = launcher.Summary{
+			Started:  started,
+			Duration: 90 * time.Second,
+			Profiles: []launcher.ProfileResult{{Name: "generic", Discovered: 2, Selected: 2}},
+			Targets: []launcher.TargetResult{
+				{
+					Target:   launcher.Target{Profile: "generic", URL: "https://example.com/repo_1.git", Path: "/backups/repo_1"},
+					Started:  started,
+					Duration: 2 * time.Second,
+					Backup: backup.Result{
+						Action: backup.ActionFetch, Size: 150, RefsBefore: 3, RefsAfter: 4, ObjectsBefore: 100, ObjectsAfter: 150,
+					},
+					Verified: true,
+				},
+				{
+					Target:   launcher.Target{Profile: "generic", URL: "https://example.com/repo_2.git", Path: "/backups/repo_2"},
+					Started:  started.Add(2 * time.Second),
+					Duration: time.Second,
+					Backup:   backup.Result{Action: backup.ActionClone},
+					Err:      fmt.Errorf("failed to backup repository: %w", backupErr),
+				},
+			},
+			Skipped: []launcher.SkippedRepo{
+				{Profile: "github", Repo: "owner/fork", URL: "git@github.com:owner/fork.git", Path: "/backups/owner/fork", Reason: "is a fork"},
+			},
+			Orphaned: []launcher.OrphanedRepo{{Profile: "github", Path: "/backups/owner/deleted"}},
+			Err:      errors.Join(fmt.Errorf("failed to backup repository: %w", backupErr)),
+		}
+	})
+
+	It("writes the report", func() {
+		var sb strings.Builder
+		Expect(report.New(summary).Write(&sb)).To(Succeed())
+		Expect(sb.String()).To(MatchJSON(`{
+			"summary": {
+				"started": "2025-01-02T03:04:05Z",
+				"finished": "2025-01-02T03:05:35Z",
+				"duration_seconds": 90,
+				"status": "failure",
+				"targets": 2,
+				"succeeded": 1,
+				"failed": 1,
+				"skipped": 1,
+				"orphaned": 1,
+				"fetched_bytes": 50,
+				"size_bytes": 150,
+				"errors": []
+			},
+			"targets": [
+				{
+					"profile": "generic", "url": "https://example.com/repo_1.git", "path": "/backups/repo_1",
+					"action": "fetch", "status": "success", "started": "2025-01-02T03:04:05Z", "duration_seconds": 2, "verified": true,
+					"refs_before": 3, "refs_after": 4, "size_bytes": 150, "fetched_bytes": 50
+				},
+				{
+					"profile": "generic", "url": "https://example.com/repo_2.git", "path": "/backups/repo_2",
+					"action": "clone", "status": "failure", "started": "2025-01-02T03:04:07Z", "duration_seconds": 1, "verified": false,
+					"error_class": "not_found", "error": "failed to backup repository: remote: Repository not found.",
+					"refs_before": 0, "refs_after": 0, "size_bytes": 0, "fetched_bytes": 0
+				},
+				{
+					"profile": "github", "url": "git@github.com:owner/fork.git", "path": "/backups/owner/fork",
+					"action": "skip", "status": "skipped", "skip_reason": "is a fork", "duration_seconds": 0, "verified": false,
+					"refs_before": 0, "refs_after": 0, "size_bytes": 0, "fetched_bytes": 0
+				}
+			],
+			"orphaned": [{"profile": "github", "path": "/backups/owner/deleted"}]
+		}`))
+	})
+
+	It("reports errors which are not tied to targets", func() {
+		summary = launcher.Summary{
+			Profiles: []launcher.ProfileResult{{Name: "github", Err: errors.New("bad credentials")}},
+			Err:      errors.New("bad credentials"),
+		}
+
+		Expect(report.New(summary).Summary.Errors).To(Equal([]string{"github: bad credentials"}))
+	})
+
+	It("reports interrupted runs", func() {
+		summary.Err = errors.Join(summary.Err, context.Canceled)
+
+		Expect(report.New(summary).Summary.Status).To(Equal(report.StatusInterrupted))
+	})
+
+	It("reports successful runs", func() {
+		summary.Targets = summary.Targets[:1]
+		summary.Err = nil
+
+		Expect(report.New(summary).Summary.Status).To(Equal(report.StatusSuccess))
+	})
+
+	Describe("Writer", func() {
+		var fileName string
+
+		BeforeEach(func() {
+			fileName = filepath.Join(GinkgoT().TempDir(), "report.json")
+		})
+
+		It("writes the report when the run finishes", func() {
+			report.NewWriter(fileName).RunFinished(ctx, summary)
+
+			content, err := os.ReadFile(fileName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"status": "failure"`))
+			Expect(filepath.Glob(filepath.Join(filepath.Dir(fileName), ".*.tmp"))).To(BeEmpty())
+		})
+
+		It("ignores errors", func() {
+			fileName = filepath.Join(fileName, "missing", "report.json")
+
+			Expect(func() { report.NewWriter(fileName).RunFinished(ctx, summary) }).NotTo(Panic())
+		})
+	})
+})
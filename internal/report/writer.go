@@ -0,0 +1,34 @@
+package report
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/launcher"
+)
+
+// Writer writes the report to the file when the run finishes. Failures to write are logged and don't
+// affect the run.
+type Writer struct {
+	fileName string
+}
+
+func NewWriter(fileName string) Writer {
+	return Writer{fileName: fileName}
+}
+
+func (w Writer) RunStarted(ctx context.Context, started time.Time) {}
+
+func (w Writer) ProfileStarted(ctx context.Context, profile string) {}
+
+func (w Writer) TargetFinished(ctx context.Context, result launcher.TargetResult) {}
+
+func (w Writer) RunFinished(ctx context.Context, summary launcher.Summary) {
+	if err := New(summary).WriteFile(w.fileName); err != nil {
+		slog.ErrorContext(ctx, "Failed to write the report", "file", w.fileName, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "Report written", "file", w.fileName)
+}
@@ -0,0 +1,106 @@
+package restore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/crypt"
+)
+
+func isBundleFolder(folder string) bool {
+	info, err := os.Stat(filepath.Join(folder, bundle.ManifestName))
+
+	return err == nil && !info.IsDir()
+}
+
+// prepareSource returns the repository to push from. Bundle folders are applied to a temporary
+// repository, which is removed by the cleanup.
+func prepareSource(ctx context.Context, git Git, path string, identities []crypt.Identity) (source string, cleanup func(), err error) {
+	if !isBundleFolder(path) {
+		return path, func() {}, nil
+	}
+
+	manifest, err := bundle.ReadManifest(path)
+	if err != nil {
+		return "", func() {}, fmt.Errorf("failed to read the manifest: %w", err)
+	}
+
+	if len(manifest.Bundles) == 0 {
+		return "", func() {}, errors.New("the manifest has no bundles")
+	}
+
+	tempFolder, err := os.MkdirTemp("", "git-backups-restore-*")
+	if err != nil {
+		return "", func() {}, err
+	}
+	cleanup = func() { _ = os.RemoveAll(tempFolder) }
+
+	source = filepath.Join(tempFolder, "repo.git")
+	if err := git.InitBare(ctx, source); err != nil {
+		return "", cleanup, err
+	}
+
+	for _, b := range manifest.Bundles {
+		if err := applyBundle(ctx, git, source, filepath.Join(path, b.File), tempFolder, b, identities); err != nil {
+			return "", cleanup, fmt.Errorf("failed to apply bundle %v: %w", b.File, err)
+		}
+	}
+
+	return source, cleanup, nil
+}
+
+// applyBundle checks the bundle against the checksum from the manifest and fetches it. Encrypted bundles
+// are decrypted into the temporary folder while they are read.
+func applyBundle(ctx context.Context, git Git, repo, fileName, tempFolder string, b bundle.Bundle, identities []crypt.Identity) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	reader := io.TeeReader(file, hash)
+	if b.Encrypted {
+		decrypted := filepath.Join(tempFolder, "current.bundle")
+		if err := decrypt(reader, decrypted, identities); err != nil {
+			return err
+		}
+		defer os.Remove(decrypted)
+		fileName = decrypted
+	}
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return err
+	}
+
+	if checksum := hex.EncodeToString(hash.Sum(nil)); checksum != b.SHA256 {
+		return fmt.Errorf("checksum mismatch: expected %v, got %v", b.SHA256, checksum)
+	}
+
+	return git.FetchBundle(ctx, repo, fileName)
+}
+
+func decrypt(source io.Reader, fileName string, identities []crypt.Identity) error {
+	decrypted, err := crypt.Decrypt(source, identities)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, decrypted); err != nil {
+		return errors.Join(fmt.Errorf("failed to decrypt: %w", err), file.Close())
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,3 @@
+package restore
+
+//go:generate go tool counterfeiter -generate
@@ -0,0 +1,43 @@
+package restore
+
+import (
+	"context"
+	"strings"
+)
+
+type Destination interface {
+	// Prepare returns the URL to push the repository to, creating the remote repository if needed.
+	Prepare(ctx context.Context, repo Repo) (string, error)
+}
+
+// Template is a URL with the {owner}, {name} and {path} placeholders, e.g. "git@gitlab.com:org/{name}.git".
+type Template string
+
+func (t Template) Prepare(_ context.Context, repo Repo) (string, error) {
+	replacer := strings.NewReplacer("{owner}", repo.Owner, "{name}", repo.Name, "{path}", repo.RelativePath)
+
+	return replacer.Replace(string(t)), nil
+}
+
+// Distinct reports whether every repository gets its own URL.
+func (t Template) Distinct() bool {
+	return strings.Contains(string(t), "{name}") || strings.Contains(string(t), "{path}")
+}
+
+// GitHub creates the repositories in the organization, or for the authenticated user if the owner is
+// empty, and pushes to their SSH URLs.
+type GitHub struct {
+	Creator RepoCreator
+	Token   string
+	Owner   string
+	Private bool
+}
+
+func (g GitHub) Prepare(ctx context.Context, repo Repo) (string, error) {
+	created, err := g.Creator.CreateRepo(ctx, g.Token, g.Owner, repo.Name, g.Private)
+	if err != nil {
+		return "", err
+	}
+
+	return created.SSHURL, nil
+}
@@ -0,0 +1,207 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/AntonKosov/git-backups/internal/clog"
+	"github.com/AntonKosov/git-backups/internal/crypt"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/github"
+)
+
+//counterfeiter:generate . Git
+type Git interface {
+	PushMirror(ctx context.Context, path, url string, privateSSHKey *string) error
+	PushRefs(ctx context.Context, path, url string, refs map[string]string, privateSSHKey *string) error
+	PushLFS(ctx context.Context, path, url string, privateSSHKey *string) error
+	InitBare(ctx context.Context, path string) error
+	FetchBundle(ctx context.Context, path, file string) error
+}
+
+//counterfeiter:generate . RepoCreator
+type RepoCreator interface {
+	CreateRepo(ctx context.Context, token, owner, name string, private bool) (github.Repo, error)
+}
+
+const wikiSuffix = ".wiki"
+
+// Repo is a backed up repository or a folder of its bundles. Its name and owner are taken from the folder
+// and its parent, which matches the layout of GitHub profiles.
+type Repo struct {
+	Path  string
+	Name  string
+	Owner string
+	// RelativePath is the path within the restored root folder without the ".git" suffix.
+	RelativePath string
+	// Wiki is the backup of the wiki of the repository, if there is one next to it.
+	Wiki string
+	// Partial is set if the backup intentionally leaves out refs, history or large files.
+	Partial bool
+}
+
+// Find returns the repository at the source, or every repository in the source if it's a root folder of
+// backups or bundles. Backups named "<name>.wiki" next to "<name>" are treated as the wiki of the repository.
+func Find(source string) ([]Repo, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%v is not a folder", source)
+	}
+
+	var repos []Repo
+	_ = filepath.WalkDir(source, func(folder string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() || (!backup.IsRepo(folder) && !isBundleFolder(folder)) {
+			return nil
+		}
+
+		rootFolder := source
+		if folder == source {
+			rootFolder = filepath.Dir(source)
+		}
+		repos = append(repos, newRepo(rootFolder, folder))
+
+		return fs.SkipDir
+	})
+
+	wikis := map[string]string{}
+	repos = slices.DeleteFunc(repos, func(repo Repo) bool {
+		main, isWiki := strings.CutSuffix(repo.RelativePath, wikiSuffix)
+		if isWiki && slices.ContainsFunc(repos, func(r Repo) bool { return r.RelativePath == main }) {
+			wikis[main] = repo.Path
+			return true
+		}
+
+		return false
+	})
+	for i := range repos {
+		repos[i].Wiki = wikis[repos[i].RelativePath]
+	}
+
+	return repos, nil
+}
+
+func newRepo(rootFolder, path string) Repo {
+	relative, _ := filepath.Rel(rootFolder, path)
+	// Bundles have no metadata, and a backup which metadata can't be read is restored as a complete one.
+	metadata, _ := backup.ReadMetadata(path)
+
+	return Repo{
+		Path:         path,
+		Name:         strings.TrimSuffix(filepath.Base(path), ".git"),
+		Owner:        filepath.Base(filepath.Dir(path)),
+		RelativePath: strings.TrimSuffix(filepath.ToSlash(relative), ".git"),
+		Partial:      metadata.Partial != nil,
+	}
+}
+
+type Options struct {
+	PrivateSSHKey *string
+	// Identities decrypt encrypted bundles.
+	Identities []crypt.Identity
+	LFS        bool
+	Wiki       bool
+	// Snapshot maps ref names to object names. If set, only these refs are pushed instead of all refs.
+	Snapshot map[string]string
+}
+
+type Result struct {
+	Repo Repo
+	URL  string
+	Err  error
+}
+
+// Restore pushes the repositories to the destination one by one. Failures are collected and don't stop
+// the restore of the remaining repositories.
+func Restore(ctx context.Context, git Git, repos []Repo, destination Destination, options Options) (results []Result, restoreErrors error) {
+	for i, repo := range repos {
+		select {
+		case <-ctx.Done():
+			return results, errors.Join(restoreErrors, context.Canceled)
+		default:
+		}
+
+		ctx := clog.Add(ctx, "source", repo.Path)
+		slog.InfoContext(ctx, "Restoring repository...", "progress", fmt.Sprintf("%v/%v", i+1, len(repos)))
+
+		result := Result{Repo: repo}
+		result.URL, result.Err = restore(ctx, git, repo, destination, options)
+		if result.Err != nil {
+			slog.ErrorContext(ctx, "Failed to restore", "error", result.Err)
+			result.Err = fmt.Errorf("failed to restore repository %v: %w", repo.Path, result.Err)
+			restoreErrors = errors.Join(restoreErrors, result.Err)
+		}
+		results = append(results, result)
+	}
+
+	return results, restoreErrors
+}
+
+func restore(ctx context.Context, git Git, repo Repo, destination Destination, options Options) (string, error) {
+	source, cleanup, err := prepareSource(ctx, git, repo.Path, options.Identities)
+	defer cleanup()
+	if err != nil {
+		return "", err
+	}
+
+	url, err := destination.Prepare(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+
+	if repo.Partial {
+		// Git fetches the missing objects from the original remote while pushing, if it's still available.
+		slog.WarnContext(ctx, "The backup is partial, the restored repository misses what the backup left out")
+	}
+
+	if options.Snapshot != nil {
+		err = git.PushRefs(ctx, source, url, options.Snapshot, options.PrivateSSHKey)
+	} else {
+		err = git.PushMirror(ctx, source, url, options.PrivateSSHKey)
+	}
+	if err != nil {
+		return url, err
+	}
+
+	if options.LFS {
+		if _, err := os.Stat(filepath.Join(source, "lfs", "objects")); err == nil {
+			if err := git.PushLFS(ctx, source, url, options.PrivateSSHKey); err != nil {
+				return url, fmt.Errorf("failed to push LFS objects: %w", err)
+			}
+		} else {
+			slog.DebugContext(ctx, "No LFS objects to push")
+		}
+	}
+
+	if options.Wiki && repo.Wiki != "" {
+		wiki, cleanup, err := prepareSource(ctx, git, repo.Wiki, options.Identities)
+		defer cleanup()
+		if err == nil {
+			err = git.PushMirror(ctx, wiki, wikiURL(url), options.PrivateSSHKey)
+		}
+		if err != nil {
+			return url, fmt.Errorf("failed to push the wiki: %w", err)
+		}
+	}
+
+	return url, nil
+}
+
+// wikiURL follows the GitHub and GitLab convention of keeping the wiki of "<name>.git" in
+// "<name>.wiki.git".
+func wikiURL(url string) string {
+	if trimmed, found := strings.CutSuffix(url, ".git"); found {
+		return trimmed + wikiSuffix + ".git"
+	}
+
+	return url + wikiSuffix
+}
@@ -0,0 +1,300 @@
+package restore_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/AntonKosov/git-backups/internal/bundle"
+	"github.com/AntonKosov/git-backups/internal/crypt"
+	"github.com/AntonKosov/git-backups/internal/git/backup"
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/restore"
+	"github.com/AntonKosov/git-backups/internal/restore/restorefakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Restore tests", func() {
+	var rootFolder string
+
+	createRepo := func(relativePath string) string {
+		path := filepath.Join(rootFolder, relativePath)
+		Expect(os.MkdirAll(filepath.Join(path, "objects"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(path, "HEAD"), []byte("ref: refs/heads/main\n"), 0o644)).To(Succeed())
+
+		return path
+	}
+
+	BeforeEach(func() {
+		rootFolder = GinkgoT().TempDir()
+	})
+
+	Context("Find", func() {
+		It("finds repositories in the root folder and attaches wikis", func() {
+			repo := createRepo("owner/repo")
+			wiki := createRepo("owner/repo.wiki.git")
+			orphanedWiki := createRepo("other.wiki")
+
+			repos, err := restore.Find(rootFolder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(ConsistOf(
+				restore.Repo{Path: repo, Name: "repo", Owner: "owner", RelativePath: "owner/repo", Wiki: wiki},
+				restore.Repo{Path: orphanedWiki, Name: "other.wiki", Owner: filepath.Base(rootFolder), RelativePath: "other.wiki"},
+			))
+		})
+
+		It("returns a single repository", func() {
+			repo := createRepo("owner/repo.git")
+
+			repos, err := restore.Find(repo)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(Equal([]restore.Repo{{Path: repo, Name: "repo", Owner: "owner", RelativePath: "repo"}}))
+		})
+
+		It("marks partial backups", func() {
+			repo := createRepo("owner/repo")
+			Expect(backup.Metadata{Partial: &backup.Partial{BlobLimit: 1024}}.WriteFile(repo)).To(Succeed())
+
+			repos, err := restore.Find(repo)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(Equal([]restore.Repo{{Path: repo, Name: "repo", Owner: "owner", RelativePath: "repo", Partial: true}}))
+		})
+
+		It("finds bundle folders", func() {
+			folder := filepath.Join(rootFolder, "github", "owner", "repo")
+			Expect(os.MkdirAll(folder, 0o755)).To(Succeed())
+			Expect(bundle.Manifest{}.WriteFile(folder)).To(Succeed())
+
+			repos, err := restore.Find(rootFolder)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(Equal([]restore.Repo{{Path: folder, Name: "repo", Owner: "owner", RelativePath: "github/owner/repo"}}))
+		})
+
+		It("fails if the source doesn't exist", func() {
+			_, err := restore.Find(filepath.Join(rootFolder, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("Restore", func() {
+		var (
+			fakeGit     *restorefakes.FakeGit
+			repos       []restore.Repo
+			destination restore.Destination
+			options     restore.Options
+			results     []restore.Result
+			err         error
+		)
+
+		BeforeEach(func() {
+			fakeGit = &restorefakes.FakeGit{}
+			repos = []restore.Repo{
+				{Path: createRepo("owner/first"), Name: "first", Owner: "owner", RelativePath: "owner/first"},
+				{Path: createRepo("owner/second"), Name: "second", Owner: "owner", RelativePath: "owner/second"},
+			}
+			destination = restore.Template("git@example.com:new-{owner}/{name}.git")
+			options = restore.Options{}
+		})
+
+		JustBeforeEach(func() {
+			results, err = restore.Restore(ctx, fakeGit, repos, destination, options)
+		})
+
+		It("pushes all refs of every repository", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeGit.PushMirrorCallCount()).To(Equal(2))
+			_, path, url, _ := fakeGit.PushMirrorArgsForCall(1)
+			Expect(path).To(Equal(repos[1].Path))
+			Expect(url).To(Equal("git@example.com:new-owner/second.git"))
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].URL).To(Equal("git@example.com:new-owner/first.git"))
+		})
+
+		When("a push fails", func() {
+			BeforeEach(func() {
+				fakeGit.PushMirrorReturnsOnCall(0, errors.New("remote rejected"))
+			})
+
+			It("restores the rest of the repositories", func() {
+				Expect(err).To(MatchError(ContainSubstring("remote rejected")))
+				Expect(fakeGit.PushMirrorCallCount()).To(Equal(2))
+				Expect(results[0].Err).To(HaveOccurred())
+				Expect(results[1].Err).NotTo(HaveOccurred())
+			})
+		})
+
+		When("a snapshot is given", func() {
+			BeforeEach(func() {
+				options.Snapshot = map[string]string{"refs/heads/main": strings.Repeat("a", 40)}
+			})
+
+			It("pushes the refs from the snapshot", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeGit.PushMirrorCallCount()).To(BeZero())
+				Expect(fakeGit.PushRefsCallCount()).To(Equal(2))
+				_, _, _, refs, _ := fakeGit.PushRefsArgsForCall(0)
+				Expect(refs).To(Equal(options.Snapshot))
+			})
+		})
+
+		When("LFS objects and wikis are restored", func() {
+			BeforeEach(func() {
+				options.LFS, options.Wiki = true, true
+				Expect(os.MkdirAll(filepath.Join(repos[0].Path, "lfs", "objects"), 0o755)).To(Succeed())
+				repos[1].Wiki = createRepo("owner/second.wiki")
+			})
+
+			It("pushes LFS objects and wikis of the repositories which have them", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeGit.PushLFSCallCount()).To(Equal(1))
+				_, path, _, _ := fakeGit.PushLFSArgsForCall(0)
+				Expect(path).To(Equal(repos[0].Path))
+				Expect(fakeGit.PushMirrorCallCount()).To(Equal(3))
+				_, path, url, _ := fakeGit.PushMirrorArgsForCall(2)
+				Expect(path).To(Equal(repos[1].Wiki))
+				Expect(url).To(Equal("git@example.com:new-owner/second.wiki.git"))
+			})
+		})
+
+		When("the source is a folder of encrypted bundles", func() {
+			var (
+				identity *age.X25519Identity
+				fetched  []string
+			)
+
+			writeBundle := func(folder, fileName, content string) bundle.Bundle {
+				file, err := os.Create(filepath.Join(folder, fileName))
+				Expect(err).NotTo(HaveOccurred())
+				defer file.Close()
+
+				hash := sha256.New()
+				encryptor, err := crypt.Encrypt(io.MultiWriter(file, hash), []crypt.Recipient{identity.Recipient()})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = io.WriteString(encryptor, content)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(encryptor.Close()).To(Succeed())
+
+				return bundle.Bundle{File: fileName, Encrypted: true, SHA256: hex.EncodeToString(hash.Sum(nil))}
+			}
+
+			BeforeEach(func() {
+				identity, err = age.GenerateX25519Identity()
+				Expect(err).NotTo(HaveOccurred())
+				options.Identities = []crypt.Identity{identity}
+
+				folder := filepath.Join(rootFolder, "bundles", "owner", "first")
+				Expect(os.MkdirAll(folder, 0o755)).To(Succeed())
+				manifest := bundle.Manifest{Bundles: []bundle.Bundle{
+					writeBundle(folder, "000001.bundle.age", "full"),
+					writeBundle(folder, "000002.bundle.age", "incremental"),
+				}}
+				Expect(manifest.WriteFile(folder)).To(Succeed())
+				repos = []restore.Repo{{Path: folder, Name: "first", Owner: "owner", RelativePath: "owner/first"}}
+
+				fetched = nil
+				fakeGit.FetchBundleStub = func(_ context.Context, _, file string) error {
+					content, err := os.ReadFile(file)
+					fetched = append(fetched, string(content))
+					return err
+				}
+			})
+
+			It("decrypts the bundles into a temporary repository and pushes it", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fetched).To(Equal([]string{"full", "incremental"}))
+				Expect(fakeGit.InitBareCallCount()).To(Equal(1))
+				_, repo := fakeGit.InitBareArgsForCall(0)
+				_, path, url, _ := fakeGit.PushMirrorArgsForCall(0)
+				Expect(path).To(Equal(repo))
+				Expect(url).To(Equal("git@example.com:new-owner/first.git"))
+				Expect(repo).NotTo(BeAnExistingFile())
+			})
+
+			When("the identity doesn't match", func() {
+				BeforeEach(func() {
+					other, err := age.GenerateX25519Identity()
+					Expect(err).NotTo(HaveOccurred())
+					options.Identities = []crypt.Identity{other}
+				})
+
+				It("doesn't push", func() {
+					Expect(err).To(MatchError(ContainSubstring("failed to decrypt")))
+					Expect(fakeGit.PushMirrorCallCount()).To(BeZero())
+				})
+			})
+
+			When("a bundle is corrupted", func() {
+				BeforeEach(func() {
+					manifest, err := bundle.ReadManifest(repos[0].Path)
+					Expect(err).NotTo(HaveOccurred())
+					manifest.Bundles[1].SHA256 = strings.Repeat("0", 64)
+					Expect(manifest.WriteFile(repos[0].Path)).To(Succeed())
+				})
+
+				It("doesn't push", func() {
+					Expect(err).To(MatchError(ContainSubstring("checksum mismatch")))
+					Expect(fetched).To(Equal([]string{"full"}))
+					Expect(fakeGit.PushMirrorCallCount()).To(BeZero())
+				})
+			})
+		})
+
+		When("the destination is GitHub", func() {
+			var fakeCreator *restorefakes.FakeRepoCreator
+
+			BeforeEach(func() {
+				fakeCreator = &restorefakes.FakeRepoCreator{}
+				fakeCreator.CreateRepoReturns(github.Repo{SSHURL: "git@github.com:org/created.git"}, nil)
+				destination = restore.GitHub{Creator: fakeCreator, Token: "token", Owner: "org", Private: true}
+			})
+
+			It("creates the repositories before pushing", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeCreator.CreateRepoCallCount()).To(Equal(2))
+				_, token, owner, name, private := fakeCreator.CreateRepoArgsForCall(0)
+				Expect([]any{token, owner, name, private}).To(Equal([]any{"token", "org", "first", true}))
+				_, _, url, _ := fakeGit.PushMirrorArgsForCall(0)
+				Expect(url).To(Equal("git@github.com:org/created.git"))
+			})
+
+			When("the repository can't be created", func() {
+				BeforeEach(func() {
+					fakeCreator.CreateRepoReturns(github.Repo{}, errors.New("forbidden"))
+				})
+
+				It("doesn't push", func() {
+					Expect(err).To(MatchError(ContainSubstring("forbidden")))
+					Expect(fakeGit.PushMirrorCallCount()).To(BeZero())
+				})
+			})
+		})
+	})
+
+	Context("ReadSnapshot", func() {
+		It("reads the output of show-ref and for-each-ref", func() {
+			snapshot := strings.Repeat("a", 40) + " HEAD\n" +
+				strings.Repeat("a", 40) + " refs/heads/main\n" +
+				"\n# tags\n" +
+				strings.Repeat("b", 40) + " tag\trefs/tags/v1\n"
+
+			refs, err := restore.ReadSnapshot(strings.NewReader(snapshot))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(refs).To(Equal(map[string]string{
+				"refs/heads/main": strings.Repeat("a", 40),
+				"refs/tags/v1":    strings.Repeat("b", 40),
+			}))
+		})
+
+		It("reports invalid lines", func() {
+			_, err := restore.ReadSnapshot(strings.NewReader(strings.Repeat("a", 40) + " refs/heads/main\nmain\n"))
+			Expect(err).To(MatchError(`invalid ref at line 2: "main"`))
+		})
+	})
+})
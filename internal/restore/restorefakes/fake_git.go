@@ -0,0 +1,434 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package restorefakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AntonKosov/git-backups/internal/restore"
+)
+
+type FakeGit struct {
+	FetchBundleStub        func(context.Context, string, string) error
+	fetchBundleMutex       sync.RWMutex
+	fetchBundleArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	fetchBundleReturns struct {
+		result1 error
+	}
+	fetchBundleReturnsOnCall map[int]struct {
+		result1 error
+	}
+	InitBareStub        func(context.Context, string) error
+	initBareMutex       sync.RWMutex
+	initBareArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+	}
+	initBareReturns struct {
+		result1 error
+	}
+	initBareReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PushLFSStub        func(context.Context, string, string, *string) error
+	pushLFSMutex       sync.RWMutex
+	pushLFSArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 *string
+	}
+	pushLFSReturns struct {
+		result1 error
+	}
+	pushLFSReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PushMirrorStub        func(context.Context, string, string, *string) error
+	pushMirrorMutex       sync.RWMutex
+	pushMirrorArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 *string
+	}
+	pushMirrorReturns struct {
+		result1 error
+	}
+	pushMirrorReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PushRefsStub        func(context.Context, string, string, map[string]string, *string) error
+	pushRefsMutex       sync.RWMutex
+	pushRefsArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 map[string]string
+		arg5 *string
+	}
+	pushRefsReturns struct {
+		result1 error
+	}
+	pushRefsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeGit) FetchBundle(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.fetchBundleMutex.Lock()
+	ret, specificReturn := fake.fetchBundleReturnsOnCall[len(fake.fetchBundleArgsForCall)]
+	fake.fetchBundleArgsForCall = append(fake.fetchBundleArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.FetchBundleStub
+	fakeReturns := fake.fetchBundleReturns
+	fake.recordInvocation("FetchBundle", []interface{}{arg1, arg2, arg3})
+	fake.fetchBundleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) FetchBundleCallCount() int {
+	fake.fetchBundleMutex.RLock()
+	defer fake.fetchBundleMutex.RUnlock()
+	return len(fake.fetchBundleArgsForCall)
+}
+
+func (fake *FakeGit) FetchBundleCalls(stub func(context.Context, string, string) error) {
+	fake.fetchBundleMutex.Lock()
+	defer fake.fetchBundleMutex.Unlock()
+	fake.FetchBundleStub = stub
+}
+
+func (fake *FakeGit) FetchBundleArgsForCall(i int) (context.Context, string, string) {
+	fake.fetchBundleMutex.RLock()
+	defer fake.fetchBundleMutex.RUnlock()
+	argsForCall := fake.fetchBundleArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeGit) FetchBundleReturns(result1 error) {
+	fake.fetchBundleMutex.Lock()
+	defer fake.fetchBundleMutex.Unlock()
+	fake.FetchBundleStub = nil
+	fake.fetchBundleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) FetchBundleReturnsOnCall(i int, result1 error) {
+	fake.fetchBundleMutex.Lock()
+	defer fake.fetchBundleMutex.Unlock()
+	fake.FetchBundleStub = nil
+	if fake.fetchBundleReturnsOnCall == nil {
+		fake.fetchBundleReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.fetchBundleReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) InitBare(arg1 context.Context, arg2 string) error {
+	fake.initBareMutex.Lock()
+	ret, specificReturn := fake.initBareReturnsOnCall[len(fake.initBareArgsForCall)]
+	fake.initBareArgsForCall = append(fake.initBareArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.InitBareStub
+	fakeReturns := fake.initBareReturns
+	fake.recordInvocation("InitBare", []interface{}{arg1, arg2})
+	fake.initBareMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) InitBareCallCount() int {
+	fake.initBareMutex.RLock()
+	defer fake.initBareMutex.RUnlock()
+	return len(fake.initBareArgsForCall)
+}
+
+func (fake *FakeGit) InitBareCalls(stub func(context.Context, string) error) {
+	fake.initBareMutex.Lock()
+	defer fake.initBareMutex.Unlock()
+	fake.InitBareStub = stub
+}
+
+func (fake *FakeGit) InitBareArgsForCall(i int) (context.Context, string) {
+	fake.initBareMutex.RLock()
+	defer fake.initBareMutex.RUnlock()
+	argsForCall := fake.initBareArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeGit) InitBareReturns(result1 error) {
+	fake.initBareMutex.Lock()
+	defer fake.initBareMutex.Unlock()
+	fake.InitBareStub = nil
+	fake.initBareReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) InitBareReturnsOnCall(i int, result1 error) {
+	fake.initBareMutex.Lock()
+	defer fake.initBareMutex.Unlock()
+	fake.InitBareStub = nil
+	if fake.initBareReturnsOnCall == nil {
+		fake.initBareReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.initBareReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) PushLFS(arg1 context.Context, arg2 string, arg3 string, arg4 *string) error {
+	fake.pushLFSMutex.Lock()
+	ret, specificReturn := fake.pushLFSReturnsOnCall[len(fake.pushLFSArgsForCall)]
+	fake.pushLFSArgsForCall = append(fake.pushLFSArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 *string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.PushLFSStub
+	fakeReturns := fake.pushLFSReturns
+	fake.recordInvocation("PushLFS", []interface{}{arg1, arg2, arg3, arg4})
+	fake.pushLFSMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) PushLFSCallCount() int {
+	fake.pushLFSMutex.RLock()
+	defer fake.pushLFSMutex.RUnlock()
+	return len(fake.pushLFSArgsForCall)
+}
+
+func (fake *FakeGit) PushLFSCalls(stub func(context.Context, string, string, *string) error) {
+	fake.pushLFSMutex.Lock()
+	defer fake.pushLFSMutex.Unlock()
+	fake.PushLFSStub = stub
+}
+
+func (fake *FakeGit) PushLFSArgsForCall(i int) (context.Context, string, string, *string) {
+	fake.pushLFSMutex.RLock()
+	defer fake.pushLFSMutex.RUnlock()
+	argsForCall := fake.pushLFSArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGit) PushLFSReturns(result1 error) {
+	fake.pushLFSMutex.Lock()
+	defer fake.pushLFSMutex.Unlock()
+	fake.PushLFSStub = nil
+	fake.pushLFSReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) PushLFSReturnsOnCall(i int, result1 error) {
+	fake.pushLFSMutex.Lock()
+	defer fake.pushLFSMutex.Unlock()
+	fake.PushLFSStub = nil
+	if fake.pushLFSReturnsOnCall == nil {
+		fake.pushLFSReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pushLFSReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) PushMirror(arg1 context.Context, arg2 string, arg3 string, arg4 *string) error {
+	fake.pushMirrorMutex.Lock()
+	ret, specificReturn := fake.pushMirrorReturnsOnCall[len(fake.pushMirrorArgsForCall)]
+	fake.pushMirrorArgsForCall = append(fake.pushMirrorArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 *string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.PushMirrorStub
+	fakeReturns := fake.pushMirrorReturns
+	fake.recordInvocation("PushMirror", []interface{}{arg1, arg2, arg3, arg4})
+	fake.pushMirrorMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) PushMirrorCallCount() int {
+	fake.pushMirrorMutex.RLock()
+	defer fake.pushMirrorMutex.RUnlock()
+	return len(fake.pushMirrorArgsForCall)
+}
+
+func (fake *FakeGit) PushMirrorCalls(stub func(context.Context, string, string, *string) error) {
+	fake.pushMirrorMutex.Lock()
+	defer fake.pushMirrorMutex.Unlock()
+	fake.PushMirrorStub = stub
+}
+
+func (fake *FakeGit) PushMirrorArgsForCall(i int) (context.Context, string, string, *string) {
+	fake.pushMirrorMutex.RLock()
+	defer fake.pushMirrorMutex.RUnlock()
+	argsForCall := fake.pushMirrorArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeGit) PushMirrorReturns(result1 error) {
+	fake.pushMirrorMutex.Lock()
+	defer fake.pushMirrorMutex.Unlock()
+	fake.PushMirrorStub = nil
+	fake.pushMirrorReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) PushMirrorReturnsOnCall(i int, result1 error) {
+	fake.pushMirrorMutex.Lock()
+	defer fake.pushMirrorMutex.Unlock()
+	fake.PushMirrorStub = nil
+	if fake.pushMirrorReturnsOnCall == nil {
+		fake.pushMirrorReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pushMirrorReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) PushRefs(arg1 context.Context, arg2 string, arg3 string, arg4 map[string]string, arg5 *string) error {
+	fake.pushRefsMutex.Lock()
+	ret, specificReturn := fake.pushRefsReturnsOnCall[len(fake.pushRefsArgsForCall)]
+	fake.pushRefsArgsForCall = append(fake.pushRefsArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 map[string]string
+		arg5 *string
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.PushRefsStub
+	fakeReturns := fake.pushRefsReturns
+	fake.recordInvocation("PushRefs", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.pushRefsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeGit) PushRefsCallCount() int {
+	fake.pushRefsMutex.RLock()
+	defer fake.pushRefsMutex.RUnlock()
+	return len(fake.pushRefsArgsForCall)
+}
+
+func (fake *FakeGit) PushRefsCalls(stub func(context.Context, string, string, map[string]string, *string) error) {
+	fake.pushRefsMutex.Lock()
+	defer fake.pushRefsMutex.Unlock()
+	fake.PushRefsStub = stub
+}
+
+func (fake *FakeGit) PushRefsArgsForCall(i int) (context.Context, string, string, map[string]string, *string) {
+	fake.pushRefsMutex.RLock()
+	defer fake.pushRefsMutex.RUnlock()
+	argsForCall := fake.pushRefsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeGit) PushRefsReturns(result1 error) {
+	fake.pushRefsMutex.Lock()
+	defer fake.pushRefsMutex.Unlock()
+	fake.PushRefsStub = nil
+	fake.pushRefsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) PushRefsReturnsOnCall(i int, result1 error) {
+	fake.pushRefsMutex.Lock()
+	defer fake.pushRefsMutex.Unlock()
+	fake.PushRefsStub = nil
+	if fake.pushRefsReturnsOnCall == nil {
+		fake.pushRefsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.pushRefsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeGit) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.fetchBundleMutex.RLock()
+	defer fake.fetchBundleMutex.RUnlock()
+	fake.initBareMutex.RLock()
+	defer fake.initBareMutex.RUnlock()
+	fake.pushLFSMutex.RLock()
+	defer fake.pushLFSMutex.RUnlock()
+	fake.pushMirrorMutex.RLock()
+	defer fake.pushMirrorMutex.RUnlock()
+	fake.pushRefsMutex.RLock()
+	defer fake.pushRefsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeGit) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ restore.Git = new(FakeGit)
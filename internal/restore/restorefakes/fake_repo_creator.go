@@ -0,0 +1,126 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package restorefakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/AntonKosov/git-backups/internal/github"
+	"github.com/AntonKosov/git-backups/internal/restore"
+)
+
+type FakeRepoCreator struct {
+	CreateRepoStub        func(context.Context, string, string, string, bool) (github.Repo, error)
+	createRepoMutex       sync.RWMutex
+	createRepoArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}
+	createRepoReturns struct {
+		result1 github.Repo
+		result2 error
+	}
+	createRepoReturnsOnCall map[int]struct {
+		result1 github.Repo
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRepoCreator) CreateRepo(arg1 context.Context, arg2 string, arg3 string, arg4 string, arg5 bool) (github.Repo, error) {
+	fake.createRepoMutex.Lock()
+	ret, specificReturn := fake.createRepoReturnsOnCall[len(fake.createRepoArgsForCall)]
+	fake.createRepoArgsForCall = append(fake.createRepoArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+		arg5 bool
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.CreateRepoStub
+	fakeReturns := fake.createRepoReturns
+	fake.recordInvocation("CreateRepo", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.createRepoMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepoCreator) CreateRepoCallCount() int {
+	fake.createRepoMutex.RLock()
+	defer fake.createRepoMutex.RUnlock()
+	return len(fake.createRepoArgsForCall)
+}
+
+func (fake *FakeRepoCreator) CreateRepoCalls(stub func(context.Context, string, string, string, bool) (github.Repo, error)) {
+	fake.createRepoMutex.Lock()
+	defer fake.createRepoMutex.Unlock()
+	fake.CreateRepoStub = stub
+}
+
+func (fake *FakeRepoCreator) CreateRepoArgsForCall(i int) (context.Context, string, string, string, bool) {
+	fake.createRepoMutex.RLock()
+	defer fake.createRepoMutex.RUnlock()
+	argsForCall := fake.createRepoArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeRepoCreator) CreateRepoReturns(result1 github.Repo, result2 error) {
+	fake.createRepoMutex.Lock()
+	defer fake.createRepoMutex.Unlock()
+	fake.CreateRepoStub = nil
+	fake.createRepoReturns = struct {
+		result1 github.Repo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepoCreator) CreateRepoReturnsOnCall(i int, result1 github.Repo, result2 error) {
+	fake.createRepoMutex.Lock()
+	defer fake.createRepoMutex.Unlock()
+	fake.CreateRepoStub = nil
+	if fake.createRepoReturnsOnCall == nil {
+		fake.createRepoReturnsOnCall = make(map[int]struct {
+			result1 github.Repo
+			result2 error
+		})
+	}
+	fake.createRepoReturnsOnCall[i] = struct {
+		result1 github.Repo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepoCreator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createRepoMutex.RLock()
+	defer fake.createRepoMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRepoCreator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ restore.RepoCreator = new(FakeRepoCreator)
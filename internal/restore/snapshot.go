@@ -0,0 +1,45 @@
+package restore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var objectNamePattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+// ReadSnapshot reads refs in the format of "git show-ref" or "git for-each-ref" and returns the object
+// name of every ref. HEAD, empty lines and comments are skipped.
+func ReadSnapshot(reader io.Reader) (map[string]string, error) {
+	refs := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		objectName, ref := fields[0], fields[len(fields)-1]
+		if ref == "HEAD" {
+			continue
+		}
+
+		if len(fields) < 2 || !objectNamePattern.MatchString(objectName) || !strings.HasPrefix(ref, "refs/") {
+			return nil, fmt.Errorf("invalid ref at line %v: %q", line, scanner.Text())
+		}
+
+		refs[ref] = objectName
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("the snapshot has no refs")
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,238 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/config"
+)
+
+type Object struct {
+	Key  string
+	Size int64
+}
+
+// Client is a minimal client of the S3 API which is enough to upload backups to AWS S3 or compatible
+// storage like MinIO. Every request is signed with the SHA-256 of its payload, so corrupted uploads are
+// rejected by the storage.
+type Client struct {
+	endpoint   *url.URL
+	bucket     string
+	pathStyle  bool
+	partSize   int64
+	signer     Signer
+	httpClient *http.Client
+}
+
+func NewClient(conf config.S3) (*Client, error) {
+	endpoint, err := url.Parse(conf.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	return &Client{
+		endpoint:   endpoint,
+		bucket:     conf.Bucket,
+		pathStyle:  conf.PathStyle,
+		partSize:   conf.PartSize,
+		signer:     Signer{AccessKeyID: conf.AccessKeyID, SecretAccessKey: conf.SecretAccessKey, Region: conf.Region},
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// List returns all objects whose keys start with the prefix.
+func (c *Client) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		body, _, err := c.send(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %v: %w", prefix, err)
+		}
+
+		var result struct {
+			Contents []struct {
+				Key  string `xml:"Key"`
+				Size int64  `xml:"Size"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return nil, fmt.Errorf("failed to list %v: %w", prefix, err)
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, Size: content.Size})
+		}
+
+		if !result.IsTruncated {
+			return objects, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// Upload uploads the file in one request if it fits into a part, and as a multipart upload otherwise.
+func (c *Client) Upload(ctx context.Context, key, fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() > c.partSize {
+		err = c.uploadParts(ctx, key, file)
+	} else {
+		var data []byte
+		if data, err = io.ReadAll(file); err == nil {
+			_, _, err = c.send(ctx, http.MethodPut, key, nil, data)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upload %v: %w", key, err)
+	}
+
+	return nil
+}
+
+func (c *Client) Delete(ctx context.Context, key string) error {
+	if _, _, err := c.send(ctx, http.MethodDelete, key, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete %v: %w", key, err)
+	}
+
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+// uploadParts reads the file part by part, so that only one part is kept in memory. The upload is
+// aborted on failure, so that the storage doesn't keep the uploaded parts.
+func (c *Client) uploadParts(ctx context.Context, key string, file io.Reader) (err error) {
+	body, _, err := c.send(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return err
+	}
+
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(body, &initiated); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			query := url.Values{"uploadId": {initiated.UploadID}}
+			_, _, abortErr := c.send(context.WithoutCancel(ctx), http.MethodDelete, key, query, nil)
+			err = errors.Join(err, abortErr)
+		}
+	}()
+
+	var parts []completedPart
+	buffer := make([]byte, c.partSize)
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(file, buffer)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {initiated.UploadID}}
+		_, header, err := c.send(ctx, http.MethodPut, key, query, buffer[:n])
+		if err != nil {
+			return fmt.Errorf("failed to upload part %v: %w", number, err)
+		}
+		parts = append(parts, completedPart{PartNumber: number, ETag: header.Get("ETag")})
+
+		if n < len(buffer) {
+			break
+		}
+	}
+
+	completion, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	_, _, err = c.send(ctx, http.MethodPost, key, url.Values{"uploadId": {initiated.UploadID}}, completion)
+
+	return err
+}
+
+type responseError struct {
+	status  string
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e responseError) Error() string {
+	return fmt.Sprintf("unexpected response: %v (%v: %v)", e.status, e.Code, e.Message)
+}
+
+func (c *Client) send(ctx context.Context, method, key string, query url.Values, body []byte) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url(key, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	c.signer.Sign(req, hashHex(body), time.Now())
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Completing a multipart upload may fail after the status is sent, so the error is in the body.
+	if res.StatusCode >= 300 || bytes.Contains(data, []byte("<Error>")) {
+		respErr := responseError{status: res.Status}
+		_ = xml.Unmarshal(data, &respErr)
+
+		return nil, nil, respErr
+	}
+
+	return data, res.Header, nil
+}
+
+func (c *Client) url(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if c.pathStyle {
+		u.Path += "/" + c.bucket
+	} else {
+		u.Host = c.bucket + "." + u.Host
+	}
+	u.Path += "/" + key
+	u.RawPath = escape(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	return &u
+}
@@ -0,0 +1,209 @@
+package s3_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/s3"
+)
+
+// fakeStorage is an in-process stand-in for MinIO with path-style addressing. It checks the signature and
+// the payload hash of every request like the real storage does.
+type fakeStorage struct {
+	mu       sync.Mutex
+	signer   s3.Signer
+	bucket   string
+	maxKeys  int
+	failPart int
+	objects  map[string][]byte
+	uploads  map[string]map[int][]byte
+	requests []string
+}
+
+func newFakeStorage(signer s3.Signer, bucket string) *fakeStorage {
+	return &fakeStorage{
+		signer:  signer,
+		bucket:  bucket,
+		maxKeys: 1000,
+		objects: map[string][]byte{},
+		uploads: map[string]map[int][]byte{},
+	}
+}
+
+func (f *fakeStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	if code, message := f.authenticate(r, body); code != "" {
+		writeError(w, http.StatusForbidden, code, message)
+		return
+	}
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		writeError(w, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	query := r.URL.Query()
+	f.requests = append(f.requests, fmt.Sprintf("%v %v", r.Method, strings.Join(slices.Sorted(func(yield func(string) bool) {
+		for name := range query {
+			if !yield(name) {
+				return
+			}
+		}
+	}), ",")))
+
+	switch {
+	case r.Method == http.MethodGet && query.Get("list-type") == "2":
+		f.list(w, query.Get("prefix"), query.Get("continuation-token"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID := fmt.Sprintf("upload-%v", len(f.requests))
+		f.uploads[uploadID] = map[int][]byte{}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			UploadID string   `xml:"UploadId"`
+		}{UploadID: uploadID})
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		if number == f.failPart {
+			writeError(w, http.StatusInternalServerError, "InternalError", "We encountered an internal error")
+			return
+		}
+		f.uploads[query.Get("uploadId")][number] = body
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		f.complete(w, key, query.Get("uploadId"), body)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+		w.Header().Set("ETag", etag(body))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented", r.Method)
+	}
+}
+
+func (f *fakeStorage) authenticate(r *http.Request, body []byte) (code, message string) {
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if hash := sha256.Sum256(body); payloadHash != hex.EncodeToString(hash[:]) {
+		return "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed"
+	}
+
+	date, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return "AccessDenied", "invalid date"
+	}
+
+	expected := r.Clone(r.Context())
+	expected.Header.Del("Authorization")
+	f.signer.Sign(expected, payloadHash, date)
+	if expected.Header.Get("Authorization") != r.Header.Get("Authorization") {
+		return "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided"
+	}
+
+	return "", ""
+}
+
+func (f *fakeStorage) list(w http.ResponseWriter, prefix, continuationToken string) {
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	start, _ := strconv.Atoi(continuationToken)
+	end := min(start+f.maxKeys, len(keys))
+
+	type content struct {
+		Key  string `xml:"Key"`
+		Size int    `xml:"Size"`
+	}
+	result := struct {
+		XMLName               xml.Name  `xml:"ListBucketResult"`
+		Contents              []content `xml:"Contents"`
+		IsTruncated           bool      `xml:"IsTruncated"`
+		NextContinuationToken string    `xml:"NextContinuationToken,omitempty"`
+	}{IsTruncated: end < len(keys)}
+	for _, key := range keys[start:end] {
+		result.Contents = append(result.Contents, content{Key: key, Size: len(f.objects[key])})
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+
+	writeXML(w, result)
+}
+
+func (f *fakeStorage) complete(w http.ResponseWriter, key, uploadID string, body []byte) {
+	var completion struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &completion); err != nil {
+		writeError(w, http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+
+	var object []byte
+	for _, part := range completion.Parts {
+		data, ok := f.uploads[uploadID][part.PartNumber]
+		if !ok || etag(data) != part.ETag {
+			// The real storage reports this error after the status is sent.
+			writeXML(w, struct {
+				XMLName xml.Name `xml:"Error"`
+				Code    string   `xml:"Code"`
+			}{Code: "InvalidPart"})
+			return
+		}
+		object = append(object, data...)
+	}
+
+	f.objects[key] = object
+	delete(f.uploads, uploadID)
+	writeXML(w, struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Key     string   `xml:"Key"`
+	}{Key: key})
+}
+
+func etag(data []byte) string {
+	hash := sha256.Sum256(data)
+
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+func writeXML(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/xml")
+	data, _ := xml.Marshal(value)
+	_, _ = w.Write(data)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	data, _ := xml.Marshal(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,130 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat    = "20060102T150405Z"
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+)
+
+// Signer signs requests with AWS Signature Version 4.
+type Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Region          string
+}
+
+// Sign adds the date, the payload hash and the authorization headers to the request. The payload hash is
+// the hex-encoded SHA-256 of the body, which the storage checks before it accepts the request.
+func (s Signer) Sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := signedHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders(req, headers),
+		strings.Join(headers, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%v/%v/s3/aws4_request", now.Format("20060102"), s.Region)
+	stringToSign := strings.Join([]string{signingAlgorithm, now.Format(amzDateFormat), scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), now.Format("20060102"))
+	for _, part := range []string{s.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%v Credential=%v/%v,SignedHeaders=%v,Signature=%v",
+		signingAlgorithm, s.AccessKeyID, scope, strings.Join(headers, ";"), hex.EncodeToString(hmacSHA256(key, stringToSign)),
+	))
+}
+
+// signedHeaders are the host and the headers which affect how the storage handles the request. Headers
+// added by the HTTP client after signing, like User-Agent, are left out.
+func signedHeaders(req *http.Request) []string {
+	headers := []string{"host"}
+	for name := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "content-type" || name == "content-md5" || name == "range" {
+			headers = append(headers, name)
+		}
+	}
+	slices.Sort(headers)
+
+	return headers
+}
+
+func canonicalHeaders(req *http.Request, headers []string) string {
+	var sb strings.Builder
+	for _, name := range headers {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		}
+		fmt.Fprintf(&sb, "%v:%v\n", name, strings.TrimSpace(value))
+	}
+
+	return sb.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, escape(name, true)+"="+escape(value, true))
+		}
+	}
+	slices.Sort(params)
+
+	return strings.Join(params, "&")
+}
+
+// escape encodes everything but unreserved characters as required by the signature. Slashes are kept in
+// paths.
+func escape(value string, encodeSlash bool) string {
+	var sb strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			sb.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			sb.WriteByte(b)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+
+	return sb.String()
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
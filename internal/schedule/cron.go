@@ -0,0 +1,190 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	// Next returns the first activation time after t, or the zero time if there is none.
+	Next(t time.Time) time.Time
+}
+
+type Interval time.Duration
+
+func (i Interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	dayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+type cronField struct {
+	name  string
+	min   int
+	max   int
+	names []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	// 7 is an alias of Sunday.
+	{name: "day of week", min: 0, max: 7, names: dayNames},
+}
+
+// Cron is a standard five-field cron expression (minute, hour, day of month, month, day of week)
+// evaluated in the local time zone. Like in cron, a day matches if either the day of month or
+// the day of week matches, unless one of them matches every day, e.g. "*", "?" or "*/1".
+type Cron struct {
+	expr          string
+	fields        [5]uint64
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+func ParseCron(expr string) (Cron, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = macro
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return Cron{}, fmt.Errorf("invalid cron expression %q: expected %v fields, got %v", expr, len(cronFields), len(parts))
+	}
+
+	c := Cron{expr: expr}
+	for i, part := range parts {
+		bits, err := cronFields[i].parse(part)
+		if err != nil {
+			return Cron{}, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		c.fields[i] = bits
+	}
+
+	if c.fields[4]&(1<<7) != 0 {
+		c.fields[4] |= 1
+	}
+	c.anyDayOfMonth = c.fields[2] == bitRange(1, 31)
+	c.anyDayOfWeek = c.fields[4]&bitRange(0, 6) == bitRange(0, 6)
+
+	return c, nil
+}
+
+func (c Cron) String() string {
+	return c.expr
+}
+
+// Next searches minute by minute, skipping whole months, days and hours that don't match. It gives up
+// after five years, which is only possible for expressions like "0 0 30 2 *".
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !c.match(3, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !c.match(1, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !c.match(0, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c Cron) match(field, value int) bool {
+	return c.fields[field]&(1<<value) != 0
+}
+
+func (c Cron) matchDay(t time.Time) bool {
+	dayOfMonth, dayOfWeek := c.match(2, t.Day()), c.match(4, int(t.Weekday()))
+	if c.anyDayOfMonth || c.anyDayOfWeek {
+		return dayOfMonth && dayOfWeek
+	}
+
+	return dayOfMonth || dayOfWeek
+}
+
+// parse converts a comma-separated list of "*", "?", "N", "N-M" and their "/step" forms to a bit set.
+func (f cronField) parse(text string) (uint64, error) {
+	var bits uint64
+	for item := range strings.SplitSeq(text, ",") {
+		rangeText, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %v", stepText, f.name)
+			}
+		}
+
+		low, high := f.min, f.max
+		if rangeText != "*" && rangeText != "?" {
+			lowText, highText, isRange := strings.Cut(rangeText, "-")
+			var err error
+			if low, err = f.value(lowText); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = f.value(highText); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				high = f.max
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %v", rangeText, f.name)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+// bitRange returns a bit set of the values from low to high inclusive.
+func bitRange(low, high int) uint64 {
+	return (1<<(high+1) - 1) &^ (1<<low - 1)
+}
+
+func (f cronField) value(text string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(text, name) {
+			return i + f.min, nil
+		}
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %v %q (allowed: %v-%v)", f.name, text, f.min, f.max)
+	}
+
+	return value, nil
+}
@@ -0,0 +1,67 @@
+package schedule_test
+
+import (
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/schedule"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("Cron.Next", func(expr, from, expectedNext string) {
+	c, err := schedule.ParseCron(expr)
+	Expect(err).NotTo(HaveOccurred())
+
+	next := c.Next(parseTime(from))
+	if expectedNext == "" {
+		Expect(next.IsZero()).To(BeTrue())
+		return
+	}
+	Expect(next).To(Equal(parseTime(expectedNext)))
+},
+	Entry("every minute", "* * * * *", "2025-01-01 10:15:30", "2025-01-01 10:16"),
+	Entry("fixed time later today", "30 3 * * *", "2025-01-01 02:00", "2025-01-01 03:30"),
+	Entry("fixed time tomorrow", "30 3 * * *", "2025-01-01 03:30", "2025-01-02 03:30"),
+	Entry("step", "*/15 * * * *", "2025-01-01 10:16", "2025-01-01 10:30"),
+	Entry("range with step", "0 8-18/4 * * *", "2025-01-01 13:00", "2025-01-01 16:00"),
+	Entry("list", "0 1,13 * * *", "2025-01-01 02:00", "2025-01-01 13:00"),
+	Entry("day of week name", "0 0 * * sat", "2025-01-01 00:00", "2025-01-04 00:00"),
+	Entry("sunday as 7", "0 0 * * 7", "2025-01-01 00:00", "2025-01-05 00:00"),
+	Entry("month name", "0 0 1 mar *", "2025-01-01 00:00", "2025-03-01 00:00"),
+	Entry("next year", "0 0 1 1 *", "2025-01-01 00:00", "2026-01-01 00:00"),
+	Entry("day of month or day of week", "0 0 15 * mon", "2025-01-01 00:00", "2025-01-06 00:00"),
+	Entry("day of month with every day of week", "0 0 15 * */1", "2025-01-01 00:00", "2025-01-15 00:00"),
+	Entry("day of week with every day of month", "0 0 1-31 * mon", "2025-01-01 00:00", "2025-01-06 00:00"),
+	Entry("question mark", "0 0 15 * ?", "2025-01-01 00:00", "2025-01-15 00:00"),
+	Entry("leap day", "0 0 29 2 *", "2025-01-01 00:00", "2028-02-29 00:00"),
+	Entry("macro", "@daily", "2025-01-01 10:00", "2025-01-02 00:00"),
+	Entry("impossible date", "0 0 30 2 *", "2025-01-01 00:00", ""),
+)
+
+var _ = DescribeTable("ParseCron errors", func(expr, expectedError string) {
+	_, err := schedule.ParseCron(expr)
+	Expect(err).To(MatchError(ContainSubstring(expectedError)))
+},
+	Entry("wrong number of fields", "* * * *", "expected 5 fields, got 4"),
+	Entry("value out of range", "60 * * * *", `invalid minute "60" (allowed: 0-59)`),
+	Entry("unknown name", "0 0 * * someday", `invalid day of week "someday"`),
+	Entry("invalid step", "*/0 * * * *", `invalid step "0" in minute`),
+	Entry("reversed range", "0 10-5 * * *", `invalid range "10-5" in hour`),
+)
+
+var _ = Describe("Interval", func() {
+	It("adds the interval", func() {
+		Expect(schedule.Interval(time.Hour).Next(parseTime("2025-01-01 10:15"))).To(Equal(parseTime("2025-01-01 11:15")))
+	})
+})
+
+func parseTime(text string) time.Time {
+	for _, layout := range []string{time.DateTime, "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t
+		}
+	}
+
+	Fail("invalid time " + text)
+	return time.Time{}
+}
@@ -0,0 +1,27 @@
+package schedule_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var (
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+)
+
+func TestSchedule(t *testing.T) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Schedule Suite")
+}
+
+var _ = BeforeEach(func() {
+	ctx, ctxCancel = context.WithCancel(context.Background())
+	DeferCleanup(ctxCancel)
+})
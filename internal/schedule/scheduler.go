@@ -0,0 +1,76 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+type Job func(ctx context.Context)
+
+type Scheduler struct {
+	schedule Schedule
+	jitter   time.Duration
+	trigger  <-chan struct{}
+}
+
+type Option func(*Scheduler)
+
+// WithJitter delays every scheduled run by a random duration up to jitter, so that many instances
+// sharing a schedule don't hit the servers at the same time. Triggered runs start immediately.
+func WithJitter(jitter time.Duration) Option {
+	return func(s *Scheduler) {
+		s.jitter = max(jitter, 0)
+	}
+}
+
+// WithTrigger starts a run as soon as a value is received from the channel.
+func WithTrigger(trigger <-chan struct{}) Option {
+	return func(s *Scheduler) {
+		s.trigger = trigger
+	}
+}
+
+func New(schedule Schedule, opts ...Option) *Scheduler {
+	s := &Scheduler{schedule: schedule}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Run calls the job on the schedule until the context is canceled. Runs never overlap: the next run is
+// scheduled only after the job returns, and a trigger received during a run starts one more run right
+// after it.
+func (s *Scheduler) Run(ctx context.Context, job Job) error {
+	for {
+		next := s.schedule.Next(time.Now())
+		if next.IsZero() {
+			return errors.New("the schedule has no next run")
+		}
+		if s.jitter > 0 {
+			next = next.Add(rand.N(s.jitter))
+		}
+
+		slog.InfoContext(ctx, "Next run scheduled", "at", next.Format(time.RFC3339))
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-s.trigger:
+			timer.Stop()
+			slog.InfoContext(ctx, "Run triggered")
+		case <-timer.C:
+		}
+
+		job(ctx)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package schedule_test
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/AntonKosov/git-backups/internal/schedule"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type neverSchedule struct{}
+
+func (neverSchedule) Next(time.Time) time.Time {
+	return time.Time{}
+}
+
+var _ = Describe("Scheduler tests", func() {
+	var (
+		trigger chan struct{}
+		runs    atomic.Int32
+		job     schedule.Job
+		done    chan error
+	)
+
+	var start = func(s schedule.Schedule, opts ...schedule.Option) {
+		scheduler := schedule.New(s, append(opts, schedule.WithTrigger(trigger))...)
+		go func() {
+			done <- scheduler.Run(ctx, job)
+		}()
+	}
+
+	BeforeEach(func() {
+		trigger = make(chan struct{}, 1)
+		runs.Store(0)
+		job = func(context.Context) { runs.Add(1) }
+		done = make(chan error, 1)
+	})
+
+	It("runs the job on the schedule", func() {
+		start(schedule.Interval(10 * time.Millisecond))
+		Eventually(runs.Load).Should(BeNumerically(">=", 3))
+		ctxCancel()
+		Eventually(done).Should(Receive(BeNil()))
+	})
+
+	It("runs the job when triggered", func() {
+		start(schedule.Interval(time.Hour), schedule.WithJitter(time.Hour))
+		Consistently(runs.Load, 50*time.Millisecond).Should(BeZero())
+
+		trigger <- struct{}{}
+		Eventually(runs.Load).Should(Equal(int32(1)))
+		Consistently(runs.Load, 50*time.Millisecond).Should(Equal(int32(1)))
+	})
+
+	It("does not overlap runs", func() {
+		var running, overlapped atomic.Bool
+		release := make(chan struct{})
+		job = func(context.Context) {
+			if !running.CompareAndSwap(false, true) {
+				overlapped.Store(true)
+			}
+			<-release
+			runs.Add(1)
+			running.Store(false)
+		}
+
+		start(schedule.Interval(time.Hour))
+		trigger <- struct{}{}
+		Eventually(running.Load).Should(BeTrue())
+
+		// Triggers during a run are coalesced into a single follow-up run.
+		trigger <- struct{}{}
+		Expect(trigger).NotTo(BeSent(struct{}{}))
+		close(release)
+
+		Eventually(runs.Load).Should(Equal(int32(2)))
+		Consistently(runs.Load, 50*time.Millisecond).Should(Equal(int32(2)))
+		Expect(overlapped.Load()).To(BeFalse())
+	})
+
+	It("stops after the running job when the context is canceled", func() {
+		job = func(context.Context) {
+			runs.Add(1)
+			ctxCancel()
+		}
+
+		start(schedule.Interval(time.Millisecond))
+		Eventually(done).Should(Receive(BeNil()))
+		Expect(runs.Load()).To(Equal(int32(1)))
+	})
+
+	It("fails if the schedule has no next run", func() {
+		start(neverSchedule{})
+		Eventually(done).Should(Receive(MatchError("the schedule has no next run")))
+	})
+})